@@ -0,0 +1,361 @@
+// Command reporter generates reports of Grafana dashboards without going
+// through the plugin. It uses the same settings, browser and report packages
+// as the plugin and hence, reports are identical to the ones generated by
+// the plugin.
+//
+// Usage:
+//
+//	reporter -url https://grafana.example.com -dashboard <uid> -from now-7d -to now \
+//		-var host=server1 -var host=server2 -output report.pdf
+//
+// Grafana API token is read from -token flag or GRAFANA_TOKEN environment
+// variable. Report settings are read from -config file, which takes the same
+// JSON as plugin's provisioned config, and GF_REPORTER_PLUGIN_* environment
+// variables. Flags have precedence over both.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/cache"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/chrome"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/helpers"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/report"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/worker"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+// listFlag is a flag that can be repeated.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+
+	return nil
+}
+
+// options are the command line options.
+type options struct {
+	grafanaURL     string
+	grafanaVersion string
+	token          string
+	dashboardUID   string
+	from           string
+	to             string
+	variables      listFlag
+	output         string
+	configFile     string
+	remoteChrome   string
+	debug          bool
+
+	format              string
+	theme               string
+	layout              string
+	orientation         string
+	dashboardMode       string
+	timeZone            string
+	includePanelIDs     listFlag
+	excludePanelIDs     listFlag
+	includePanelDataIDs listFlag
+	skipTLSCheck        bool
+}
+
+func parseOptions(args []string) (*options, error) {
+	opts := &options{}
+
+	fs := flag.NewFlagSet("reporter", flag.ContinueOnError)
+	fs.StringVar(&opts.grafanaURL, "url", "", "URL of Grafana server (required)")
+	fs.StringVar(&opts.grafanaVersion, "grafana-version", "", "Version of Grafana server. It is fetched from Grafana API when empty")
+	fs.StringVar(&opts.token, "token", os.Getenv("GRAFANA_TOKEN"), "Grafana API token. Defaults to GRAFANA_TOKEN environment variable")
+	fs.StringVar(&opts.dashboardUID, "dashboard", "", "UID of dashboard (required)")
+	fs.StringVar(&opts.from, "from", "now-1h", "Start of time range")
+	fs.StringVar(&opts.to, "to", "now", "End of time range")
+	fs.Var(&opts.variables, "var", "Template variable as name=value. Can be repeated")
+	fs.StringVar(&opts.output, "output", "", "Path of the generated report. Defaults to report.<format>")
+	fs.StringVar(&opts.format, "format", "", "Format of the report (pdf, xlsx, zip or html)")
+	fs.StringVar(&opts.configFile, "config", "", "Path to a JSON file with report settings")
+	fs.StringVar(&opts.remoteChrome, "remote-chrome-url", "", "Remote Chrome URL. A local Chrome is started when empty")
+	fs.BoolVar(&opts.debug, "debug", false, "Enable debug logs")
+	fs.StringVar(&opts.theme, "theme", "", "Theme of the report (light or dark)")
+	fs.StringVar(&opts.layout, "layout", "", "Layout of the report (simple or grid)")
+	fs.StringVar(&opts.orientation, "orientation", "", "Orientation of the report (portrait or landscape)")
+	fs.StringVar(&opts.dashboardMode, "dashboard-mode", "", "Dashboard mode (default or full)")
+	fs.StringVar(&opts.timeZone, "time-zone", "", "Time zone of the report")
+	fs.Var(&opts.includePanelIDs, "include-panel", "ID of panel to include in the report. Can be repeated")
+	fs.Var(&opts.excludePanelIDs, "exclude-panel", "ID of panel to exclude from the report. Can be repeated")
+	fs.Var(&opts.includePanelDataIDs, "include-panel-data", "ID of panel to include data as table. Can be repeated")
+	fs.BoolVar(&opts.skipTLSCheck, "skip-tls-verify", false, "Skip TLS verification of Grafana server")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if opts.grafanaURL == "" || opts.dashboardUID == "" {
+		return nil, errors.New("-url and -dashboard are required")
+	}
+
+	for _, v := range opts.variables {
+		if name, _, ok := strings.Cut(v, "="); !ok || name == "" {
+			return nil, fmt.Errorf("invalid variable %q: must be name=value", v)
+		}
+	}
+
+	return opts, nil
+}
+
+// query returns template variables and time range as query parameters
+// of Grafana dashboard URL.
+func (o *options) query() url.Values {
+	values := url.Values{}
+
+	for _, v := range o.variables {
+		name, value, _ := strings.Cut(v, "=")
+		if !strings.HasPrefix(name, "var-") {
+			name = "var-" + name
+		}
+
+		values.Add(name, value)
+	}
+
+	values.Set("from", o.from)
+	values.Set("to", o.to)
+
+	return values
+}
+
+// loadConfig returns the report settings from config file and environment
+// variables, overridden by command line options.
+func (o *options) loadConfig(ctx context.Context) (config.Config, error) {
+	settings := backend.AppInstanceSettings{}
+
+	if o.configFile != "" {
+		data, err := os.ReadFile(o.configFile)
+		if err != nil {
+			return config.Config{}, fmt.Errorf("failed to read config file: %w", err)
+		}
+
+		if !json.Valid(data) {
+			return config.Config{}, fmt.Errorf("config file %s is not a valid JSON", o.configFile)
+		}
+
+		settings.JSONData = data
+	}
+
+	conf, err := config.Load(ctx, settings)
+	if err != nil {
+		return config.Config{}, err
+	}
+
+	for _, opt := range []struct {
+		value string
+		dest  *string
+	}{
+		{o.format, &conf.Format},
+		{o.theme, &conf.Theme},
+		{o.layout, &conf.Layout},
+		{o.orientation, &conf.Orientation},
+		{o.dashboardMode, &conf.DashboardMode},
+		{o.timeZone, &conf.TimeZone},
+		{o.remoteChrome, &conf.RemoteChromeURL},
+	} {
+		if opt.value != "" {
+			*opt.dest = opt.value
+		}
+	}
+
+	if o.skipTLSCheck {
+		conf.SkipTLSCheck = true
+		conf.HTTPClientOptions.TLS.InsecureSkipVerify = true
+	}
+
+	conf.Token = o.token
+	conf.AppURL = strings.TrimSuffix(o.grafanaURL, "/")
+
+	if err := conf.Validate(); err != nil {
+		return config.Config{}, fmt.Errorf("invalid options: %w", err)
+	}
+
+	if o.output == "" {
+		o.output = "report." + conf.Format
+	}
+
+	return conf, nil
+}
+
+// grafanaVersion returns the version of Grafana server from its health API.
+func grafanaVersion(ctx context.Context, httpClient *http.Client, appURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, appURL+"/api/health", nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("health API returned %s", resp.Status)
+	}
+
+	var health struct {
+		Version string `json:"version"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return "", fmt.Errorf("failed to decode health API response: %w", err)
+	}
+
+	return health.Version, nil
+}
+
+func run(ctx context.Context, logger log.Logger, opts *options) error {
+	conf, err := opts.loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	httpClient, err := httpclient.New(conf.HTTPClientOptions)
+	if err != nil {
+		return fmt.Errorf("error in httpclient new: %w", err)
+	}
+	defer httpClient.CloseIdleConnections()
+
+	version := opts.grafanaVersion
+	if version == "" {
+		if version, err = grafanaVersion(ctx, httpClient, conf.AppURL); err != nil {
+			return fmt.Errorf("failed to get Grafana version: %w", err)
+		}
+	}
+
+	semVer := "v" + strings.TrimPrefix(version, "v")
+
+	// Panel IDs depend on Grafana version
+	conf.IncludePanelIDs = helpers.PanelIDs(semVer, opts.includePanelIDs)
+	conf.ExcludePanelIDs = helpers.PanelIDs(semVer, opts.excludePanelIDs)
+	conf.IncludePanelDataIDs = helpers.PanelIDs(semVer, opts.includePanelDataIDs)
+
+	logger.Debug("generate report using config: " + conf.String())
+
+	authHeader := http.Header{}
+	if conf.Token != "" {
+		authHeader.Set(backend.OAuthIdentityTokenHeaderName, "Bearer "+conf.Token)
+	}
+
+	model, err := dashboard.FetchModel(ctx, httpClient, conf.AppURL, opts.dashboardUID, authHeader, opts.query())
+	if err != nil {
+		return fmt.Errorf("failed to get dashboard JSON model: %w", err)
+	}
+
+	var chromeInstance chrome.Instance
+
+	switch len(conf.RemoteChromeURLs) {
+	case 0:
+		chromeInstance, err = chrome.NewLocalBrowserInstance(ctx, logger, conf.SkipTLSCheck)
+	case 1:
+		chromeInstance, err = chrome.NewRemoteBrowserInstance(ctx, logger, conf.RemoteChromeURLs[0])
+	default:
+		chromeInstance, err = chrome.NewPoolBrowserInstance(ctx, logger, conf.RemoteChromeURLs, conf.RemoteChromeBalancing)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to start browser: %w", err)
+	}
+	defer chromeInstance.Close(logger)
+
+	workerPools := worker.Pools{
+		worker.Browser:  worker.New(ctx, worker.Browser, conf.MaxBrowserWorkers, conf.MaxQueuedTasks),
+		worker.Renderer: worker.New(ctx, worker.Renderer, conf.MaxRenderWorkers, conf.MaxQueuedTasks),
+	}
+	defer func() {
+		for _, pool := range workerPools {
+			pool.Done()
+		}
+	}()
+
+	panelCache, err := cache.New(&conf)
+	if err != nil {
+		logger.Warn("failed to create panel cache", "err", err)
+	}
+
+	grafanaDashboard, err := dashboard.New(logger, &conf, httpClient, chromeInstance, conf.AppURL, semVer, model, authHeader, panelCache)
+	if err != nil {
+		return fmt.Errorf("failed to create a new dashboard: %w", err)
+	}
+
+	pdfReport := report.New(logger, &conf, httpClient, chromeInstance, workerPools, grafanaDashboard)
+
+	// Write to a temporary file so that a failed report does not leave
+	// a partial report behind
+	f, err := os.CreateTemp(filepath.Dir(opts.output), ".reporter-*")
+	if err != nil {
+		return fmt.Errorf("failed to create report file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if err := pdfReport.Generate(ctx, f); err != nil {
+		f.Close()
+
+		return fmt.Errorf("error generating report: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write report file: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), opts.output); err != nil {
+		return fmt.Errorf("failed to save report file: %w", err)
+	}
+
+	logger.Info("report generated", "dash_uid", opts.dashboardUID, "output", opts.output, "size", pdfReport.Size())
+
+	return nil
+}
+
+func main() {
+	opts, err := parseOptions(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "reporter:", err)
+		os.Exit(2)
+	}
+
+	level := log.Info
+	if opts.debug {
+		level = log.Debug
+	}
+
+	logger := log.NewWithLevel(level)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, logger, opts); err != nil {
+		logger.Error("failed to generate report", "err", err)
+		stop()
+		os.Exit(1)
+	}
+}
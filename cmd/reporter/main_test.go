@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOptions(t *testing.T) {
+	Convey("When parsing command line options", t, func() {
+		Convey("Grafana URL and dashboard UID should be required", func() {
+			_, err := parseOptions([]string{"-url", "http://localhost:3000"})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Invalid variables should be rejected", func() {
+			_, err := parseOptions([]string{"-url", "http://localhost:3000", "-dashboard", "abc", "-var", "host"})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Variables and time range should be converted to query parameters", func() {
+			opts, err := parseOptions([]string{
+				"-url", "http://localhost:3000", "-dashboard", "abc", "-from", "now-7d",
+				"-var", "host=server1", "-var", "host=server2", "-var", "var-env=a=b",
+			})
+			So(err, ShouldBeNil)
+
+			q := opts.query()
+			So(q["var-host"], ShouldResemble, []string{"server1", "server2"})
+			So(q.Get("var-env"), ShouldEqual, "a=b")
+			So(q.Get("from"), ShouldEqual, "now-7d")
+			So(q.Get("to"), ShouldEqual, "now")
+		})
+
+		Convey("Flags should override report settings", func() {
+			opts, err := parseOptions([]string{
+				"-url", "http://localhost:3000/", "-dashboard", "abc", "-theme", "dark", "-layout", "grid", "-token", "secret",
+			})
+			So(err, ShouldBeNil)
+
+			conf, err := opts.loadConfig(t.Context())
+			So(err, ShouldBeNil)
+			So(conf.Theme, ShouldEqual, "dark")
+			So(conf.Layout, ShouldEqual, "grid")
+			So(conf.Orientation, ShouldEqual, "portrait")
+			So(conf.AppURL, ShouldEqual, "http://localhost:3000")
+			So(conf.Token, ShouldEqual, "secret")
+			So(conf.Format, ShouldEqual, "pdf")
+			So(opts.output, ShouldEqual, "report.pdf")
+		})
+
+		Convey("Invalid report settings should be rejected", func() {
+			opts, err := parseOptions([]string{"-url", "http://localhost:3000", "-dashboard", "abc", "-theme", "blue"})
+			So(err, ShouldBeNil)
+
+			_, err = opts.loadConfig(t.Context())
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
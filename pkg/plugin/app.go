@@ -6,8 +6,12 @@ import (
 	"net/http"
 	"sync"
 
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/cache"
 	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/chrome"
 	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/delivery"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/jobs"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/scheduler"
 	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/worker"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
@@ -44,7 +48,20 @@ type App struct {
 
 	workerPools    worker.Pools
 	chromeInstance chrome.Instance
+	panelCache     cache.Cache
 	ctxLogger      log.Logger
+
+	// Org of the current app instance and Grafana config at the time
+	// of creation of app instance. They are used by background tasks
+	// that do not have a request context.
+	orgID         int64
+	grafanaConfig *backend.GrafanaCfg
+
+	scheduleStore *scheduler.Store
+	scheduler     *scheduler.Scheduler
+
+	jobs     *jobs.Manager
+	webhooks *delivery.Webhooks
 }
 
 // NewDashboardReporterApp creates a new example *App instance.
@@ -85,7 +102,8 @@ func NewDashboardReporterApp(ctx context.Context, settings backend.AppInstanceSe
 		app.ctxLogger.Debug("got grafana version from backend user agent", "version", app.grafanaSemVer)
 	}
 
-	// Make a new HTTP client
+	// Make a new HTTP client. Its default middlewares trace the requests and
+	// propagate the trace context to Grafana and image renderer
 	if app.httpClient, err = httpclient.New(app.conf.HTTPClientOptions); err != nil {
 		return nil, fmt.Errorf("error in httpclient new: %w", err)
 	}
@@ -109,18 +127,25 @@ func NewDashboardReporterApp(ctx context.Context, settings backend.AppInstanceSe
 	// Create a new browser instance
 	var chromeInstance chrome.Instance
 
-	switch app.conf.RemoteChromeURL {
-	case "":
+	switch len(app.conf.RemoteChromeURLs) {
+	case 0:
 		chromeInstance, err = chrome.NewLocalBrowserInstance(
 			context.Background(),
 			app.ctxLogger,
 			app.conf.HTTPClientOptions.TLS.InsecureSkipVerify,
 		)
-	default:
+	case 1:
 		chromeInstance, err = chrome.NewRemoteBrowserInstance(
 			context.Background(),
 			app.ctxLogger,
-			app.conf.RemoteChromeURL,
+			app.conf.RemoteChromeURLs[0],
+		)
+	default:
+		chromeInstance, err = chrome.NewPoolBrowserInstance(
+			context.Background(),
+			app.ctxLogger,
+			app.conf.RemoteChromeURLs,
+			app.conf.RemoteChromeBalancing,
 		)
 	}
 
@@ -133,6 +158,11 @@ func NewDashboardReporterApp(ctx context.Context, settings backend.AppInstanceSe
 	// Use the same browser instance for all API requests
 	app.chromeInstance = chromeInstance
 
+	// Reports can still be generated without cache, so do not return an error
+	if app.panelCache, err = cache.New(&app.conf); err != nil {
+		app.ctxLogger.Error("failed to create panel cache", "err", err)
+	}
+
 	// Span Worker Pool across multiple instances
 	// Seems like context passed by App instance is closing channel at the end of
 	// request which I dont understand.
@@ -140,10 +170,27 @@ func NewDashboardReporterApp(ctx context.Context, settings backend.AppInstanceSe
 	// safely disposing both workers and chrome instances in dispose() method, we are
 	// sure that there wont be any leaks.
 	app.workerPools = worker.Pools{
-		worker.Browser:  worker.New(context.Background(), app.conf.MaxBrowserWorkers),
-		worker.Renderer: worker.New(context.Background(), app.conf.MaxRenderWorkers),
+		worker.Browser:  worker.New(context.Background(), worker.Browser, app.conf.MaxBrowserWorkers, app.conf.MaxQueuedTasks),
+		worker.Renderer: worker.New(context.Background(), worker.Renderer, app.conf.MaxRenderWorkers, app.conf.MaxQueuedTasks),
 	}
 
+	// Start scheduler for the reports of current org. There will be an
+	// app instance per org and hence, each instance handles the schedules
+	// of its own org. Reports can still be generated when scheduler fails
+	// to start, so do not return an error.
+	app.orgID = backend.PluginConfigFromContext(ctx).OrgID
+	app.grafanaConfig = backend.GrafanaConfigFromContext(ctx)
+
+	if err := app.startScheduler(context.Background()); err != nil {
+		app.ctxLogger.Error("failed to start report scheduler", "err", err)
+	}
+
+	// Reports of jobs are generated in background and hence, use background
+	// context like workers
+	app.startJobs(context.Background())
+
+	app.webhooks = delivery.NewWebhooks(context.Background(), app.ctxLogger, &app.conf)
+
 	return &app, nil
 }
 
@@ -153,6 +200,19 @@ func (app *App) Dispose() {
 	// Clean up idle connections
 	app.httpClient.CloseIdleConnections()
 
+	// Stop scheduler and jobs before stopping workers
+	if app.scheduler != nil {
+		app.scheduler.Stop()
+	}
+
+	if app.jobs != nil {
+		app.jobs.Stop()
+	}
+
+	if app.webhooks != nil {
+		app.webhooks.Stop()
+	}
+
 	if app.workerPools != nil {
 		for _, pool := range app.workerPools {
 			pool.Done()
@@ -167,11 +227,3 @@ func (app *App) Dispose() {
 	app.ctxLogger.Info("disposing chromium from old plugin app instance")
 	app.chromeInstance.Close(app.ctxLogger)
 }
-
-// CheckHealth handles health checks sent from Grafana to the plugin.
-func (app *App) CheckHealth(_ context.Context, _ *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
-	return &backend.CheckHealthResult{
-		Status:  backend.HealthStatusOk,
-		Message: "ok",
-	}, nil
-}
@@ -0,0 +1,48 @@
+// Package cache implements size bounded caches whose entries expire after a
+// TTL. They are used to reuse panel PNGs and CSV data across reports.
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
+)
+
+// Backends of cache.
+const (
+	None   = "none"
+	Memory = "memory"
+	Disk   = "disk"
+)
+
+// Cache stores values by key. Values that are not retrieved in time might
+// be evicted to respect the size limit of cache.
+type Cache interface {
+	// Get returns the value of key and true, or false when key is not
+	// cached or its value has expired.
+	Get(key string) ([]byte, bool)
+	// Set caches the value of key.
+	Set(key string, value []byte) error
+}
+
+// New returns the panel cache configured in conf. It returns nil when panel
+// cache is disabled.
+func New(conf *config.Config) (Cache, error) {
+	maxSize := int64(conf.PanelCacheMaxSize) * 1024 * 1024
+	ttl := time.Duration(conf.PanelCacheTTL) * time.Second
+
+	switch conf.PanelCache {
+	case Memory:
+		return NewMemory(maxSize, ttl), nil
+	case Disk:
+		c, err := NewDisk(conf.PanelCachePath, maxSize, ttl)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create disk cache: %w", err)
+		}
+
+		return c, nil
+	default:
+		return nil, nil //nolint:nilnil
+	}
+}
@@ -0,0 +1,146 @@
+package cache
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMemoryCache(t *testing.T) {
+	Convey("When caching values in memory", t, func() {
+		c := NewMemory(10, time.Hour)
+
+		So(c.Set("a", []byte("aaaa")), ShouldBeNil)
+		So(c.Set("b", []byte("bbbb")), ShouldBeNil)
+
+		Convey("Cached values should be returned", func() {
+			value, ok := c.Get("a")
+			So(ok, ShouldBeTrue)
+			So(string(value), ShouldEqual, "aaaa")
+
+			_, ok = c.Get("c")
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("Least recently used values should be evicted when cache is full", func() {
+			_, ok := c.Get("a")
+			So(ok, ShouldBeTrue)
+
+			So(c.Set("c", []byte("cccc")), ShouldBeNil)
+
+			_, ok = c.Get("b")
+			So(ok, ShouldBeFalse)
+
+			_, ok = c.Get("a")
+			So(ok, ShouldBeTrue)
+			So(c.size, ShouldEqual, 8)
+		})
+
+		Convey("Values larger than cache should be ignored", func() {
+			So(c.Set("c", []byte(strings.Repeat("c", 11))), ShouldBeNil)
+
+			_, ok := c.Get("c")
+			So(ok, ShouldBeFalse)
+
+			_, ok = c.Get("a")
+			So(ok, ShouldBeTrue)
+		})
+	})
+
+	Convey("When values in memory expire", t, func() {
+		c := NewMemory(10, 10*time.Millisecond)
+
+		So(c.Set("a", []byte("aaaa")), ShouldBeNil)
+		time.Sleep(20 * time.Millisecond)
+
+		Convey("They should not be returned", func() {
+			_, ok := c.Get("a")
+			So(ok, ShouldBeFalse)
+			So(c.size, ShouldEqual, 0)
+		})
+	})
+}
+
+func TestDiskCache(t *testing.T) {
+	Convey("When caching values on disk", t, func() {
+		dir := t.TempDir()
+
+		c, err := NewDisk(dir, 10, time.Hour)
+		So(err, ShouldBeNil)
+
+		So(c.Set("a", []byte("aaaa")), ShouldBeNil)
+		So(c.Set("b", []byte("bbbb")), ShouldBeNil)
+
+		Convey("Cached values should be returned", func() {
+			value, ok := c.Get("a")
+			So(ok, ShouldBeTrue)
+			So(string(value), ShouldEqual, "aaaa")
+
+			_, ok = c.Get("c")
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("Cached values should be reused by a new cache", func() {
+			c, err := NewDisk(dir, 10, time.Hour)
+			So(err, ShouldBeNil)
+
+			value, ok := c.Get("b")
+			So(ok, ShouldBeTrue)
+			So(string(value), ShouldEqual, "bbbb")
+		})
+
+		Convey("Oldest values should be evicted when cache is full", func() {
+			So(c.Set("c", []byte("cccc")), ShouldBeNil)
+
+			_, ok := c.Get("a")
+			So(ok, ShouldBeFalse)
+
+			_, ok = c.Get("c")
+			So(ok, ShouldBeTrue)
+
+			files, err := os.ReadDir(dir)
+			So(err, ShouldBeNil)
+			So(files, ShouldHaveLength, 2)
+		})
+
+		Convey("Expired values should be removed by a new cache", func() {
+			time.Sleep(20 * time.Millisecond)
+
+			_, err := NewDisk(dir, 10, 10*time.Millisecond)
+			So(err, ShouldBeNil)
+
+			files, err := os.ReadDir(dir)
+			So(err, ShouldBeNil)
+			So(files, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestNew(t *testing.T) {
+	Convey("When creating panel cache from config", t, func() {
+		conf := config.Config{PanelCache: None, PanelCacheMaxSize: 1, PanelCacheTTL: 60}
+
+		Convey("No cache should be returned when it is disabled", func() {
+			c, err := New(&conf)
+			So(err, ShouldBeNil)
+			So(c, ShouldBeNil)
+		})
+
+		Convey("Configured backend should be returned", func() {
+			conf.PanelCache = Memory
+			c, err := New(&conf)
+			So(err, ShouldBeNil)
+			So(c, ShouldHaveSameTypeAs, &MemoryCache{})
+
+			conf.PanelCache = Disk
+			conf.PanelCachePath = t.TempDir()
+			c, err = New(&conf)
+			So(err, ShouldBeNil)
+			So(c, ShouldHaveSameTypeAs, &DiskCache{})
+		})
+	})
+}
@@ -0,0 +1,196 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// diskEntry is a value cached in a file.
+type diskEntry struct {
+	size    int64
+	written time.Time
+}
+
+// DiskCache is a cache that stores values in files of a directory, so that
+// they survive restarts. When it is full, oldest values are evicted.
+type DiskCache struct {
+	dir     string
+	maxSize int64
+	ttl     time.Duration
+
+	mu      sync.Mutex
+	size    int64
+	entries map[string]diskEntry // By file name
+}
+
+// NewDisk returns a new cache that stores at most maxSize bytes in dir.
+// Values expire after ttl. Values cached in dir by a previous instance are
+// reused.
+func NewDisk(dir string, maxSize int64, ttl time.Duration) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory %s: %w", dir, err)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache directory %s: %w", dir, err)
+	}
+
+	c := &DiskCache{
+		dir:     dir,
+		maxSize: maxSize,
+		ttl:     ttl,
+		entries: make(map[string]diskEntry),
+	}
+
+	for _, file := range files {
+		// Ignore temporary files of interrupted writes
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+
+		c.entries[file.Name()] = diskEntry{size: info.Size(), written: info.ModTime()}
+		c.size += info.Size()
+	}
+
+	c.mu.Lock()
+	c.evict()
+	c.mu.Unlock()
+
+	return c, nil
+}
+
+// Get returns the value of key and true, or false when key is not cached
+// or its value has expired.
+func (c *DiskCache) Get(key string) ([]byte, bool) {
+	name := fileName(key)
+
+	c.mu.Lock()
+	entry, ok := c.entries[name]
+
+	if ok && time.Since(entry.written) > c.ttl {
+		c.remove(name)
+
+		ok = false
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	value, err := os.ReadFile(filepath.Join(c.dir, name))
+	if err != nil {
+		return nil, false
+	}
+
+	return value, true
+}
+
+// Set caches the value of key. Values larger than the cache are ignored.
+func (c *DiskCache) Set(key string, value []byte) error {
+	if int64(len(value)) > c.maxSize {
+		return nil
+	}
+
+	name := fileName(key)
+
+	// Write to a temporary file first so that readers never see partial values
+	f, err := os.CreateTemp(c.dir, ".tmp-"+name+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to create cache file: %w", err)
+	}
+
+	_, err = f.Write(value)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(c.dir, name))
+	}
+
+	if err != nil {
+		os.Remove(f.Name()) //nolint:errcheck
+
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.entries[name]; ok {
+		c.size -= entry.size
+	}
+
+	c.entries[name] = diskEntry{size: int64(len(value)), written: time.Now()}
+	c.size += int64(len(value))
+
+	c.evict()
+
+	return nil
+}
+
+// evict removes expired values and then oldest values until cache fits in
+// its size limit. It must be called with mu held.
+func (c *DiskCache) evict() {
+	names := make([]string, 0, len(c.entries))
+
+	for name, entry := range c.entries {
+		if time.Since(entry.written) > c.ttl {
+			c.remove(name)
+
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	if c.size <= c.maxSize {
+		return
+	}
+
+	slices.SortFunc(names, func(a, b string) int {
+		return c.entries[a].written.Compare(c.entries[b].written)
+	})
+
+	for _, name := range names {
+		if c.size <= c.maxSize {
+			break
+		}
+
+		c.remove(name)
+	}
+}
+
+// remove removes the value of file name from cache. It must be called with
+// mu held.
+func (c *DiskCache) remove(name string) {
+	if err := os.Remove(filepath.Join(c.dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return
+	}
+
+	c.size -= c.entries[name].size
+	delete(c.entries, name)
+}
+
+// fileName returns the name of the file of key. Keys are hashed as they
+// might be long and contain characters that are not allowed in file names.
+func fileName(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// memoryEntry is a value cached in memory.
+type memoryEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// MemoryCache is an in-memory cache. When it is full, least recently used
+// values are evicted.
+type MemoryCache struct {
+	maxSize int64
+	ttl     time.Duration
+
+	mu      sync.Mutex
+	size    int64
+	entries map[string]*list.Element
+	lru     *list.List // Most recently used entry at front
+}
+
+// NewMemory returns a new in-memory cache of at most maxSize bytes whose
+// values expire after ttl.
+func NewMemory(maxSize int64, ttl time.Duration) *MemoryCache {
+	return &MemoryCache{
+		maxSize: maxSize,
+		ttl:     ttl,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Get returns the value of key and true, or false when key is not cached
+// or its value has expired.
+func (c *MemoryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*memoryEntry) //nolint:forcetypeassert
+	if time.Now().After(entry.expires) {
+		c.remove(elem)
+
+		return nil, false
+	}
+
+	c.lru.MoveToFront(elem)
+
+	return entry.value, true
+}
+
+// Set caches the value of key. Values larger than the cache are ignored.
+func (c *MemoryCache) Set(key string, value []byte) error {
+	if int64(len(value)) > c.maxSize {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+
+	c.entries[key] = c.lru.PushFront(&memoryEntry{
+		key:     key,
+		value:   value,
+		expires: time.Now().Add(c.ttl),
+	})
+	c.size += int64(len(value))
+
+	for c.size > c.maxSize {
+		c.remove(c.lru.Back())
+	}
+
+	return nil
+}
+
+// remove removes the entry of elem from cache.
+func (c *MemoryCache) remove(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*memoryEntry) //nolint:forcetypeassert
+
+	delete(c.entries, entry.key)
+	c.size -= int64(len(entry.value))
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/helpers"
 	"github.com/chromedp/chromedp"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
 	"golang.org/x/net/context"
@@ -31,15 +32,11 @@ func init() {
 	}
 
 	// Get Grafana data path based on path of current executable
-	pluginExe, err := os.Executable()
+	dataPath, err := helpers.GrafanaDataPath()
 	if err != nil {
 		panic(err)
 	}
 
-	// Generally this pluginExe should be at install_dir/plugins/mahendrapaipuri-dashboardreporter-app/exe
-	// Now we attempt to get install_dir directory which is Grafana data path
-	dataPath := filepath.Dir(filepath.Dir(filepath.Dir(pluginExe)))
-
 	// Create a folder to use it as HOME for chrome process
 	homeDir := filepath.Join(dataPath, ".chrome")
 	if err := os.MkdirAll(homeDir, 0o750); err == nil {
@@ -82,10 +79,10 @@ func init() {
 	)
 }
 
-// LocalInstance is a locally running browser instance.
+// LocalInstance is a locally running browser instance. Browser is restarted
+// with the same options when it crashes.
 type LocalInstance struct {
-	allocCtx   context.Context
-	browserCtx context.Context
+	supervisor *supervisor
 }
 
 // NewLocalBrowserInstance creates a new local browser instance.
@@ -147,23 +144,35 @@ func NewLocalBrowserInstance(ctx context.Context, logger log.Logger, insecureSki
 		it is not normal that these will be updated regularly. So, we can live with
 		this side-effect without running into deep issues.
 	*/
-	allocCtx, _ := chromedp.NewExecAllocator(ctx, chromeOptions...)
-
-	// start a browser (and an empty tab) so we can add more tabs to the browser
 	chromeLogger := logger.With("subsystem", "chromium")
-	browserCtx, _ := chromedp.NewContext(allocCtx,
-		chromedp.WithErrorf(chromeLogger.Error),
-		chromedp.WithLogf(chromeLogger.Debug),
-	)
 
-	if err := chromedp.Run(browserCtx); err != nil {
-		return nil, fmt.Errorf("couldn't create browser context: %w", err)
+	start := func() (context.Context, context.CancelFunc, error) {
+		allocCtx, allocCtxCancel := chromedp.NewExecAllocator(ctx, chromeOptions...)
+
+		// start a browser (and an empty tab) so we can add more tabs to the browser
+		browserCtx, _ := chromedp.NewContext(allocCtx,
+			chromedp.WithErrorf(chromeLogger.Error),
+			chromedp.WithLogf(chromeLogger.Debug),
+		)
+
+		if err := chromedp.Run(browserCtx); err != nil {
+			allocCtxCancel()
+
+			return nil, nil, fmt.Errorf("couldn't create browser context: %w", err)
+		}
+
+		return browserCtx, allocCtxCancel, nil
+	}
+
+	instance := &LocalInstance{
+		supervisor: newSupervisor(chromeLogger, start),
+	}
+
+	if err := instance.supervisor.Start(true); err != nil {
+		return nil, err
 	}
 
-	return &LocalInstance{
-		allocCtx,
-		browserCtx,
-	}, nil
+	return instance, nil
 }
 
 // Name returns the kind of browser instance.
@@ -171,19 +180,28 @@ func (i *LocalInstance) Name() string {
 	return "local"
 }
 
-// NewTab starts and returns a new tab on current browser instance.
-func (i *LocalInstance) NewTab(_ log.Logger, _ *config.Config) *Tab {
-	ctx, _ := chromedp.NewContext(i.browserCtx)
+// NewTab starts and returns a new tab on current browser instance. When
+// browser is restarting, it waits for the restart to finish.
+func (i *LocalInstance) NewTab(ctx context.Context, _ log.Logger, conf *config.Config) *Tab {
+	target, cancelTarget := chromedp.NewContext(i.supervisor.Browser(ctx, browserWait(conf)))
 
-	return &Tab{
-		ctx: ctx,
-	}
+	return newTab(ctx, target, cancelTarget)
 }
 
 func (i *LocalInstance) Close(logger log.Logger) {
-	if i.browserCtx != nil {
-		if err := chromedp.Cancel(i.browserCtx); err != nil {
+	if i.supervisor == nil {
+		return
+	}
+
+	browserCtx, cancel := i.supervisor.Close()
+
+	if browserCtx != nil {
+		if err := chromedp.Cancel(browserCtx); err != nil {
 			logger.Error("got error from cancel browser context", "error", err)
 		}
 	}
+
+	if cancel != nil {
+		cancel()
+	}
 }
@@ -0,0 +1,235 @@
+package chrome
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"github.com/chromedp/chromedp"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"golang.org/x/net/context"
+)
+
+// Strategies to spread new tabs across the browsers of a pool.
+const (
+	RoundRobin = "round-robin"
+	LeastTabs  = "least-tabs"
+)
+
+// Health checks of the browsers of a pool.
+var (
+	poolHealthCheckInterval = 10 * time.Second
+	poolHealthCheckTimeout  = 5 * time.Second
+)
+
+// probeFunc returns an error when browser instance is not healthy.
+type probeFunc func(instance Instance) error
+
+// poolEndpoint is a browser of a pool.
+type poolEndpoint struct {
+	url      string
+	instance Instance
+	tabs     int
+	ejected  bool
+}
+
+// PoolInstance is a pool of remote browser instances. New tabs are spread
+// across the browsers of the pool. Browsers are health checked periodically
+// and failing ones are ejected from the pool until they are healthy again.
+type PoolInstance struct {
+	logger   log.Logger
+	strategy string
+	probe    probeFunc
+
+	mu        sync.Mutex
+	endpoints []*poolEndpoint
+	next      int // Index of the endpoint to start looking from for next tab
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewPoolBrowserInstance creates a new pool of remote browser instances.
+// Strategy is either round-robin or least-tabs.
+func NewPoolBrowserInstance(ctx context.Context, logger log.Logger, remoteChromeURLs []string, strategy string) (*PoolInstance, error) {
+	endpoints := make([]*poolEndpoint, 0, len(remoteChromeURLs))
+
+	for _, remoteChromeURL := range remoteChromeURLs {
+		instance, err := NewRemoteBrowserInstance(ctx, logger.With("remote_chrome_url", remoteChromeURL), remoteChromeURL)
+		if err != nil {
+			for _, endpoint := range endpoints {
+				endpoint.instance.Close(logger)
+			}
+
+			return nil, fmt.Errorf("failed to create remote browser instance for %s: %w", remoteChromeURL, err)
+		}
+
+		endpoints = append(endpoints, &poolEndpoint{url: remoteChromeURL, instance: instance})
+	}
+
+	return newPoolInstance(logger, endpoints, strategy, probeInstance), nil
+}
+
+func newPoolInstance(logger log.Logger, endpoints []*poolEndpoint, strategy string, probe probeFunc) *PoolInstance {
+	pool := &PoolInstance{
+		logger:    logger.With("subsystem", "chromium_pool"),
+		strategy:  strategy,
+		probe:     probe,
+		endpoints: endpoints,
+		done:      make(chan struct{}),
+	}
+
+	pool.wg.Add(1)
+
+	go pool.monitor()
+
+	return pool
+}
+
+// Name returns the kind of browser instance.
+func (p *PoolInstance) Name() string {
+	return "pool"
+}
+
+// NewTab starts and returns a new tab on one of the browsers of the pool.
+func (p *PoolInstance) NewTab(ctx context.Context, logger log.Logger, conf *config.Config) *Tab {
+	endpoint := p.pick()
+
+	tab := endpoint.instance.NewTab(ctx, logger, conf)
+	tab.onClose = func() {
+		p.mu.Lock()
+		endpoint.tabs--
+		p.mu.Unlock()
+	}
+
+	return tab
+}
+
+// Close releases the resources of all browsers of the pool. It is safe to
+// call Close more than once.
+func (p *PoolInstance) Close(logger log.Logger) {
+	p.closeOnce.Do(func() {
+		close(p.done)
+		p.wg.Wait()
+
+		for _, endpoint := range p.endpoints {
+			endpoint.instance.Close(logger)
+		}
+	})
+}
+
+// pick returns the endpoint for a new tab and accounts the tab to it. When
+// every endpoint is ejected, all of them are considered as a last resort.
+func (p *PoolInstance) pick() *poolEndpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	includeEjected := true
+
+	for _, endpoint := range p.endpoints {
+		if !endpoint.ejected {
+			includeEjected = false
+
+			break
+		}
+	}
+
+	if includeEjected {
+		p.logger.Warn("all remote browsers of the pool are failing health checks")
+	}
+
+	picked := -1
+
+	// Look from the endpoint after the last picked one so that ties of
+	// least-tabs are broken in turn as well
+	for i := range p.endpoints {
+		idx := (p.next + i) % len(p.endpoints)
+		endpoint := p.endpoints[idx]
+
+		if endpoint.ejected && !includeEjected {
+			continue
+		}
+
+		if picked == -1 || endpoint.tabs < p.endpoints[picked].tabs {
+			picked = idx
+		}
+
+		if p.strategy != LeastTabs {
+			break
+		}
+	}
+
+	p.next = (picked + 1) % len(p.endpoints)
+	p.endpoints[picked].tabs++
+
+	return p.endpoints[picked]
+}
+
+// monitor health checks the browsers of the pool until pool is closed.
+func (p *PoolInstance) monitor() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(poolHealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.checkEndpoints()
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// checkEndpoints health checks all browsers of the pool concurrently and
+// ejects the failing ones. Ejected browsers are restored once they pass
+// health checks.
+func (p *PoolInstance) checkEndpoints() {
+	errs := make([]error, len(p.endpoints))
+
+	var wg sync.WaitGroup
+
+	for i, endpoint := range p.endpoints {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			errs[i] = p.probe(endpoint.instance)
+		}()
+	}
+
+	wg.Wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, endpoint := range p.endpoints {
+		switch {
+		case errs[i] != nil && !endpoint.ejected:
+			p.logger.Warn("ejecting remote browser from pool", "url", endpoint.url, "err", errs[i])
+		case errs[i] == nil && endpoint.ejected:
+			p.logger.Info("restoring remote browser in pool", "url", endpoint.url)
+		}
+
+		endpoint.ejected = errs[i] != nil
+	}
+}
+
+// probeInstance checks that a new tab can be opened in browser instance.
+func probeInstance(instance Instance) error {
+	logger := log.NewNullLogger()
+
+	tab := instance.NewTab(context.Background(), logger, nil)
+	tab.WithTimeout(poolHealthCheckTimeout)
+	defer tab.Close(logger)
+
+	if err := tab.Run(chromedp.Navigate("about:blank")); err != nil {
+		return fmt.Errorf("failed to open a tab: %w", err)
+	}
+
+	return nil
+}
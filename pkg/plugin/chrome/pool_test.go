@@ -0,0 +1,220 @@
+package chrome
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeInstance is a browser instance whose tabs do not run any browser.
+type fakeInstance struct {
+	name   string
+	closed bool
+}
+
+func (i *fakeInstance) NewTab(ctx context.Context, _ log.Logger, _ *config.Config) *Tab {
+	return newTab(ctx, context.Background(), func() {})
+}
+func (i *fakeInstance) Name() string       { return i.name }
+func (i *fakeInstance) Close(_ log.Logger) { i.closed = true }
+
+// fakeProbe fails health checks of the instances set as failing.
+type fakeProbe struct {
+	mu      sync.Mutex
+	failing map[string]bool
+}
+
+func (f *fakeProbe) probe(instance Instance) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failing[instance.Name()] {
+		return errors.New("unreachable")
+	}
+
+	return nil
+}
+
+func (f *fakeProbe) fail(name string, failing bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failing[name] = failing
+}
+
+func TestPoolInstance(t *testing.T) {
+	poolHealthCheckInterval = time.Hour
+
+	logger := log.NewNullLogger()
+
+	newPool := func(strategy string, probe *fakeProbe) (*PoolInstance, []*fakeInstance) {
+		var (
+			instances []*fakeInstance
+			endpoints []*poolEndpoint
+		)
+
+		for _, name := range []string{"a", "b", "c"} {
+			instance := &fakeInstance{name: name}
+			instances = append(instances, instance)
+			endpoints = append(endpoints, &poolEndpoint{url: "ws://" + name, instance: instance})
+		}
+
+		return newPoolInstance(logger, endpoints, strategy, probe.probe), instances
+	}
+
+	Convey("When spreading tabs in round robin", t, func() {
+		probe := &fakeProbe{failing: map[string]bool{}}
+		pool, _ := newPool(RoundRobin, probe)
+
+		defer pool.Close(logger)
+
+		Convey("Tabs should be opened in every browser in turn", func() {
+			var tabs []*Tab
+
+			for range 6 {
+				tabs = append(tabs, pool.NewTab(t.Context(), logger, nil))
+			}
+
+			for _, endpoint := range pool.endpoints {
+				So(endpoint.tabs, ShouldEqual, 2)
+			}
+
+			for _, tab := range tabs {
+				tab.Close(logger)
+			}
+
+			for _, endpoint := range pool.endpoints {
+				So(endpoint.tabs, ShouldEqual, 0)
+			}
+		})
+
+		Convey("Failing browsers should be ejected until they are healthy", func() {
+			probe.fail("b", true)
+			pool.checkEndpoints()
+
+			for range 4 {
+				pool.NewTab(t.Context(), logger, nil)
+			}
+
+			So(pool.endpoints[0].tabs, ShouldEqual, 2)
+			So(pool.endpoints[1].tabs, ShouldEqual, 0)
+			So(pool.endpoints[2].tabs, ShouldEqual, 2)
+
+			probe.fail("b", false)
+			pool.checkEndpoints()
+
+			for range 3 {
+				pool.NewTab(t.Context(), logger, nil)
+			}
+
+			So(pool.endpoints[1].tabs, ShouldEqual, 1)
+		})
+
+		Convey("All browsers should be used when all are failing", func() {
+			for _, name := range []string{"a", "b", "c"} {
+				probe.fail(name, true)
+			}
+
+			pool.checkEndpoints()
+
+			So(pool.NewTab(t.Context(), logger, nil), ShouldNotBeNil)
+		})
+	})
+
+	Convey("When spreading tabs to least busy browsers", t, func() {
+		probe := &fakeProbe{failing: map[string]bool{}}
+		pool, _ := newPool(LeastTabs, probe)
+
+		defer pool.Close(logger)
+
+		Convey("Tabs should be opened in browser with least tabs", func() {
+			first := pool.NewTab(t.Context(), logger, nil)
+			pool.NewTab(t.Context(), logger, nil)
+			pool.NewTab(t.Context(), logger, nil)
+
+			// Closing the tab of first browser makes it the least busy one
+			first.Close(logger)
+			So(pool.endpoints[0].tabs, ShouldEqual, 0)
+
+			pool.NewTab(t.Context(), logger, nil)
+
+			So(pool.endpoints[0].tabs, ShouldEqual, 1)
+			So(pool.endpoints[1].tabs, ShouldEqual, 1)
+			So(pool.endpoints[2].tabs, ShouldEqual, 1)
+		})
+
+		Convey("Failing browsers should not get tabs", func() {
+			probe.fail("a", true)
+			pool.checkEndpoints()
+
+			for range 4 {
+				pool.NewTab(t.Context(), logger, nil)
+			}
+
+			So(pool.endpoints[0].tabs, ShouldEqual, 0)
+			So(pool.endpoints[1].tabs, ShouldEqual, 2)
+			So(pool.endpoints[2].tabs, ShouldEqual, 2)
+		})
+	})
+
+	Convey("When the request of tabs is cancelled", t, func() {
+		pool, _ := newPool(RoundRobin, &fakeProbe{failing: map[string]bool{}})
+
+		defer pool.Close(logger)
+
+		ctx, cancel := context.WithCancel(t.Context())
+
+		closed := make(chan struct{})
+
+		// Tabs are closed by their users once their actions are cancelled
+		for range 3 {
+			tab := pool.NewTab(ctx, logger, nil)
+
+			go func() {
+				<-tab.Context().Done()
+				tab.Close(logger)
+				closed <- struct{}{}
+			}()
+		}
+
+		cancel()
+
+		Convey("Actions of tabs should be cancelled and tabs should be closed", func() {
+			for range 3 {
+				select {
+				case <-closed:
+				case <-time.After(time.Second):
+					t.Fatal("tab was not closed after cancellation")
+				}
+			}
+
+			pool.mu.Lock()
+			defer pool.mu.Unlock()
+
+			for _, endpoint := range pool.endpoints {
+				So(endpoint.tabs, ShouldEqual, 0)
+			}
+		})
+	})
+
+	Convey("When closing a pool", t, func() {
+		pool, instances := newPool(RoundRobin, &fakeProbe{failing: map[string]bool{}})
+		pool.Close(logger)
+
+		Convey("All browsers should be closed", func() {
+			for _, instance := range instances {
+				So(instance.closed, ShouldBeTrue)
+			}
+		})
+
+		Convey("Closing it again should not panic", func() {
+			So(func() { pool.Close(logger) }, ShouldNotPanic)
+		})
+	})
+}
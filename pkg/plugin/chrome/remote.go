@@ -1,23 +1,61 @@
 package chrome
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
 	"github.com/chromedp/chromedp"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
 	"golang.org/x/net/context"
 )
 
-// RemoteInstance is a remotely running browser instance.
+// Maximum duration of a connection attempt to remote browser.
+const remoteConnectTimeout = 30 * time.Second
+
+// RemoteInstance is a remotely running browser instance. Every tab uses its
+// own connection to remote browser. A connection is kept open to detect when
+// remote browser is unreachable, so that new tabs wait for it to be reachable
+// again.
 type RemoteInstance struct {
 	allocCtx       context.Context
 	allocCtxCancel context.CancelFunc
+	supervisor     *supervisor
 }
 
-// NewRemoteBrowserInstance creates a new remote browser instance.
-func NewRemoteBrowserInstance(ctx context.Context, _ log.Logger, remoteChromeURL string) (*RemoteInstance, error) {
+// NewRemoteBrowserInstance creates a new remote browser instance. Remote
+// browser does not need to be reachable at creation.
+func NewRemoteBrowserInstance(ctx context.Context, logger log.Logger, remoteChromeURL string) (*RemoteInstance, error) {
 	allocCtx, allocCtxCancel := chromedp.NewRemoteAllocator(ctx, remoteChromeURL)
 
-	return &RemoteInstance{allocCtx, allocCtxCancel}, nil
+	chromeLogger := logger.With("subsystem", "chromium")
+
+	connect := func() (context.Context, context.CancelFunc, error) {
+		browserCtx, cancel := chromedp.NewContext(allocCtx)
+
+		// Do not wait indefinitely for unresponsive remote browsers
+		timer := time.AfterFunc(remoteConnectTimeout, cancel)
+		defer timer.Stop()
+
+		if err := chromedp.Run(browserCtx); err != nil {
+			cancel()
+
+			return nil, nil, fmt.Errorf("couldn't connect to remote browser: %w", err)
+		}
+
+		return browserCtx, cancel, nil
+	}
+
+	instance := &RemoteInstance{
+		allocCtx:       allocCtx,
+		allocCtxCancel: allocCtxCancel,
+		supervisor:     newSupervisor(chromeLogger, connect),
+	}
+
+	// Connection is retried in background when remote browser is unreachable
+	_ = instance.supervisor.Start(false)
+
+	return instance, nil
 }
 
 // Name returns the kind of browser instance.
@@ -25,21 +63,27 @@ func (i *RemoteInstance) Name() string {
 	return "remote"
 }
 
-// NewTab starts and returns a new tab on current browser instance.
-func (i *RemoteInstance) NewTab(logger log.Logger, _ *config.Config) *Tab {
+// NewTab starts and returns a new tab on current browser instance. When
+// remote browser is unreachable, it waits for it to be reachable again.
+func (i *RemoteInstance) NewTab(ctx context.Context, logger log.Logger, conf *config.Config) *Tab {
+	i.supervisor.Browser(ctx, browserWait(conf))
+
 	chromeLogger := logger.With("subsystem", "chromium")
-	browserCtx, _ := chromedp.NewContext(i.allocCtx,
+
+	target, cancelTarget := chromedp.NewContext(i.allocCtx,
 		chromedp.WithErrorf(chromeLogger.Error),
 		chromedp.WithLogf(chromeLogger.Debug),
 	)
 
-	return &Tab{
-		ctx: browserCtx,
-	}
+	return newTab(ctx, target, cancelTarget)
 }
 
 // Close releases the resources of browser instance.
 func (i *RemoteInstance) Close(_ log.Logger) {
+	if _, cancel := i.supervisor.Close(); cancel != nil {
+		cancel()
+	}
+
 	if i.allocCtxCancel != nil {
 		i.allocCtxCancel()
 	}
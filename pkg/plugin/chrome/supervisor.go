@@ -0,0 +1,236 @@
+package chrome
+
+import (
+	"sync"
+	"time"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"golang.org/x/net/context"
+)
+
+// Bounds of the delay between attempts to restart a browser.
+var (
+	minRestartBackoff = 500 * time.Millisecond
+	maxRestartBackoff = 30 * time.Second
+)
+
+// startFunc starts a browser and returns its context along with a function
+// that releases its resources.
+type startFunc func() (context.Context, context.CancelFunc, error)
+
+// supervisor keeps a browser running. When the context of browser is done
+// before the supervisor is closed, which happens when browser crashes or
+// connection to it is lost, browser is restarted with backoff. Restarts are
+// serialized and callers of Browser wait for an ongoing restart instead of
+// getting a dead browser.
+type supervisor struct {
+	logger log.Logger
+	start  startFunc
+
+	mu      sync.Mutex
+	browser context.Context
+	cancel  context.CancelFunc
+	ready   chan struct{} // Closed when browser is running
+	done    chan struct{} // Closed when supervisor is closed
+	closed  bool
+}
+
+func newSupervisor(logger log.Logger, start startFunc) *supervisor {
+	return &supervisor{
+		logger: logger,
+		start:  start,
+		ready:  make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// Start starts the browser and supervises it. When required is false, a
+// failure to start is not returned and start is retried in background.
+func (s *supervisor) Start(required bool) error {
+	browser, cancel, err := s.start()
+	if err != nil {
+		if required {
+			return err
+		}
+
+		s.logger.Warn("failed to start browser, retrying in background", "err", err)
+	} else {
+		s.browser, s.cancel = browser, cancel
+		close(s.ready)
+	}
+
+	go s.supervise()
+
+	return nil
+}
+
+// Browser returns the context of running browser. If browser is restarting,
+// it waits for at most wait for the restart to finish or until ctx is done.
+// The returned context might be done or nil when browser could not be
+// restarted in time.
+func (s *supervisor) Browser(ctx context.Context, wait time.Duration) context.Context {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	for {
+		s.mu.Lock()
+		browser, ready, closed := s.browser, s.ready, s.closed
+		s.mu.Unlock()
+
+		if closed {
+			return browser
+		}
+
+		select {
+		case <-ready:
+			if browser.Err() == nil {
+				return browser
+			}
+
+			// Browser has stopped but supervisor did not notice it yet
+			s.stopped(browser)
+
+			continue
+		default:
+		}
+
+		select {
+		case <-ready:
+		case <-timer.C:
+			return browser
+		case <-ctx.Done():
+			return browser
+		}
+	}
+}
+
+// Close stops supervising browser and returns its context and the function
+// that releases its resources.
+func (s *supervisor) Close() (context.Context, context.CancelFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, nil
+	}
+
+	s.closed = true
+	close(s.done)
+
+	return s.browser, s.cancel
+}
+
+// supervise restarts browser every time it stops until supervisor is closed.
+func (s *supervisor) supervise() {
+	for {
+		s.mu.Lock()
+		browser := s.browser
+		s.mu.Unlock()
+
+		// Browser is nil when the first start failed
+		if browser != nil {
+			select {
+			case <-browser.Done():
+			case <-s.done:
+				return
+			}
+
+			// Browser is expected to stop when supervisor is closed
+			select {
+			case <-s.done:
+				return
+			default:
+			}
+
+			s.logger.Warn("browser stopped unexpectedly, restarting", "err", browser.Err())
+			s.stopped(browser)
+		}
+
+		if !s.restart() {
+			return
+		}
+	}
+}
+
+// stopped marks browser as not ready, if it is still the current one.
+func (s *supervisor) stopped(browser context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.browser != browser {
+		return
+	}
+
+	select {
+	case <-s.ready:
+		s.ready = make(chan struct{})
+	default:
+	}
+}
+
+// restart starts a new browser with backoff until it succeeds or supervisor
+// is closed. It returns false when supervisor is closed.
+func (s *supervisor) restart() bool {
+	backoff := minRestartBackoff
+
+	for {
+		select {
+		case <-s.done:
+			return false
+		default:
+		}
+
+		browser, cancel, err := s.start()
+
+		s.mu.Lock()
+
+		if s.closed {
+			s.mu.Unlock()
+
+			if err == nil {
+				cancel()
+			}
+
+			return false
+		}
+
+		if err == nil {
+			oldCancel := s.cancel
+			s.browser, s.cancel = browser, cancel
+			close(s.ready)
+			s.mu.Unlock()
+
+			// Release resources of the stopped browser
+			if oldCancel != nil {
+				oldCancel()
+			}
+
+			s.logger.Info("browser restarted")
+
+			return true
+		}
+
+		s.mu.Unlock()
+
+		s.logger.Error("failed to restart browser", "err", err, "retry_in", backoff.String())
+
+		select {
+		case <-time.After(backoff):
+		case <-s.done:
+			return false
+		}
+
+		backoff = min(2*backoff, maxRestartBackoff)
+	}
+}
+
+// browserWait returns how long new tabs wait for a restarting browser. It is
+// the timeout of HTTP client as tabs are used to make requests to Grafana.
+func browserWait(conf *config.Config) time.Duration {
+	if conf == nil || conf.HTTPClientOptions.Timeouts == nil {
+		return 0
+	}
+
+	return conf.HTTPClientOptions.Timeouts.Timeout
+}
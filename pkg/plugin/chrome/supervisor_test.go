@@ -0,0 +1,148 @@
+package chrome
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	. "github.com/smartystreets/goconvey/convey"
+	"golang.org/x/net/context"
+)
+
+// fakeBrowsers starts fake browsers whose contexts are cancelled to simulate
+// crashes. Starts fail while failures is positive.
+type fakeBrowsers struct {
+	mu       sync.Mutex
+	started  []context.CancelFunc
+	failures int
+	delay    time.Duration
+}
+
+func (f *fakeBrowsers) start() (context.Context, context.CancelFunc, error) {
+	time.Sleep(f.delay)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failures > 0 {
+		f.failures--
+
+		return nil, nil, errors.New("failed to start")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f.started = append(f.started, cancel)
+
+	return ctx, cancel, nil
+}
+
+func (f *fakeBrowsers) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.started)
+}
+
+// crash cancels the context of the last started browser.
+func (f *fakeBrowsers) crash() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.started[len(f.started)-1]()
+}
+
+func TestSupervisor(t *testing.T) {
+	minRestartBackoff = time.Millisecond
+	maxRestartBackoff = 5 * time.Millisecond
+
+	Convey("When supervising a browser", t, func() {
+		browsers := &fakeBrowsers{}
+		s := newSupervisor(log.NewNullLogger(), browsers.start)
+
+		So(s.Start(true), ShouldBeNil)
+
+		defer s.Close()
+
+		Convey("Running browser should be returned", func() {
+			browser := s.Browser(t.Context(), 0)
+			So(browser.Err(), ShouldBeNil)
+			So(browsers.count(), ShouldEqual, 1)
+		})
+
+		Convey("Crashed browser should be restarted", func() {
+			browsers.delay = 20 * time.Millisecond
+			browsers.crash()
+
+			// Callers wait for the ongoing restart
+			browser := s.Browser(t.Context(), time.Second)
+			So(browser.Err(), ShouldBeNil)
+			So(browsers.count(), ShouldEqual, 2)
+		})
+
+		Convey("Failed restarts should be retried", func() {
+			browsers.mu.Lock()
+			browsers.failures = 3
+			browsers.mu.Unlock()
+
+			browsers.crash()
+
+			browser := s.Browser(t.Context(), time.Second)
+			So(browser.Err(), ShouldBeNil)
+			So(browsers.count(), ShouldEqual, 2)
+		})
+
+		Convey("Callers should not wait longer than requested", func() {
+			browsers.delay = time.Second
+			browsers.crash()
+
+			browser := s.Browser(t.Context(), 10*time.Millisecond)
+			So(browser.Err(), ShouldNotBeNil)
+		})
+
+		Convey("Callers should not wait after their request is cancelled", func() {
+			browsers.delay = time.Second
+			browsers.crash()
+
+			ctx, cancel := context.WithCancel(t.Context())
+			cancel()
+
+			start := time.Now()
+			browser := s.Browser(ctx, time.Minute)
+			So(browser.Err(), ShouldNotBeNil)
+			So(time.Since(start), ShouldBeLessThan, time.Second)
+		})
+
+		Convey("Browser should not be restarted after close", func() {
+			browser, cancel := s.Close()
+			So(browser, ShouldNotBeNil)
+			So(cancel, ShouldNotBeNil)
+
+			cancel()
+			time.Sleep(20 * time.Millisecond)
+
+			So(browsers.count(), ShouldEqual, 1)
+			So(s.Browser(t.Context(), time.Second).Err(), ShouldNotBeNil)
+		})
+	})
+
+	Convey("When first start of a browser fails", t, func() {
+		browsers := &fakeBrowsers{failures: 2}
+		s := newSupervisor(log.NewNullLogger(), browsers.start)
+
+		defer s.Close()
+
+		Convey("It should be returned when start is required", func() {
+			So(s.Start(true), ShouldNotBeNil)
+		})
+
+		Convey("It should be retried in background otherwise", func() {
+			So(s.Start(false), ShouldBeNil)
+
+			browser := s.Browser(t.Context(), time.Second)
+			So(browser, ShouldNotBeNil)
+			So(browser.Err(), ShouldBeNil)
+		})
+	})
+}
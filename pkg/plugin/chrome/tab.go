@@ -1,6 +1,7 @@
 package chrome
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,30 +13,111 @@ import (
 	"github.com/chromedp/cdproto/runtime"
 	"github.com/chromedp/chromedp"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
-	"golang.org/x/net/context"
 )
 
 var WithAwaitPromise = func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
 	return p.WithAwaitPromise(true)
 }
 
+// Timeouts of opening a tab and of clearing its session when it is closed.
+var (
+	tabOpenTimeout  = 30 * time.Second
+	tabCloseTimeout = 10 * time.Second
+)
+
 // Tab is container for a browser tab.
 type Tab struct {
 	ctx    context.Context
 	cancel context.CancelFunc
+
+	// Context of the browser target of tab. Actions run in ctx, which is
+	// cancelled along with the request, while target is kept alive until
+	// tab is closed
+	target context.Context
+	stop   func() bool
+
+	// Error of opening tab. Actions are not run on a tab that could not be
+	// opened as the browser of its target cannot be allocated again
+	err error
+
+	// Called once when tab is closed
+	onClose func()
+}
+
+// newTab opens and returns the tab of browser target. Actions of the tab are
+// cancelled when ctx is done. Opening the tab is abandoned and target is
+// cancelled with cancelTarget when ctx is done before the browser responds.
+func newTab(ctx, target context.Context, cancelTarget context.CancelFunc) *Tab {
+	// First run of target binds its browser connection and event loop to
+	// the context it runs with. Bind them to target so that the session can
+	// still be cleared when tab is closed after ctx is done. Errors surface
+	// in the actions of tab.
+	var err error
+
+	opened := make(chan struct{})
+
+	go func() {
+		defer close(opened)
+
+		err = chromedp.Run(target)
+	}()
+
+	openCtx, cancelOpen := context.WithTimeout(ctx, tabOpenTimeout)
+	defer cancelOpen()
+
+	select {
+	case <-opened:
+	case <-openCtx.Done():
+		// Cancelled target makes the run return promptly
+		cancelTarget()
+		<-opened
+
+		err = openCtx.Err()
+	}
+
+	tabCtx, cancel := context.WithCancel(target)
+
+	tab := &Tab{
+		ctx:    tabCtx,
+		cancel: cancel,
+		target: target,
+		stop:   context.AfterFunc(ctx, cancel),
+	}
+
+	if err != nil {
+		tab.err = fmt.Errorf("error opening tab: %w", err)
+	}
+
+	return tab
 }
 
 // Close releases the resources of the current browser tab.
 func (t *Tab) Close(logger log.Logger) {
+	if t.stop != nil {
+		t.stop()
+	}
+
 	if t.ctx != nil {
 		var err error
 
-		// Clear browser cookies to ensure no session is left
-		if err = chromedp.Run(t.ctx, network.ClearBrowserCookies()); err != nil {
-			logger.Error("got error from clear browser cookies", "error", err)
+		// Target is still alive when actions of tab have been cancelled
+		target := t.target
+		if target == nil {
+			target = t.ctx
 		}
 
-		if err = chromedp.Cancel(t.ctx); err != nil {
+		// Clear browser cookies to ensure no session is left. There is no
+		// session when the browser target could not be created
+		if c := chromedp.FromContext(target); c != nil && c.Target != nil {
+			ctx, cancel := context.WithTimeout(target, tabCloseTimeout)
+			if err = chromedp.Run(ctx, network.ClearBrowserCookies()); err != nil {
+				logger.Error("got error from clear browser cookies", "error", err)
+			}
+
+			cancel()
+		}
+
+		if err = chromedp.Cancel(target); err != nil {
 			logger.Error("got error from cancel tab context", "error", err)
 		}
 
@@ -43,6 +125,11 @@ func (t *Tab) Close(logger log.Logger) {
 			t.cancel()
 		}
 	}
+
+	if t.onClose != nil {
+		t.onClose()
+		t.onClose = nil
+	}
 }
 
 // NavigateAndWaitFor navigates to the given address and waits for the given event to be fired on the page.
@@ -61,6 +148,10 @@ func (t *Tab) NavigateAndWaitFor(addr string, headers map[string]any, eventName
 		return fmt.Errorf("error enable lifecycle events: %w", err)
 	}
 
+	if t.err != nil {
+		return t.err
+	}
+
 	resp, err := chromedp.RunResponse(t.ctx, chromedp.Navigate(addr))
 	if err != nil {
 		return fmt.Errorf("failed navigate to %s: %w", addr, err)
@@ -84,11 +175,19 @@ func (t *Tab) WithTimeout(timeout time.Duration) {
 
 // Run executes the actions in the current tab.
 func (t *Tab) Run(actions ...chromedp.Action) error {
+	if t.err != nil {
+		return t.err
+	}
+
 	return chromedp.Run(t.ctx, actions...)
 }
 
 // RunWithTimeout executes the actions in the current tab.
 func (t *Tab) RunWithTimeout(timeout time.Duration, actions ...chromedp.Action) error {
+	if t.err != nil {
+		return t.err
+	}
+
 	ctx, cancel := context.WithTimeout(t.ctx, timeout)
 	err := chromedp.Run(ctx, actions...)
 
@@ -109,6 +208,10 @@ func (t *Tab) Target() *chromedp.Target {
 
 // PrintToPDF returns chroms tasks that print the requested HTML into a PDF and returns the PDF stream handle.
 func (t *Tab) PrintToPDF(options PDFOptions, writer io.Writer) error {
+	if t.err != nil {
+		return t.err
+	}
+
 	err := chromedp.Run(t.ctx, chromedp.Tasks{
 		chromedp.Navigate("about:blank"),
 		chromedp.ActionFunc(func(ctx context.Context) error {
@@ -144,8 +247,31 @@ func (t *Tab) PrintToPDF(options PDFOptions, writer io.Writer) error {
 				pageParams = pageParams.WithLandscape(true)
 			}
 
+			if options.PaperWidth > 0 && options.PaperHeight > 0 {
+				pageParams = pageParams.WithPaperWidth(options.PaperWidth).WithPaperHeight(options.PaperHeight)
+			}
+
+			pageParams = pageParams.
+				WithMarginTop(options.Margins[0]).
+				WithMarginRight(options.Margins[1]).
+				WithMarginBottom(options.Margins[2]).
+				WithMarginLeft(options.Margins[3])
+
+			if options.Scale > 0 {
+				pageParams = pageParams.WithScale(options.Scale)
+			}
+
+			if options.PageRanges != "" {
+				pageParams = pageParams.WithPageRanges(options.PageRanges)
+			}
+
 			pageParams = pageParams.WithPrintBackground(true)
 
+			// Outline is generated from the structure of tagged PDFs
+			if options.Outline {
+				pageParams = pageParams.WithGenerateTaggedPDF(true).WithGenerateDocumentOutline(true)
+			}
+
 			// Finally execute and get PDF buffer
 			_, stream, err := pageParams.Do(ctx)
 			if err != nil {
@@ -0,0 +1,111 @@
+package chrome
+
+import (
+	"context"
+	"net"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"github.com/chromedp/chromedp"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTabClose(t *testing.T) {
+	logger := log.NewNullLogger()
+
+	Convey("When a tab without browser target is closed", t, func() {
+		ctx, cancel := context.WithCancel(t.Context())
+		tab := newTab(ctx, context.Background(), func() {})
+
+		cancel()
+
+		start := time.Now()
+		tab.Close(logger)
+
+		Convey("It should not wait to clear the session", func() {
+			So(time.Since(start), ShouldBeLessThan, time.Second)
+		})
+	})
+
+	Convey("When a tab is cancelled while the browser does not respond", t, func() {
+		// Browser accepts connections but never responds
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		So(err, ShouldBeNil)
+
+		defer ln.Close()
+
+		go func() {
+			for {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+
+				defer conn.Close()
+			}
+		}()
+
+		allocCtx, allocCancel := chromedp.NewRemoteAllocator(t.Context(), "ws://"+ln.Addr().String(), chromedp.NoModifyURL)
+		defer allocCancel()
+
+		target, cancelTarget := chromedp.NewContext(allocCtx)
+
+		ctx, cancel := context.WithCancel(t.Context())
+		time.AfterFunc(100*time.Millisecond, cancel)
+
+		start := time.Now()
+		tab := newTab(ctx, target, cancelTarget)
+
+		Convey("Opening the tab should be abandoned", func() {
+			So(time.Since(start), ShouldBeLessThan, 5*time.Second)
+			So(tab.Run(chromedp.Navigate("about:blank")), ShouldNotBeNil)
+
+			start = time.Now()
+			tab.Close(logger)
+			So(time.Since(start), ShouldBeLessThan, 5*time.Second)
+		})
+	})
+
+	var execPath string
+
+	for _, path := range []string{"google-chrome", "chrome", "chromium", "chromium-browser"} {
+		if found, err := exec.LookPath(path); err == nil {
+			execPath = found
+
+			break
+		}
+	}
+
+	// Skip test if chrome is not available
+	if execPath == "" {
+		t.Skip("Chrome not found. Skipping test")
+	}
+
+	Convey("When a tab of a browser is closed after its request is done", t, func() {
+		defer func(timeout time.Duration) { tabCloseTimeout = timeout }(tabCloseTimeout)
+
+		tabCloseTimeout = time.Minute
+
+		instance, err := NewLocalBrowserInstance(t.Context(), logger, false)
+		So(err, ShouldBeNil)
+
+		defer instance.Close(logger)
+
+		ctx, cancel := context.WithCancel(t.Context())
+		tab := instance.NewTab(ctx, logger, &config.Config{})
+
+		So(tab.Run(chromedp.Navigate("about:blank")), ShouldBeNil)
+
+		cancel()
+
+		start := time.Now()
+		tab.Close(logger)
+
+		Convey("It should clear the session without waiting for the timeout", func() {
+			So(time.Since(start), ShouldBeLessThan, 5*time.Second)
+		})
+	})
+}
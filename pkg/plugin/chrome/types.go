@@ -1,6 +1,8 @@
 package chrome
 
 import (
+	"context"
+
 	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
 )
@@ -12,11 +14,24 @@ type PDFOptions struct {
 	Footer string
 
 	Orientation string
+
+	// Width and height of paper and its top, right, bottom and left margins
+	// in inches. Zero paper size keeps the default of Chromium
+	PaperWidth  float64
+	PaperHeight float64
+	Margins     [4]float64
+
+	// Scale of rendering of pages and ranges of pages to print like 1-5, 8
+	Scale      float64
+	PageRanges string
+
+	// Outline adds bookmarks of the headings of document to PDF
+	Outline bool
 }
 
 // Instance is the interface remote and local chrome must implement.
 type Instance interface {
-	NewTab(logger log.Logger, conf *config.Config) *Tab
+	NewTab(ctx context.Context, logger log.Logger, conf *config.Config) *Tab
 	Name() string
 	Close(logger log.Logger)
 }
@@ -0,0 +1,182 @@
+package plugin
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/report"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/worker"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+// Maximum number of dashboards in a combined report.
+const maxCombinedDashboards = 20
+
+// combinedDashboard is a dashboard of a combined report request.
+type combinedDashboard struct {
+	DashboardUID        string              `json:"dashUid"`
+	From                string              `json:"from,omitempty"`
+	To                  string              `json:"to,omitempty"`
+	Variables           map[string][]string `json:"variables,omitempty"`
+	IncludePanelIDs     []string            `json:"includePanelIds,omitempty"`
+	ExcludePanelIDs     []string            `json:"excludePanelIds,omitempty"`
+	IncludePanelDataIDs []string            `json:"includePanelDataIds,omitempty"`
+}
+
+// combinedRequest is the body of a combined report request. Options, like
+// theme and layout, and time range apply to all dashboards unless a
+// dashboard sets its own time range.
+type combinedRequest struct {
+	Title      string              `json:"title"`
+	From       string              `json:"from,omitempty"`
+	To         string              `json:"to,omitempty"`
+	Options    map[string][]string `json:"options,omitempty"`
+	Dashboards []combinedDashboard `json:"dashboards"`
+}
+
+// validate checks the request and sets the defaults.
+func (r *combinedRequest) validate() error {
+	if len(r.Dashboards) == 0 {
+		return errors.New("at least one dashboard is required")
+	}
+
+	if len(r.Dashboards) > maxCombinedDashboards {
+		return fmt.Errorf("at most %d dashboards are allowed", maxCombinedDashboards)
+	}
+
+	for i, d := range r.Dashboards {
+		if d.DashboardUID == "" {
+			return fmt.Errorf("dashUid of dashboard %d is required", i+1)
+		}
+	}
+
+	if err := dashboard.NewTimeRange(r.From, r.To).Validate(); err != nil {
+		return err
+	}
+
+	if r.Title == "" {
+		r.Title = "Report"
+	}
+
+	return nil
+}
+
+// query returns the dashboard at index i as query parameters of report API.
+func (r *combinedRequest) query(i int) url.Values {
+	d := r.Dashboards[i]
+
+	values := url.Values{}
+
+	for name, v := range r.Options {
+		values[name] = v
+	}
+
+	for name, v := range d.Variables {
+		if !strings.HasPrefix(name, "var-") {
+			name = "var-" + name
+		}
+
+		values[name] = v
+	}
+
+	// Time range of dashboard has precedence over the common one
+	if from := cmp.Or(d.From, r.From); from != "" {
+		values.Set("from", from)
+	}
+
+	if to := cmp.Or(d.To, r.To); to != "" {
+		values.Set("to", to)
+	}
+
+	for name, ids := range map[string][]string{
+		"includePanelID":     d.IncludePanelIDs,
+		"excludePanelID":     d.ExcludePanelIDs,
+		"includePanelDataID": d.IncludePanelDataIDs,
+	} {
+		if len(ids) > 0 {
+			values[name] = ids
+		}
+	}
+
+	values.Set("dashUid", d.DashboardUID)
+
+	return values
+}
+
+// handleCombinedReport handles creating a single PDF report from several
+// dashboards
+// POST /api/plugins/mahendrapaipuri-dashboardreporter-app/resources/report/combined.
+func (app *App) handleCombinedReport(w http.ResponseWriter, req *http.Request) {
+	user := backend.PluginConfigFromContext(req.Context()).User.Login
+	ctxLogger := log.DefaultLogger.FromContext(req.Context()).With("user", user)
+
+	var combinedReq combinedRequest
+	if err := json.NewDecoder(req.Body).Decode(&combinedReq); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+
+		return
+	}
+
+	if err := combinedReq.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	// Cover page uses the options common to all dashboards
+	conf := app.conf
+	app.updateConfig(url.Values(combinedReq.Options), &conf)
+
+	if err := conf.Validate(); err != nil {
+		ctxLogger.Debug("invalid config: "+conf.String(), "err", err)
+		http.Error(w, "invalid options found", http.StatusBadRequest)
+
+		return
+	}
+
+	if conf.Format != report.FormatPDF {
+		http.Error(w, "combined reports only support pdf format", http.StatusBadRequest)
+
+		return
+	}
+
+	// Prepare reports of all dashboards before generating anything so that
+	// a dashboard without permissions fails the whole request
+	reports := make([]*report.Report, len(combinedReq.Dashboards))
+
+	for i := range combinedReq.Dashboards {
+		reportReq, ok := app.newReportRequestFromQuery(w, req, combinedReq.query(i))
+		if !ok {
+			return
+		}
+
+		reports[i] = reportReq.report
+	}
+
+	combinedReport := report.NewCombined(
+		ctxLogger,
+		&conf,
+		app.chromeInstance,
+		combinedReq.Title,
+		dashboard.NewTimeRange(combinedReq.From, combinedReq.To),
+		reports,
+	)
+
+	ctx := worker.WithUser(req.Context(), user, worker.Interactive)
+
+	if err := combinedReport.Generate(ctx, w); err != nil {
+		ctxLogger.Error("error generating combined report", "err", err)
+		writeError(w, err)
+
+		return
+	}
+
+	ctxLogger.Info("combined report generated", "dashboards", len(reports))
+}
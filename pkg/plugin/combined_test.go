@@ -0,0 +1,155 @@
+package plugin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/chrome"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/worker"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCombinedRequest(t *testing.T) {
+	Convey("When validating combined report requests", t, func() {
+		Convey("Requests without dashboards should be rejected", func() {
+			r := combinedRequest{}
+			So(r.validate(), ShouldNotBeNil)
+		})
+
+		Convey("Dashboards without UID should be rejected", func() {
+			r := combinedRequest{Dashboards: []combinedDashboard{{DashboardUID: "a"}, {}}}
+			So(r.validate(), ShouldNotBeNil)
+		})
+
+		Convey("Invalid time range should be rejected", func() {
+			r := combinedRequest{From: "yesterday", Dashboards: []combinedDashboard{{DashboardUID: "a"}}}
+			So(r.validate(), ShouldNotBeNil)
+		})
+
+		Convey("Default title should be set", func() {
+			r := combinedRequest{Dashboards: []combinedDashboard{{DashboardUID: "a"}}}
+			So(r.validate(), ShouldBeNil)
+			So(r.Title, ShouldEqual, "Report")
+		})
+	})
+
+	Convey("When converting dashboards to query parameters", t, func() {
+		r := combinedRequest{
+			From:    "now-7d",
+			To:      "now",
+			Options: map[string][]string{"theme": {"dark"}},
+			Dashboards: []combinedDashboard{
+				{
+					DashboardUID:    "a",
+					Variables:       map[string][]string{"host": {"h1", "h2"}, "var-env": {"prod"}},
+					IncludePanelIDs: []string{"1", "2"},
+				},
+				{DashboardUID: "b", From: "now-1d", ExcludePanelIDs: []string{"3"}},
+			},
+		}
+
+		Convey("Common options and time range should apply to all dashboards", func() {
+			q := r.query(0)
+			So(q.Get("dashUid"), ShouldEqual, "a")
+			So(q.Get("theme"), ShouldEqual, "dark")
+			So(q.Get("from"), ShouldEqual, "now-7d")
+			So(q.Get("to"), ShouldEqual, "now")
+			So(q["var-host"], ShouldResemble, []string{"h1", "h2"})
+			So(q.Get("var-env"), ShouldEqual, "prod")
+			So(q["includePanelID"], ShouldResemble, []string{"1", "2"})
+			So(q.Has("excludePanelID"), ShouldBeFalse)
+		})
+
+		Convey("Time range of a dashboard should have precedence", func() {
+			q := r.query(1)
+			So(q.Get("dashUid"), ShouldEqual, "b")
+			So(q.Get("from"), ShouldEqual, "now-1d")
+			So(q.Get("to"), ShouldEqual, "now")
+			So(q["excludePanelID"], ShouldResemble, []string{"3"})
+			So(q.Has("var-host"), ShouldBeFalse)
+		})
+	})
+}
+
+func TestCombinedReportTimeRange(t *testing.T) {
+	Convey("When a combined report of dashboards with different time ranges is generated", t, func() {
+		var mu sync.Mutex
+
+		renderQueries := map[string]url.Values{}
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case strings.HasPrefix(r.URL.Path, "/api/dashboards/uid/"):
+				uid := strings.TrimPrefix(r.URL.Path, "/api/dashboards/uid/")
+				w.Write([]byte(`{"dashboard": {"uid": "` + uid + `", "title": "Ops", "panels": [
+					{"id": 2, "type": "timeseries", "title": "CPU", "gridPos": {"h": 8, "w": 12, "x": 0, "y": 0}}
+				]}}`))
+			case strings.HasPrefix(r.URL.Path, "/render/d-solo/"):
+				mu.Lock()
+				renderQueries[r.URL.Path] = r.URL.Query()
+				mu.Unlock()
+
+				w.Header().Set("Content-Type", "image/png")
+				w.Write([]byte("\x89PNG\r\n\x1a\nfake"))
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		defer ts.Close()
+
+		conf := config.Config{
+			Theme:          "light",
+			Orientation:    "portrait",
+			Layout:         "simple",
+			DashboardMode:  "default",
+			PanelDiscovery: "model",
+			Token:          "token",
+		}
+		So(conf.Validate(), ShouldBeNil)
+
+		// Browser is unreachable so that report fails once panels are fetched
+		chromeInstance, err := chrome.NewRemoteBrowserInstance(t.Context(), log.NewNullLogger(), "ws://127.0.0.1:1")
+		So(err, ShouldBeNil)
+
+		defer chromeInstance.Close(log.NewNullLogger())
+
+		app := &App{
+			conf:           conf,
+			httpClient:     ts.Client(),
+			chromeInstance: chromeInstance,
+			grafanaSemVer:  "v11.4.0",
+			workerPools: worker.Pools{
+				worker.Browser:  worker.New(t.Context(), worker.Browser, 1, 0),
+				worker.Renderer: worker.New(t.Context(), worker.Renderer, 1, 0),
+			},
+		}
+
+		ctx := backend.WithGrafanaConfig(t.Context(), backend.NewGrafanaCfg(map[string]string{
+			backend.AppURL: ts.URL,
+		}))
+		ctx = backend.WithPluginContext(ctx, backend.PluginContext{User: &backend.User{Login: "foo"}})
+
+		body := `{"from": "now-7d", "to": "now", "dashboards": [
+			{"dashUid": "a"},
+			{"dashUid": "b", "from": "1704067200000", "to": "1704153600000"}
+		]}`
+
+		req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/report/combined", strings.NewReader(body))
+		app.handleCombinedReport(httptest.NewRecorder(), req)
+
+		Convey("Panels of every dashboard should be rendered with its own time range", func() {
+			So(renderQueries, ShouldHaveLength, 2)
+			So(renderQueries["/render/d-solo/a/_"].Get("from"), ShouldEqual, "now-7d")
+			So(renderQueries["/render/d-solo/a/_"].Get("to"), ShouldEqual, "now")
+			So(renderQueries["/render/d-solo/b/_"].Get("from"), ShouldEqual, "1704067200000")
+			So(renderQueries["/render/d-solo/b/_"].Get("to"), ShouldEqual, "1704153600000")
+		})
+	})
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Default paper of PDF reports. Top and bottom margins leave room for header
+// and footer of reports.
+const (
+	defaultPaperSize = "Letter"
+	defaultMargins   = "3cm 0cm 1cm 0cm"
+)
+
+// Width and height of named paper sizes.
+var paperSizes = map[string][2]string{
+	"A3":      {"297mm", "420mm"},
+	"A4":      {"210mm", "297mm"},
+	"A5":      {"148mm", "210mm"},
+	"Letter":  {"8.5in", "11in"},
+	"Legal":   {"8.5in", "14in"},
+	"Tabloid": {"11in", "17in"},
+}
+
+// Lengths of CSS absolute units in inches.
+var lengthUnits = map[string]float64{
+	"in": 1,
+	"cm": 1 / 2.54,
+	"mm": 1 / 25.4,
+	"pt": 1.0 / 72,
+	"px": 1.0 / 96,
+}
+
+// Page ranges like 1-5, 8, 11-13. Open ranges like -5 or 8- are allowed.
+var pageRangesRegexp = regexp.MustCompile(`^\s*(\d+(\s*-\s*\d*)?|-\s*\d+)(\s*,\s*(\d+(\s*-\s*\d*)?|-\s*\d+))*\s*$`)
+
+// parseLength returns the length in inches of a CSS length like 2.5cm. Zero
+// may be given without unit.
+func parseLength(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "0" {
+		return 0, nil
+	}
+
+	for unit, inches := range lengthUnits {
+		if v, ok := strings.CutSuffix(s, unit); ok {
+			length, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil || length < 0 {
+				break
+			}
+
+			return length * inches, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%s must be a length in one of [in,cm,mm,pt,px]", s)
+}
+
+// paperSize returns width and height as CSS lengths of a named paper size or
+// custom dimensions like 210mm x 297mm.
+func paperSize(s string) (string, string, error) {
+	for name, size := range paperSizes {
+		if strings.EqualFold(name, s) {
+			return size[0], size[1], nil
+		}
+	}
+
+	width, height, ok := strings.Cut(strings.ToLower(s), "x")
+	if !ok {
+		return "", "", fmt.Errorf("paper size: %s must be one of [%s] or dimensions like 210mm x 297mm",
+			s, strings.Join(validPaperSizes, ","))
+	}
+
+	width, height = strings.TrimSpace(width), strings.TrimSpace(height)
+
+	for _, length := range []string{width, height} {
+		if v, err := parseLength(length); err != nil {
+			return "", "", fmt.Errorf("paper size: %w", err)
+		} else if v == 0 {
+			return "", "", errors.New("paper size: dimensions must not be zero")
+		}
+	}
+
+	return width, height, nil
+}
+
+// parseMargins returns top, right, bottom and left margins in inches of CSS
+// margin shorthand with one to four lengths.
+func parseMargins(s string) ([4]float64, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 4 {
+		return [4]float64{}, fmt.Errorf("margins: %s must have one to four lengths", s)
+	}
+
+	lengths := make([]float64, len(fields))
+
+	for i, field := range fields {
+		length, err := parseLength(field)
+		if err != nil {
+			return [4]float64{}, fmt.Errorf("margins: %w", err)
+		}
+
+		lengths[i] = length
+	}
+
+	// Missing sides are taken from the opposite ones like in CSS
+	switch len(lengths) {
+	case 1:
+		return [4]float64{lengths[0], lengths[0], lengths[0], lengths[0]}, nil
+	case 2:
+		return [4]float64{lengths[0], lengths[1], lengths[0], lengths[1]}, nil
+	case 3:
+		return [4]float64{lengths[0], lengths[1], lengths[2], lengths[1]}, nil
+	}
+
+	return [4]float64{lengths[0], lengths[1], lengths[2], lengths[3]}, nil
+}
+
+// paper returns width and height as CSS lengths of paper of PDF reports. It
+// falls back to the default paper for invalid sizes.
+func (c *Config) paper() (string, string) {
+	width, height, err := paperSize(c.PaperSize)
+	if err != nil {
+		return paperSizes[defaultPaperSize][0], paperSizes[defaultPaperSize][1]
+	}
+
+	return width, height
+}
+
+// PaperDimensions returns width and height of paper of PDF reports in inches
+// in portrait orientation.
+func (c *Config) PaperDimensions() (float64, float64) {
+	width, height := c.paper()
+
+	// Sizes are already validated
+	w, _ := parseLength(width)
+	h, _ := parseLength(height)
+
+	return w, h
+}
+
+// PageMargins returns top, right, bottom and left margins of pages of PDF
+// reports in inches.
+func (c *Config) PageMargins() [4]float64 {
+	margins, err := parseMargins(c.Margins)
+	if err != nil {
+		margins, _ = parseMargins(defaultMargins)
+	}
+
+	return margins
+}
+
+// PageSizeCSS returns the size of pages of PDF reports in the orientation of
+// reports for CSS page rules.
+func (c *Config) PageSizeCSS() string {
+	width, height := c.paper()
+	if c.Orientation == "landscape" {
+		width, height = height, width
+	}
+
+	return width + " " + height
+}
+
+// PageMarginsCSS returns the margins of pages of PDF reports for CSS page
+// rules.
+func (c *Config) PageMarginsCSS() string {
+	if _, err := parseMargins(c.Margins); err != nil {
+		return defaultMargins
+	}
+
+	return strings.Join(strings.Fields(c.Margins), " ")
+}
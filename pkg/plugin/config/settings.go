@@ -4,18 +4,28 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/mail"
 	"net/url"
+	"path/filepath"
 	"slices"
 	"strings"
+	"text/template"
 	"time"
 
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/helpers"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
 	"github.com/sethvargo/go-envconfig"
 	"golang.org/x/net/context"
 )
 
-const SaToken = "saToken"
+const (
+	SaToken       = "saToken"
+	SMTPPassword  = "smtpPassword"
+	S3AccessKey   = "s3AccessKey"
+	S3SecretKey   = "s3SecretKey"
+	WebhookSecret = "webhookSecret"
+)
 
 // Valid setting parameters.
 var (
@@ -23,6 +33,13 @@ var (
 	validLayouts      = []string{"simple", "grid"}
 	validOrientations = []string{"portrait", "landscape"}
 	validModes        = []string{"default", "full"}
+	validSMTPTLSModes = []string{"starttls", "tls", "none"}
+	validFormats      = []string{"pdf", "xlsx", "zip", "html"}
+	validBalancings   = []string{"round-robin", "least-tabs"}
+	validPanelCaches  = []string{"none", "memory", "disk"}
+	validDiscoveries  = []string{"model", "browser"}
+	validRowModes     = []string{"skip", "expand", "title"}
+	validPaperSizes   = []string{"A3", "A4", "A5", "Letter", "Legal", "Tabloid"}
 )
 
 // Config contains plugin settings.
@@ -33,6 +50,7 @@ type Config struct {
 	Orientation       string            `env:"GF_REPORTER_PLUGIN_REPORT_ORIENTATION, overwrite"     json:"orientation"`
 	Layout            string            `env:"GF_REPORTER_PLUGIN_REPORT_LAYOUT, overwrite"          json:"layout"`
 	DashboardMode     string            `env:"GF_REPORTER_PLUGIN_REPORT_DASHBOARD_MODE, overwrite"  json:"dashboardMode"`
+	PanelDiscovery    string            `env:"GF_REPORTER_PLUGIN_PANEL_DISCOVERY, overwrite"        json:"panelDiscovery"`
 	TimeZone          string            `env:"GF_REPORTER_PLUGIN_REPORT_TIMEZONE, overwrite"        json:"timeZone"`
 	TimeFormat        string            `env:"GF_REPORTER_PLUGIN_REPORT_TIMEFORMAT, overwrite"      json:"timeFormat"`
 	EncodedLogo       string            `env:"GF_REPORTER_PLUGIN_REPORT_LOGO, overwrite"            json:"logo"`
@@ -40,9 +58,12 @@ type Config struct {
 	FooterTemplate    string            `env:"GF_REPORTER_PLUGIN_REPORT_FOOTER_TEMPLATE, overwrite" json:"footerTemplate"`
 	MaxBrowserWorkers int               `env:"GF_REPORTER_PLUGIN_MAX_BROWSER_WORKERS, overwrite"    json:"maxBrowserWorkers"`
 	MaxRenderWorkers  int               `env:"GF_REPORTER_PLUGIN_MAX_RENDER_WORKERS, overwrite"     json:"maxRenderWorkers"`
+	MaxQueuedTasks    int               `env:"GF_REPORTER_PLUGIN_MAX_QUEUED_TASKS, overwrite"       json:"maxQueuedTasks"`
 	RemoteChromeURL   string            `env:"GF_REPORTER_PLUGIN_REMOTE_CHROME_URL, overwrite"      json:"remoteChromeUrl"`
 	NativeRendering   bool              `env:"GF_REPORTER_PLUGIN_NATIVE_RENDERER, overwrite"        json:"nativeRenderer"`
 	CustomQueryParams map[string]string `env:"GF_REPORTER_PLUGIN_CUSTOM_QUERY_PARAMS, overwrite"    json:"customQueryParams"`
+	StoragePath       string            `env:"GF_REPORTER_PLUGIN_STORAGE_PATH, overwrite"           json:"storagePath"`
+	JobRetention      int               `env:"GF_REPORTER_PLUGIN_JOB_RETENTION, overwrite"          json:"jobRetention"`
 	AppVersion        string            `json:"appVersion"`
 	// Timeout configuration fields (in seconds)
 	Timeout                 int `env:"GF_REPORTER_PLUGIN_TIMEOUT, overwrite"                      json:"timeout"`
@@ -57,6 +78,66 @@ type Config struct {
 	ExcludePanelIDs         []string
 	IncludePanelDataIDs     []string
 
+	// Size of panel images in pixels. When unset, it depends on layout
+	PanelWidth  int
+	PanelHeight int
+
+	// Output format of the report
+	Format string
+
+	// SMTP settings to email reports
+	SMTPHost             string `env:"GF_REPORTER_PLUGIN_SMTP_HOST, overwrite"              json:"smtpHost"`
+	SMTPPort             int    `env:"GF_REPORTER_PLUGIN_SMTP_PORT, overwrite"              json:"smtpPort"`
+	SMTPTLS              string `env:"GF_REPORTER_PLUGIN_SMTP_TLS, overwrite"               json:"smtpTls"`
+	SMTPSkipTLSCheck     bool   `env:"GF_REPORTER_PLUGIN_SMTP_SKIP_TLS_CHECK, overwrite"    json:"smtpSkipTlsCheck"`
+	SMTPUser             string `env:"GF_REPORTER_PLUGIN_SMTP_USER, overwrite"              json:"smtpUser"`
+	SMTPFrom             string `env:"GF_REPORTER_PLUGIN_SMTP_FROM, overwrite"              json:"smtpFrom"`
+	EmailSubjectTemplate string `env:"GF_REPORTER_PLUGIN_EMAIL_SUBJECT_TEMPLATE, overwrite" json:"emailSubjectTemplate"`
+	EmailBodyTemplate    string `env:"GF_REPORTER_PLUGIN_EMAIL_BODY_TEMPLATE, overwrite"    json:"emailBodyTemplate"`
+
+	// S3 compatible object storage to archive reports
+	S3Endpoint string `env:"GF_REPORTER_PLUGIN_S3_ENDPOINT, overwrite"            json:"s3Endpoint"`
+	S3Region   string `env:"GF_REPORTER_PLUGIN_S3_REGION, overwrite"              json:"s3Region"`
+	S3Bucket   string `env:"GF_REPORTER_PLUGIN_S3_BUCKET, overwrite"              json:"s3Bucket"`
+	S3Prefix   string `env:"GF_REPORTER_PLUGIN_S3_PREFIX, overwrite"              json:"s3Prefix"`
+
+	// Webhooks notified when reports are generated
+	WebhookURLs       []string `env:"GF_REPORTER_PLUGIN_WEBHOOK_URLS, overwrite"        json:"webhookUrls"`
+	WebhookMaxRetries int      `env:"GF_REPORTER_PLUGIN_WEBHOOK_MAX_RETRIES, overwrite" json:"webhookMaxRetries"`
+
+	// Pool of remote chrome instances and the strategy to spread tabs across them.
+	// RemoteChromeURL, when set, is the first instance of the pool
+	RemoteChromeURLs      []string `env:"GF_REPORTER_PLUGIN_REMOTE_CHROME_URLS, overwrite"      json:"remoteChromeUrls"`
+	RemoteChromeBalancing string   `env:"GF_REPORTER_PLUGIN_REMOTE_CHROME_BALANCING, overwrite" json:"remoteChromeBalancing"`
+
+	// Cache of panel PNGs and CSV data. Size is in MiB, TTL and granularity
+	// of relative time ranges are in seconds
+	PanelCache            string `env:"GF_REPORTER_PLUGIN_PANEL_CACHE, overwrite"             json:"panelCache"`
+	PanelCachePath        string `env:"GF_REPORTER_PLUGIN_PANEL_CACHE_PATH, overwrite"        json:"panelCachePath"`
+	PanelCacheMaxSize     int    `env:"GF_REPORTER_PLUGIN_PANEL_CACHE_MAX_SIZE, overwrite"    json:"panelCacheMaxSize"`
+	PanelCacheTTL         int    `env:"GF_REPORTER_PLUGIN_PANEL_CACHE_TTL, overwrite"         json:"panelCacheTtl"`
+	PanelCacheGranularity int    `env:"GF_REPORTER_PLUGIN_PANEL_CACHE_GRANULARITY, overwrite" json:"panelCacheGranularity"`
+
+	// Sections of the report for dashboard rows. Collapsed rows are skipped,
+	// expanded or shown as title only. When unset, it follows dashboard mode
+	CollapsedRows string `env:"GF_REPORTER_PLUGIN_REPORT_COLLAPSED_ROWS, overwrite"  json:"collapsedRows"`
+	RowPageBreak  bool   `env:"GF_REPORTER_PLUGIN_REPORT_ROW_PAGE_BREAK, overwrite" json:"rowPageBreak"`
+
+	// Table of contents with page numbers of rows and panels of the report
+	TableOfContents bool `env:"GF_REPORTER_PLUGIN_REPORT_TABLE_OF_CONTENTS, overwrite" json:"tableOfContents"`
+
+	// Paper of PDF reports. Paper size is either a named size or dimensions
+	// like 210mm x 297mm and margins are in CSS margin shorthand
+	PaperSize  string  `env:"GF_REPORTER_PLUGIN_REPORT_PAPER_SIZE, overwrite"  json:"paperSize"`
+	Margins    string  `env:"GF_REPORTER_PLUGIN_REPORT_MARGINS, overwrite"     json:"margins"`
+	Scale      float64 `env:"GF_REPORTER_PLUGIN_REPORT_SCALE, overwrite"       json:"scale"`
+	PageRanges string  `env:"GF_REPORTER_PLUGIN_REPORT_PAGE_RANGES, overwrite" json:"pageRanges"`
+
+	// Partial reports with failed panels rendered as error cards. Reports
+	// fail when more than the fraction of panels fail
+	TolerateFailures bool    `env:"GF_REPORTER_PLUGIN_REPORT_TOLERATE_FAILURES, overwrite"  json:"tolerateFailures"`
+	MaxFailedPanels  float64 `env:"GF_REPORTER_PLUGIN_REPORT_MAX_FAILED_PANELS, overwrite" json:"maxFailedPanels"`
+
 	// Time location
 	Location *time.Location
 
@@ -64,7 +145,11 @@ type Config struct {
 	HTTPClientOptions httpclient.Options
 
 	// Secrets
-	Token string
+	Token         string
+	SMTPPassword  string
+	S3AccessKey   string
+	S3SecretKey   string
+	WebhookSecret string
 }
 
 // Validate checks current settings and sets them to defaults for invalid ones.
@@ -89,6 +174,61 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("dashboard mode: %s must be one of [%s]", c.DashboardMode, strings.Join(validModes, ","))
 	}
 
+	// Scrape panels from browser by default
+	if c.PanelDiscovery == "" {
+		c.PanelDiscovery = "browser"
+	}
+
+	if !slices.Contains(validDiscoveries, c.PanelDiscovery) {
+		return fmt.Errorf("panel discovery: %s must be one of [%s]", c.PanelDiscovery, strings.Join(validDiscoveries, ","))
+	}
+
+	if c.CollapsedRows != "" && !slices.Contains(validRowModes, c.CollapsedRows) {
+		return fmt.Errorf("collapsed rows: %s must be one of [%s]", c.CollapsedRows, strings.Join(validRowModes, ","))
+	}
+
+	// Print reports on letter paper with room for header and footer by default
+	if c.PaperSize == "" {
+		c.PaperSize = defaultPaperSize
+	}
+
+	if _, _, err := paperSize(c.PaperSize); err != nil {
+		return err
+	}
+
+	if c.Margins == "" {
+		c.Margins = defaultMargins
+	}
+
+	if _, err := parseMargins(c.Margins); err != nil {
+		return err
+	}
+
+	if c.Scale == 0 {
+		c.Scale = 1
+	}
+
+	if c.Scale < 0.1 || c.Scale > 2 {
+		return fmt.Errorf("scale: %v must be between 0.1 and 2", c.Scale)
+	}
+
+	if c.PageRanges != "" && !pageRangesRegexp.MatchString(c.PageRanges) {
+		return fmt.Errorf("page ranges: %s must be pages or ranges of pages like 1-5, 8", c.PageRanges)
+	}
+
+	if c.MaxFailedPanels < 0 || c.MaxFailedPanels > 1 {
+		return fmt.Errorf("max failed panels: %v must be between 0 and 1", c.MaxFailedPanels)
+	}
+
+	// Generate PDF reports by default
+	if c.Format == "" {
+		c.Format = "pdf"
+	}
+
+	if !slices.Contains(validFormats, c.Format) {
+		return fmt.Errorf("format: %s must be one of [%s]", c.Format, strings.Join(validFormats, ","))
+	}
+
 	// Set time zone to current server time zone if empty
 	if loc, err := time.LoadLocation(c.TimeZone); err != nil || c.TimeZone == "" {
 		c.Location = time.Now().Local().Location()
@@ -104,18 +244,121 @@ func (c *Config) Validate() error {
 		c.TimeFormat = time.UnixDate
 	}
 
-	// Verify RemoteChromeURL
+	// RemoteChromeURL is the first remote chrome instance of the pool
+	if c.RemoteChromeURL != "" && !slices.Contains(c.RemoteChromeURLs, c.RemoteChromeURL) {
+		c.RemoteChromeURLs = append([]string{c.RemoteChromeURL}, c.RemoteChromeURLs...)
+	}
+
+	// Verify RemoteChromeURLs
 	// url.Parse almost allows all the URLs. Need to check Scheme and Host
-	if c.RemoteChromeURL != "" {
-		if u, err := url.Parse(c.RemoteChromeURL); err != nil {
-			return err
+	for _, remoteChromeURL := range c.RemoteChromeURLs {
+		if u, err := url.Parse(remoteChromeURL); err != nil {
+			return fmt.Errorf("remote chrome url %s: %w", remoteChromeURL, err)
 		} else {
 			if u.Scheme == "" || u.Host == "" {
-				return errors.New("remote chrome url is invalid")
+				return fmt.Errorf("remote chrome url %s is invalid", remoteChromeURL)
+			}
+		}
+	}
+
+	// Spread tabs across remote chrome instances in turn by default
+	if c.RemoteChromeBalancing == "" {
+		c.RemoteChromeBalancing = "round-robin"
+	}
+
+	if !slices.Contains(validBalancings, c.RemoteChromeBalancing) {
+		return fmt.Errorf("remote chrome balancing: %s must be one of [%s]", c.RemoteChromeBalancing, strings.Join(validBalancings, ","))
+	}
+
+	// Keep results of report jobs for an hour by default
+	if c.JobRetention <= 0 {
+		c.JobRetention = 3600
+	}
+
+	// Queues of worker pools are always bounded
+	if c.MaxQueuedTasks <= 0 {
+		c.MaxQueuedTasks = 1000
+	}
+
+	// Check SMTP settings only when email delivery is configured
+	if c.SMTPHost != "" {
+		if !slices.Contains(validSMTPTLSModes, c.SMTPTLS) {
+			return fmt.Errorf("smtp tls: %s must be one of [%s]", c.SMTPTLS, strings.Join(validSMTPTLSModes, ","))
+		}
+
+		if c.SMTPFrom == "" {
+			return errors.New("smtp from address is required when smtp host is set")
+		}
+
+		if _, err := mail.ParseAddress(c.SMTPFrom); err != nil {
+			return fmt.Errorf("smtp from address: %w", err)
+		}
+
+		// Use submission ports by default
+		if c.SMTPPort == 0 {
+			c.SMTPPort = 587
+			if c.SMTPTLS == "tls" {
+				c.SMTPPort = 465
 			}
 		}
 	}
 
+	// Check object storage settings only when archiving of reports is configured
+	if c.S3Bucket != "" {
+		if u, err := url.Parse(c.S3Endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+			return errors.New("s3 endpoint is invalid")
+		}
+
+		if c.S3Region == "" {
+			c.S3Region = "us-east-1"
+		}
+
+		if _, err := template.New("prefix").Parse(c.S3Prefix); err != nil {
+			return fmt.Errorf("s3 prefix: %w", err)
+		}
+	}
+
+	for _, webhookURL := range c.WebhookURLs {
+		if u, err := url.Parse(webhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook url %s is invalid", webhookURL)
+		}
+	}
+
+	if c.WebhookMaxRetries < 0 {
+		c.WebhookMaxRetries = 0
+	}
+
+	// Panel cache is disabled by default
+	if c.PanelCache == "" {
+		c.PanelCache = "none"
+	}
+
+	if !slices.Contains(validPanelCaches, c.PanelCache) {
+		return fmt.Errorf("panel cache: %s must be one of [%s]", c.PanelCache, strings.Join(validPanelCaches, ","))
+	}
+
+	// Keep disk cache inside plugin storage by default
+	if c.PanelCache == "disk" && c.PanelCachePath == "" {
+		if c.StoragePath == "" {
+			return errors.New("panel cache path is required when storage path is not set")
+		}
+
+		c.PanelCachePath = filepath.Join(c.StoragePath, "panel-cache")
+	}
+
+	if c.PanelCacheMaxSize <= 0 {
+		c.PanelCacheMaxSize = 100
+	}
+
+	if c.PanelCacheTTL <= 0 {
+		c.PanelCacheTTL = 300
+	}
+
+	// Panels of relative time ranges are not cached by default
+	if c.PanelCacheGranularity < 0 {
+		c.PanelCacheGranularity = 0
+	}
+
 	// If AppVersion is empty, set it to 0.0.0
 	if c.AppVersion == "" {
 		c.AppVersion = "0.0.0"
@@ -124,6 +367,21 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// CollapsedRowsMode returns how collapsed rows of dashboard are rendered. When
+// it is not configured, collapsed rows are expanded in full dashboard mode and
+// skipped otherwise.
+func (c *Config) CollapsedRowsMode() string {
+	if c.CollapsedRows != "" {
+		return c.CollapsedRows
+	}
+
+	if c.DashboardMode == "full" {
+		return "expand"
+	}
+
+	return "skip"
+}
+
 // String implements the stringer interface of Config.
 func (c *Config) String() string {
 	var encodedLogo string
@@ -157,11 +415,11 @@ func (c *Config) String() string {
 	return fmt.Sprintf(
 		"Theme: %s; Orientation: %s; Layout: %s; Dashboard Mode: %s; "+
 			"Time Zone: %s; Time Format: %s; Encoded Logo: %s; "+
-			"Max Renderer Workers: %d; Max Browser Workers: %d; Remote Chrome Addr: %s; App URL: %s; "+
+			"Max Renderer Workers: %d; Max Browser Workers: %d; Remote Chrome Addrs: %s; App URL: %s; "+
 			"TLS Skip verify: %v; Included Panel IDs: %s; Excluded Panel IDs: %s Included Data for Panel IDs: %s; "+
 			"Native Renderer: %v; Client Timeout: %d",
 		c.Theme, c.Orientation, c.Layout, c.DashboardMode, c.TimeZone, c.TimeFormat,
-		encodedLogo, c.MaxRenderWorkers, c.MaxBrowserWorkers, c.RemoteChromeURL, appURL,
+		encodedLogo, c.MaxRenderWorkers, c.MaxBrowserWorkers, strings.Join(c.RemoteChromeURLs, ","), appURL,
 		c.SkipTLSCheck, includedPanelIDs, excludedPanelIDs, includeDataPanelIDs, c.NativeRendering,
 		c.Timeout,
 	)
@@ -172,17 +430,26 @@ func Load(ctx context.Context, settings backend.AppInstanceSettings) (Config, er
 	// Always start with a default config so that when the plugin is not provisioned
 	// with a config, we will still have "non-null" config to work with
 	config := Config{
-		Theme:             "light",
-		Orientation:       "portrait",
-		Layout:            "simple",
-		DashboardMode:     "default",
-		TimeZone:          "",
-		TimeFormat:        "",
-		EncodedLogo:       "",
-		HeaderTemplate:    "",
-		FooterTemplate:    "",
-		MaxBrowserWorkers: 2,
-		MaxRenderWorkers:  2,
+		Theme:                "light",
+		Orientation:          "portrait",
+		Layout:               "simple",
+		DashboardMode:        "default",
+		TimeZone:             "",
+		TimeFormat:           "",
+		EncodedLogo:          "",
+		HeaderTemplate:       "",
+		FooterTemplate:       "",
+		MaxBrowserWorkers:    2,
+		MaxRenderWorkers:     2,
+		MaxQueuedTasks:       1000,
+		JobRetention:         3600,
+		SMTPTLS:              "starttls",
+		EmailSubjectTemplate: `{{.Title}}`,
+		EmailBodyTemplate:    "Please find attached the report of dashboard {{.Title}} from {{.From}} to {{.To}}.",
+		S3Region:             "us-east-1",
+		S3Prefix:             `{{.DashboardUID}}/{{.Date.Format "2006/01/02"}}/`,
+		WebhookMaxRetries:    3,
+		MaxFailedPanels:      0.5,
 		// Set default timeout values (in seconds) - increased for slow operations
 		Timeout:                 120, // 2 minutes default timeout
 		DialTimeout:             10,
@@ -206,6 +473,11 @@ func Load(ctx context.Context, settings backend.AppInstanceSettings) (Config, er
 		if saToken, ok := settings.DecryptedSecureJSONData[SaToken]; ok && saToken != "" {
 			config.Token = saToken
 		}
+
+		config.SMTPPassword = settings.DecryptedSecureJSONData[SMTPPassword]
+		config.S3AccessKey = settings.DecryptedSecureJSONData[S3AccessKey]
+		config.S3SecretKey = settings.DecryptedSecureJSONData[S3SecretKey]
+		config.WebhookSecret = settings.DecryptedSecureJSONData[WebhookSecret]
 	}
 
 	// Update plugin settings defaults
@@ -220,6 +492,13 @@ func Load(ctx context.Context, settings backend.AppInstanceSettings) (Config, er
 		return Config{}, fmt.Errorf("error in reading config env vars: %w", err)
 	}
 
+	// Use a folder inside Grafana data path to persist plugin state, if not configured
+	if config.StoragePath == "" {
+		if dataPath, err := helpers.GrafanaDataPath(); err == nil {
+			config.StoragePath = filepath.Join(dataPath, ".reporter")
+		}
+	}
+
 	// Initialize CustomQueryParams if nil
 	if config.CustomQueryParams == nil {
 		config.CustomQueryParams = make(map[string]string)
@@ -87,6 +87,201 @@ func TestSettingsWithCustomQueryParams(t *testing.T) {
 	})
 }
 
+func TestSettingsWithSMTP(t *testing.T) {
+	Convey("When creating a new config with SMTP settings", t, func() {
+		const configJSON = `{"smtpHost": "smtp.example.com", "smtpTls": "tls", "smtpFrom": "reporter@example.com"}`
+		configData := json.RawMessage(configJSON)
+		config, err := Load(t.Context(), backend.AppInstanceSettings{
+			JSONData:                configData,
+			DecryptedSecureJSONData: map[string]string{SMTPPassword: "secret"},
+		})
+
+		Convey("Config should contain SMTP settings with default port", func() {
+			So(err, ShouldBeNil)
+			So(config.SMTPHost, ShouldEqual, "smtp.example.com")
+			So(config.SMTPPort, ShouldEqual, 465)
+			So(config.SMTPPassword, ShouldEqual, "secret")
+			So(config.EmailSubjectTemplate, ShouldEqual, "{{.Title}}")
+		})
+	})
+
+	Convey("When creating a new config with invalid SMTP settings", t, func() {
+		for _, configJSON := range []string{
+			`{"smtpHost": "smtp.example.com", "smtpTls": "ssl", "smtpFrom": "reporter@example.com"}`,
+			`{"smtpHost": "smtp.example.com"}`,
+			`{"smtpHost": "smtp.example.com", "smtpFrom": "not an address"}`,
+		} {
+			_, err := Load(t.Context(), backend.AppInstanceSettings{JSONData: json.RawMessage(configJSON)})
+
+			Convey("Config should be rejected: "+configJSON, func() {
+				So(err, ShouldNotBeNil)
+			})
+		}
+	})
+}
+
+func TestSettingsWithRemoteChromeURLs(t *testing.T) {
+	Convey("When creating a new config with a pool of remote chrome instances", t, func() {
+		const configJSON = `{"remoteChromeUrl": "ws://chrome-1:9222", "remoteChromeUrls": ["ws://chrome-2:9222", "ws://chrome-1:9222"]}`
+		config, err := Load(t.Context(), backend.AppInstanceSettings{JSONData: json.RawMessage(configJSON)})
+
+		Convey("Config should contain every remote chrome instance once", func() {
+			So(err, ShouldBeNil)
+			So(config.RemoteChromeURLs, ShouldResemble, []string{"ws://chrome-2:9222", "ws://chrome-1:9222"})
+			So(config.RemoteChromeBalancing, ShouldEqual, "round-robin")
+		})
+	})
+
+	Convey("When creating a new config with invalid remote chrome settings", t, func() {
+		for _, configJSON := range []string{
+			`{"remoteChromeUrl": "chrome-1"}`,
+			`{"remoteChromeUrls": ["ws://chrome-1:9222", "chrome-2"]}`,
+			`{"remoteChromeUrls": ["ws://chrome-1:9222"], "remoteChromeBalancing": "random"}`,
+		} {
+			_, err := Load(t.Context(), backend.AppInstanceSettings{JSONData: json.RawMessage(configJSON)})
+
+			Convey("Config should be rejected: "+configJSON, func() {
+				So(err, ShouldNotBeNil)
+			})
+		}
+	})
+}
+
+func TestSettingsWithPanelCache(t *testing.T) {
+	Convey("When creating a new config with a disk panel cache", t, func() {
+		const configJSON = `{"panelCache": "disk", "storagePath": "/var/lib/reporter"}`
+		config, err := Load(t.Context(), backend.AppInstanceSettings{JSONData: json.RawMessage(configJSON)})
+
+		Convey("Config should contain default panel cache settings", func() {
+			So(err, ShouldBeNil)
+			So(config.PanelCachePath, ShouldEqual, "/var/lib/reporter/panel-cache")
+			So(config.PanelCacheMaxSize, ShouldEqual, 100)
+			So(config.PanelCacheTTL, ShouldEqual, 300)
+			So(config.PanelCacheGranularity, ShouldEqual, 0)
+		})
+	})
+
+	Convey("When creating a new config with invalid panel cache", t, func() {
+		_, err := Load(t.Context(), backend.AppInstanceSettings{JSONData: json.RawMessage(`{"panelCache": "redis"}`)})
+
+		Convey("Config should be rejected", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestSettingsWithPanelDiscovery(t *testing.T) {
+	Convey("When creating a new config without panel discovery", t, func() {
+		config, err := Load(t.Context(), backend.AppInstanceSettings{JSONData: json.RawMessage(`{}`)})
+
+		Convey("Panels should be discovered from browser", func() {
+			So(err, ShouldBeNil)
+			So(config.PanelDiscovery, ShouldEqual, "browser")
+		})
+	})
+
+	Convey("When creating a new config with invalid panel discovery", t, func() {
+		_, err := Load(t.Context(), backend.AppInstanceSettings{JSONData: json.RawMessage(`{"panelDiscovery": "api"}`)})
+
+		Convey("Config should be rejected", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestSettingsWithCollapsedRows(t *testing.T) {
+	Convey("When creating a new config without collapsed rows mode", t, func() {
+		config, err := Load(t.Context(), backend.AppInstanceSettings{JSONData: json.RawMessage(`{}`)})
+		So(err, ShouldBeNil)
+
+		Convey("Collapsed rows should follow dashboard mode", func() {
+			So(config.CollapsedRowsMode(), ShouldEqual, "skip")
+
+			config.DashboardMode = "full"
+			So(config.CollapsedRowsMode(), ShouldEqual, "expand")
+
+			config.CollapsedRows = "title"
+			So(config.CollapsedRowsMode(), ShouldEqual, "title")
+		})
+	})
+
+	Convey("When creating a new config with invalid collapsed rows mode", t, func() {
+		_, err := Load(t.Context(), backend.AppInstanceSettings{JSONData: json.RawMessage(`{"collapsedRows": "hide"}`)})
+
+		Convey("Config should be rejected", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestSettingsWithPaper(t *testing.T) {
+	Convey("When creating a new config without paper settings", t, func() {
+		config, err := Load(t.Context(), backend.AppInstanceSettings{JSONData: json.RawMessage(`{}`)})
+		So(err, ShouldBeNil)
+
+		Convey("Config should contain default paper settings", func() {
+			So(config.PaperSize, ShouldEqual, "Letter")
+			So(config.Margins, ShouldEqual, "3cm 0cm 1cm 0cm")
+			So(config.Scale, ShouldEqual, 1)
+
+			width, height := config.PaperDimensions()
+			So(width, ShouldEqual, 8.5)
+			So(height, ShouldEqual, 11)
+			So(config.PageMargins()[0], ShouldAlmostEqual, 3/2.54)
+		})
+	})
+
+	Convey("When creating a new config with a custom paper", t, func() {
+		const configJSON = `{"paperSize": "8in x 254mm", "margins": "1in 2in 0", "orientation": "landscape", "pageRanges": "1-5, 8, 11-"}`
+		config, err := Load(t.Context(), backend.AppInstanceSettings{JSONData: json.RawMessage(configJSON)})
+		So(err, ShouldBeNil)
+
+		Convey("Paper should have custom dimensions and margins", func() {
+			width, height := config.PaperDimensions()
+			So(width, ShouldEqual, 8)
+			So(height, ShouldAlmostEqual, 10)
+			So(config.PageMargins(), ShouldResemble, [4]float64{1, 2, 0, 2})
+			So(config.PageSizeCSS(), ShouldEqual, "254mm 8in")
+		})
+	})
+
+	Convey("When creating a new config with invalid paper settings", t, func() {
+		for _, configJSON := range []string{
+			`{"paperSize": "B7"}`,
+			`{"paperSize": "8in x 0"}`,
+			`{"margins": "1in 2em"}`,
+			`{"scale": 3}`,
+			`{"pageRanges": "1-5, last"}`,
+		} {
+			_, err := Load(t.Context(), backend.AppInstanceSettings{JSONData: json.RawMessage(configJSON)})
+
+			Convey("Config should be rejected: "+configJSON, func() {
+				So(err, ShouldNotBeNil)
+			})
+		}
+	})
+}
+
+func TestSettingsWithPartialReports(t *testing.T) {
+	Convey("When creating a new config without partial reports settings", t, func() {
+		config, err := Load(t.Context(), backend.AppInstanceSettings{JSONData: json.RawMessage(`{}`)})
+
+		Convey("Failures of panels should not be tolerated", func() {
+			So(err, ShouldBeNil)
+			So(config.TolerateFailures, ShouldBeFalse)
+			So(config.MaxFailedPanels, ShouldEqual, 0.5)
+		})
+	})
+
+	Convey("When creating a new config with invalid fraction of failed panels", t, func() {
+		_, err := Load(t.Context(), backend.AppInstanceSettings{JSONData: json.RawMessage(`{"maxFailedPanels": 1.5}`)})
+
+		Convey("Config should be rejected", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
 func TestSettingsUsingEnvVars(t *testing.T) {
 	// Setup env vars
 	t.Setenv("GF_REPORTER_PLUGIN_APP_URL", "https://localhost:3000")
@@ -0,0 +1,119 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/metrics"
+)
+
+// Kinds of panel data in cache.
+const (
+	panelPNGKind = "png"
+	panelCSVKind = "csv"
+)
+
+// cached returns panel data of kind from cache. When data is not cached, it
+// is fetched and cached.
+func cached[T any](d *Dashboard, kind string, p Panel, fetch func() (T, error)) (T, error) {
+	if d.cache == nil {
+		return fetch()
+	}
+
+	key, ok := d.cacheKey(kind, p)
+	if !ok {
+		metrics.PanelCacheRequests.WithLabelValues(kind, "bypass").Inc()
+
+		return fetch()
+	}
+
+	if value, ok := d.cache.Get(key); ok {
+		var data T
+		if err := json.Unmarshal(value, &data); err == nil {
+			metrics.PanelCacheRequests.WithLabelValues(kind, "hit").Inc()
+			d.logger.Debug("got panel data from cache", "panel_id", p.ID, "kind", kind)
+
+			return data, nil
+		}
+	}
+
+	metrics.PanelCacheRequests.WithLabelValues(kind, "miss").Inc()
+
+	data, err := fetch()
+	if err != nil {
+		return data, err
+	}
+
+	value, err := json.Marshal(data)
+	if err == nil {
+		err = d.cache.Set(key, value)
+	}
+
+	// Failing to cache data does not fail the report
+	if err != nil {
+		d.logger.Warn("failed to cache panel data", "panel_id", p.ID, "kind", kind, "err", err)
+	}
+
+	return data, nil
+}
+
+// cacheKey returns the key of panel data of kind in cache. It contains all
+// the parameters used to render panel with time range resolved to absolute
+// time. Data is not cached when time range is relative to now, unless a
+// granularity is configured, in which case relative time range is rounded
+// to it. Data is not cached either when time range is not set as panel is
+// then rendered with the saved time range of dashboard.
+func (d *Dashboard) cacheKey(kind string, p Panel) (_ string, ok bool) {
+	values := d.panelVariables(p)
+	if values.Get("from") == "" || values.Get("to") == "" {
+		return "", false
+	}
+
+	timeRange := NewTimeRange(values.Get("from"), values.Get("to"))
+	granularity := time.Duration(d.conf.PanelCacheGranularity) * time.Second
+
+	relative := strings.Contains(timeRange.From, "now") || strings.Contains(timeRange.To, "now")
+	if relative && granularity == 0 {
+		return "", false
+	}
+
+	// Time parser panics on unrecognised formats. Do not cache such panels
+	defer func() {
+		if r := recover(); r != nil {
+			ok = false
+		}
+	}()
+
+	from, to := timeRange.FromTime(), timeRange.ToTime()
+	if relative {
+		from, to = from.Truncate(granularity), to.Truncate(granularity)
+	}
+
+	values.Del("from")
+	values.Del("to")
+
+	w, h := d.panelDims(p)
+
+	values.Set("cache.kind", kind)
+	values.Set("cache.appURL", d.appURL.String())
+	values.Set("cache.dashboardID", strconv.Itoa(d.model.Dashboard.ID))
+	values.Set("cache.dashboardUID", d.model.Dashboard.UID)
+	values.Set("cache.version", strconv.Itoa(d.model.Meta.Version))
+	values.Set("cache.panelID", p.ID)
+	values.Set("cache.from", strconv.FormatInt(from.UnixMilli(), 10))
+	values.Set("cache.to", strconv.FormatInt(to.UnixMilli(), 10))
+	values.Set("cache.theme", d.conf.Theme)
+	values.Set("cache.timeZone", d.conf.TimeZone)
+	values.Set("cache.width", strconv.FormatInt(w, 10))
+	values.Set("cache.height", strconv.FormatInt(h, 10))
+	values.Set("cache.nativeRendering", strconv.FormatBool(d.conf.NativeRendering))
+
+	for name, value := range d.conf.CustomQueryParams {
+		values.Set("cache.customQueryParams."+name, value)
+	}
+
+	// Encoded values are sorted by name
+	return values.Encode(), true
+}
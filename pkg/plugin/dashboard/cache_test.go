@@ -0,0 +1,104 @@
+package dashboard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/cache"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/chrome"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPanelCache(t *testing.T) {
+	Convey("When fetching panel PNGs with a panel cache", t, func() {
+		var requests atomic.Int32
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			requests.Add(1)
+			w.Write([]byte("png")) //nolint:errcheck
+		}))
+		defer ts.Close()
+
+		conf := config.Config{Layout: "simple", Theme: "light"}
+
+		newDashboard := func(from, to string) *Dashboard {
+			model := &Model{}
+			model.Dashboard.ID = 1
+			model.Dashboard.UID = "uid"
+			model.Dashboard.Variables = url.Values{"from": {from}, "to": {to}, "var-host": {"server"}}
+
+			dash, err := New(log.NewNullLogger(), &conf, http.DefaultClient, &chrome.LocalInstance{},
+				ts.URL, "v11.4.0", model, http.Header{}, cache.NewMemory(1024, time.Hour))
+			So(err, ShouldBeNil)
+
+			return dash
+		}
+
+		panel := Panel{ID: "2", Type: "graph"}
+
+		Convey("Panels of absolute time ranges should be rendered once", func() {
+			dash := newDashboard("1700000000000", "1700003600000")
+
+			image, err := dash.PanelPNG(t.Context(), panel)
+			So(err, ShouldBeNil)
+
+			cachedImage, err := dash.PanelPNG(t.Context(), panel)
+			So(err, ShouldBeNil)
+			So(cachedImage, ShouldResemble, image)
+			So(requests.Load(), ShouldEqual, 1)
+
+			// Other panels and themes are rendered again
+			_, err = dash.PanelPNG(t.Context(), Panel{ID: "3", Type: "graph"})
+			So(err, ShouldBeNil)
+
+			conf.Theme = "dark"
+			_, err = dash.PanelPNG(t.Context(), panel)
+			So(err, ShouldBeNil)
+			So(requests.Load(), ShouldEqual, 3)
+		})
+
+		Convey("Panels of relative time ranges should not be cached", func() {
+			dash := newDashboard("now-1h", "now")
+
+			_, ok := dash.cacheKey(panelPNGKind, panel)
+			So(ok, ShouldBeFalse)
+
+			for range 2 {
+				_, err := dash.PanelPNG(t.Context(), panel)
+				So(err, ShouldBeNil)
+			}
+
+			So(requests.Load(), ShouldEqual, 2)
+		})
+
+		Convey("Panels without time range should not be cached", func() {
+			conf.PanelCacheGranularity = 3600
+			dash := newDashboard("", "")
+
+			_, ok := dash.cacheKey(panelPNGKind, panel)
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("Panels of relative time ranges should be cached with a granularity", func() {
+			conf.PanelCacheGranularity = 3600
+			dash := newDashboard("now-1h", "now")
+
+			key, ok := dash.cacheKey(panelPNGKind, panel)
+			So(ok, ShouldBeTrue)
+			So(key, ShouldNotContainSubstring, "now")
+
+			for range 2 {
+				_, err := dash.PanelPNG(t.Context(), panel)
+				So(err, ShouldBeNil)
+			}
+
+			So(requests.Load(), ShouldEqual, 1)
+		})
+	})
+}
@@ -10,10 +10,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/cache"
 	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/chrome"
 	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
 	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/helpers"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Embed the entire directory.
@@ -21,9 +23,10 @@ import (
 //go:embed js
 var jsFS embed.FS
 
-// New creates a new instance of the Dashboard struct.
+// New creates a new instance of the Dashboard struct. Panel PNGs and CSV data
+// are cached in panelCache, unless it is nil.
 func New(logger log.Logger, conf *config.Config, httpClient *http.Client, chromeInstance chrome.Instance,
-	appURL, appVersion string, model *Model, authHeader http.Header,
+	appURL, appVersion string, model *Model, authHeader http.Header, panelCache cache.Cache,
 ) (*Dashboard, error) {
 	// Parse app URL
 	u, err := url.Parse(appURL)
@@ -47,26 +50,39 @@ func New(logger log.Logger, conf *config.Config, httpClient *http.Client, chrome
 		string(js),
 		model,
 		authHeader,
+		panelCache,
 	}, nil
 }
 
 // GetData fetches dashboard related data.
-func (d *Dashboard) GetData(ctx context.Context) (*Data, error) {
+func (d *Dashboard) GetData(ctx context.Context) (_ *Data, err error) {
 	defer helpers.TimeTrack(time.Now(), "dashboard data", d.logger)
 
-	// Make panels from loading the dashboard in a browser instance
-	panels, err := d.panels(ctx)
+	ctx, span := helpers.StartSpan(ctx, "dashboard.GetData", attribute.String("dashboard.uid", d.model.Dashboard.UID))
+	defer func() { helpers.EndSpan(span, err) }()
+
+	// Make panels from dashboard model or by loading the dashboard in a
+	// browser instance
+	panels, rows, err := d.panels(ctx)
 	if err != nil {
-		d.logger.Error("error collecting panels from browser", "error", err)
+		d.logger.Error("error collecting panels", "error", err)
 
-		return nil, fmt.Errorf("error collecting panels from browser: %w", err)
+		return nil, fmt.Errorf("error collecting panels: %w", err)
 	}
 
 	return &Data{
+		UID:       d.model.Dashboard.UID,
 		Title:     d.model.Dashboard.Title,
 		TimeRange: NewTimeRange(d.model.Dashboard.Variables.Get("from"), d.model.Dashboard.Variables.Get("to")),
 		Variables: variablesValues(d.model.Dashboard.Variables),
 		Panels:    panels,
+		Rows:      rows,
+
+		Description: d.model.Dashboard.Description,
+		Tags:        d.model.Dashboard.Tags,
+		CreatedBy:   d.model.Meta.CreatedBy,
+
+		TemplateVariables: templateVariables(d.model.Dashboard.Variables),
 	}, err
 }
 
@@ -84,3 +100,17 @@ func variablesValues(queryParams url.Values) string {
 
 	return strings.Join(values, "; ")
 }
+
+// templateVariables returns current dashboard template variables and their
+// values.
+func templateVariables(queryParams url.Values) map[string][]string {
+	values := make(map[string][]string)
+
+	for k, v := range queryParams {
+		if n, ok := strings.CutPrefix(k, "var-"); ok {
+			values[n] = v
+		}
+	}
+
+	return values
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/csv"
 	"fmt"
-	"maps"
 	"net/url"
 	"strconv"
 	"strings"
@@ -12,20 +11,38 @@ import (
 
 	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/chrome"
 	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/helpers"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/metrics"
 	"github.com/chromedp/cdproto/browser"
 	"github.com/chromedp/cdproto/runtime"
 	"github.com/chromedp/chromedp"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-// PanelCSV returns CSV data of a given panel.
-func (d *Dashboard) PanelCSV(_ context.Context, p Panel) (CSVData, error) {
+// PanelCSV returns CSV data of a given panel. Data is served from panel
+// cache when available.
+func (d *Dashboard) PanelCSV(ctx context.Context, p Panel) (CSVData, error) {
+	return cached(d, panelCSVKind, p, func() (CSVData, error) {
+		return d.panelCSV(ctx, p)
+	})
+}
+
+// panelCSV fetches CSV data of a given panel from browser.
+func (d *Dashboard) panelCSV(ctx context.Context, p Panel) (_ CSVData, err error) {
 	// Get panel CSV data URL
 	panelURL := d.panelCSVURL(p)
 
+	_, span := helpers.StartSpan(ctx, "dashboard.PanelCSV",
+		attribute.String("dashboard.uid", d.model.Dashboard.UID),
+		attribute.String("panel.id", p.ID),
+		attribute.String("url", panelURL.String()),
+	)
+	defer func() { helpers.EndSpan(span, err) }()
+
 	defer helpers.TimeTrack(time.Now(), "fetch panel CSV data", d.logger, "fetcher", "native", "panel_id", p.ID, "url", panelURL.String())
+	defer func(start time.Time) { metrics.Observe(metrics.StagePanelCSV, start, err) }(time.Now())
 
 	// Create a new tab
-	tab := d.chromeInstance.NewTab(d.logger, d.conf)
+	tab := d.chromeInstance.NewTab(ctx, d.logger, d.conf)
 	// Set a timeout for the tab
 	// Fail-safe for newer Grafana versions, if css has been changed.
 	tab.WithTimeout(2 * d.conf.HTTPClientOptions.Timeouts.Timeout)
@@ -39,7 +56,7 @@ func (d *Dashboard) PanelCSV(_ context.Context, p Panel) (CSVData, error) {
 		}
 	}
 
-	err := tab.NavigateAndWaitFor(panelURL.String(), headers, "networkIdle")
+	err = tab.NavigateAndWaitFor(panelURL.String(), headers, "networkIdle")
 	if err != nil {
 		return nil, fmt.Errorf("NavigateAndWaitFor: %w", err)
 	}
@@ -135,10 +152,10 @@ func (d *Dashboard) PanelCSV(_ context.Context, p Panel) (CSVData, error) {
 
 // panelCSVURL returns URL to fetch panel's CSV data.
 func (d *Dashboard) panelCSVURL(p Panel) *url.URL {
-	values := maps.Clone(d.model.Dashboard.Variables)
+	values := d.panelVariables(p)
 	values.Add("theme", d.conf.Theme)
-	values.Add("viewPanel", p.ID)
-	values.Add("inspect", p.ID)
+	values.Add("viewPanel", p.renderID())
+	values.Add("inspect", p.renderID())
 	values.Add("inspectTab", "data")
 
 	// Make a copy of appURL
@@ -0,0 +1,366 @@
+package dashboard
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"math"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/helpers"
+)
+
+// Number of columns of Grafana dashboard grid.
+const gridColumns = 24
+
+// Maximum number of horizontally repeated panels per row when not set in
+// JSON model, which is the default of Grafana.
+const defaultMaxPerRow = 4
+
+// section is a group of panels of JSON model, which are the panels of a row
+// or the panels before the first row.
+type section struct {
+	row    *RowOrPanel
+	panels []Panel
+}
+
+// modelPanels creates panels and rows from the JSON model of dashboard.
+// Repeated rows and panels are expanded for the selected values of their
+// variables. Collapsed rows are skipped, expanded or kept as title only
+// depending on config.
+func (d *Dashboard) modelPanels() ([]Panel, []Row, error) {
+	var (
+		panels []Panel
+		rows   []Row
+		y      float64
+	)
+
+	// Number of instances of panels by their ID to make unique IDs of repeats
+	instances := make(map[string]int)
+
+	collapsedRows := d.conf.CollapsedRowsMode()
+
+	for _, s := range d.model.sections() {
+		// Each value of repeat variable of row gets an instance of the row
+		rowVariables := []url.Values{nil}
+		if s.row != nil {
+			rowVariables = d.repeatVariables(s.row.Repeat)
+		}
+
+		for _, variables := range rowVariables {
+			var row *Row
+
+			if s.row != nil {
+				row = &Row{
+					ID:        d.panelID(cloneID(s.row.ID, instances)),
+					Title:     d.interpolate(s.row.Title, variables),
+					Collapsed: s.row.Collapsed,
+				}
+
+				// Row header takes a grid unit
+				y++
+
+				if row.Collapsed && collapsedRows == "skip" {
+					continue
+				}
+
+				rows = append(rows, *row)
+
+				if row.Collapsed && collapsedRows == "title" {
+					continue
+				}
+			}
+
+			expanded, height := d.expandPanels(s.panels, variables, instances)
+
+			for _, p := range expanded {
+				p.GridPos.Y += y
+				p.Row = row
+				panels = append(panels, p)
+			}
+
+			y += height
+		}
+	}
+
+	if len(panels) == 0 {
+		return nil, nil, ErrNoPanels
+	}
+
+	d.logger.Debug("discovered panels from dashboard model", "num_panels", len(panels), "num_rows", len(rows))
+
+	return panels, rows, nil
+}
+
+// expandPanels expands repeated panels of a section for the selected values
+// of their variables. Panels are positioned from the top of section like
+// Grafana does and the height of section is returned.
+func (d *Dashboard) expandPanels(sectionPanels []Panel, rowVariables url.Values, instances map[string]int) ([]Panel, float64) {
+	sectionPanels = slices.Clone(sectionPanels)
+	slices.SortStableFunc(sectionPanels, func(a, b Panel) int {
+		return cmp.Or(cmp.Compare(a.GridPos.Y, b.GridPos.Y), cmp.Compare(a.GridPos.X, b.GridPos.X))
+	})
+
+	top := math.MaxFloat64
+	for _, p := range sectionPanels {
+		top = min(top, p.GridPos.Y)
+	}
+
+	// Extra height taken by repeated panels by their position in JSON model.
+	// Panels below them are pushed down by this height
+	type push struct{ y, height float64 }
+
+	var (
+		pushes []push
+		panels []Panel
+		height float64
+	)
+
+	for _, p := range sectionPanels {
+		y := p.GridPos.Y - top
+
+		for _, push := range pushes {
+			if p.GridPos.Y > push.y {
+				y += push.height
+			}
+		}
+
+		// Repeat variable of row is fixed for its panels
+		panelVariables := []url.Values{nil}
+		if _, ok := rowVariables["var-"+p.Repeat]; !ok {
+			panelVariables = d.repeatVariables(p.Repeat)
+		}
+
+		n := float64(len(panelVariables))
+
+		// Grafana stacks vertical repeats and fits horizontal repeats in rows
+		// of maxPerRow panels
+		perRow := n
+		width := p.GridPos.W
+
+		switch {
+		case p.RepeatDirection == "v":
+			perRow = 1
+		case n > 1:
+			maxPerRow := float64(cmp.Or(p.MaxPerRow, defaultMaxPerRow))
+			width = max(gridColumns/n, gridColumns/maxPerRow)
+			perRow = math.Floor(gridColumns / width)
+		}
+
+		for i, variables := range panelVariables {
+			instance := p
+			instance.ID = d.panelID(cloneID(p.ID, instances))
+
+			if rowVariables != nil || variables != nil {
+				instance.RepeatVariables = url.Values{}
+				for name, values := range rowVariables {
+					instance.RepeatVariables[name] = values
+				}
+
+				for name, values := range variables {
+					instance.RepeatVariables[name] = values
+				}
+			}
+
+			instance.Title = d.interpolate(p.Title, instance.RepeatVariables)
+			instance.GridPos.Y = y
+
+			switch {
+			case n == 1:
+			case p.RepeatDirection == "v":
+				instance.GridPos.Y = y + float64(i)*p.GridPos.H
+			default:
+				instance.GridPos.W = width
+				instance.GridPos.X = math.Mod(float64(i), perRow) * width
+				instance.GridPos.Y = y + math.Floor(float64(i)/perRow)*p.GridPos.H
+			}
+
+			panels = append(panels, instance)
+			height = max(height, instance.GridPos.Y+instance.GridPos.H)
+		}
+
+		if rows := math.Ceil(n / perRow); rows > 1 {
+			pushes = append(pushes, push{p.GridPos.Y, (rows - 1) * p.GridPos.H})
+		}
+	}
+
+	return panels, height
+}
+
+// repeatVariables returns the values of repeat variable name of a row or a
+// panel, one for every selected value of variable. It returns a single nil
+// value when there is no repeat variable or its values are unknown.
+func (d *Dashboard) repeatVariables(name string) []url.Values {
+	if name == "" {
+		return []url.Values{nil}
+	}
+
+	values := d.variableValues(name)
+	if len(values) == 0 {
+		return []url.Values{nil}
+	}
+
+	variables := make([]url.Values, len(values))
+	for i, value := range values {
+		variables[i] = url.Values{"var-" + name: {value}}
+	}
+
+	return variables
+}
+
+// variableValues returns the selected values of template variable name. All
+// the options of variable are returned when "All" is selected.
+func (d *Dashboard) variableValues(name string) []string {
+	var variable *TemplateVariable
+
+	for i := range d.model.Dashboard.Templating.List {
+		if d.model.Dashboard.Templating.List[i].Name == name {
+			variable = &d.model.Dashboard.Templating.List[i]
+
+			break
+		}
+	}
+
+	// Variables of request have precedence over the saved ones
+	values := d.model.Dashboard.Variables["var-"+name]
+	if len(values) == 0 && variable != nil {
+		values = stringValues(variable.Current.Value)
+	}
+
+	if !slices.Contains(values, "$__all") {
+		return values
+	}
+
+	if variable == nil {
+		return nil
+	}
+
+	values = nil
+
+	for _, option := range variable.Options {
+		for _, value := range stringValues(option.Value) {
+			if value != "$__all" {
+				values = append(values, value)
+			}
+		}
+	}
+
+	return values
+}
+
+// interpolate replaces the template variables of s by their values. Values
+// of variables override the values of dashboard.
+func (d *Dashboard) interpolate(s string, variables url.Values) string {
+	if !strings.ContainsAny(s, "$[") {
+		return s
+	}
+
+	list := slices.Clone(d.model.Dashboard.Templating.List)
+
+	// Replacer matches in the order of arguments. Longer names go first so
+	// that $hostname is not replaced as $host
+	slices.SortStableFunc(list, func(a, b TemplateVariable) int {
+		return cmp.Compare(len(b.Name), len(a.Name))
+	})
+
+	replacements := make([]string, 0, 6*len(list))
+
+	for _, variable := range list {
+		values, ok := variables["var-"+variable.Name]
+		if !ok {
+			values = d.variableValues(variable.Name)
+		}
+
+		value := strings.Join(values, ", ")
+
+		replacements = append(replacements,
+			"${"+variable.Name+"}", value,
+			"[["+variable.Name+"]]", value,
+			"$"+variable.Name, value,
+		)
+	}
+
+	return strings.NewReplacer(replacements...).Replace(s)
+}
+
+// renderID returns the ID of panel to render. Instances of repeated panels
+// are rendered as the repeated panel of JSON model with the values of their
+// repeat variables.
+func (p *Panel) renderID() string {
+	if p.RepeatVariables == nil {
+		return p.ID
+	}
+
+	return strings.Split(p.ID, "-clone")[0]
+}
+
+// panelVariables returns the template variables to render panel with, which
+// are the variables of dashboard overridden by its repeat variables.
+func (d *Dashboard) panelVariables(p Panel) url.Values {
+	values := url.Values{}
+	maps.Copy(values, d.model.Dashboard.Variables)
+	maps.Copy(values, p.RepeatVariables)
+
+	return values
+}
+
+// panelID returns panel ID in the format used by Grafana version.
+func (d *Dashboard) panelID(id string) string {
+	return helpers.PanelIDs(d.appVersion, []string{id})[0]
+}
+
+// sections returns the panels of JSON model grouped by rows.
+func (m *Model) sections() []section {
+	sections := []section{{}}
+
+	for _, rowOrPanel := range m.Dashboard.RowOrPanels {
+		if rowOrPanel.Type != "row" {
+			sections[len(sections)-1].panels = append(sections[len(sections)-1].panels, rowOrPanel.Panel)
+
+			continue
+		}
+
+		row := rowOrPanel
+
+		// Panels of collapsed rows are nested in the row. Panels of expanded
+		// rows follow the row
+		sections = append(sections, section{row: &row, panels: slices.Clone(row.Panels)})
+	}
+
+	return sections
+}
+
+// cloneID returns a unique ID for an instance of a panel or row. The first
+// instance keeps the ID of JSON model.
+func cloneID(id string, instances map[string]int) string {
+	n := instances[id]
+	instances[id]++
+
+	if n == 0 {
+		return id
+	}
+
+	return fmt.Sprintf("%s-clone-%d", id, n)
+}
+
+// stringValues returns the values of a template variable, which is either a
+// string or a list of strings.
+func stringValues(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+
+		return values
+	case nil:
+		return nil
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
@@ -0,0 +1,162 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/chrome"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestModelPanels(t *testing.T) {
+	Convey("When discovering panels from dashboard JSON model", t, func() {
+		var model Model
+
+		err := json.Unmarshal([]byte(`{"dashboard": {"uid": "abc", "panels": [
+			{"id": 1, "type": "timeseries", "title": "CPU", "gridPos": {"h": 8, "w": 12, "x": 0, "y": 0}},
+			{"id": 2, "type": "timeseries", "title": "Load of $host", "repeat": "host", "maxPerRow": 2,
+			 "gridPos": {"h": 8, "w": 12, "x": 12, "y": 0}},
+			{"id": 3, "type": "row", "title": "Network", "collapsed": false, "panels": [],
+			 "gridPos": {"h": 1, "w": 24, "x": 0, "y": 8}},
+			{"id": 4, "type": "stat", "title": "Traffic", "gridPos": {"h": 4, "w": 24, "x": 0, "y": 9}},
+			{"id": 5, "type": "row", "title": "Disks of [[host]]", "collapsed": true, "repeat": "host",
+			 "gridPos": {"h": 1, "w": 24, "x": 0, "y": 13},
+			 "panels": [{"id": 6, "type": "gauge", "title": "Usage", "gridPos": {"h": 4, "w": 6, "x": 0, "y": 14}}]}
+		], "templating": {"list": [
+			{"name": "host", "current": {"value": ["$__all"]},
+			 "options": [{"value": "$__all"}, {"value": "a"}, {"value": "b"}, {"value": "c"}]}
+		]}}}`), &model)
+		So(err, ShouldBeNil)
+
+		model.Dashboard.Variables = url.Values{}
+
+		conf := config.Config{DashboardMode: "default"}
+
+		newDashboard := func() *Dashboard {
+			dash, err := New(log.NewNullLogger(), &conf, http.DefaultClient, &chrome.LocalInstance{},
+				"http://localhost:3000", "v11.4.0", &model, http.Header{}, nil)
+			So(err, ShouldBeNil)
+
+			return dash
+		}
+
+		Convey("Repeated panels should be expanded for all values of variable", func() {
+			panels, _, err := newDashboard().modelPanels()
+			So(err, ShouldBeNil)
+
+			ids := make([]string, len(panels))
+			for i, p := range panels {
+				ids[i] = p.ID
+			}
+
+			So(ids, ShouldResemble, []string{"panel-1", "panel-2", "panel-2-clone-1", "panel-2-clone-2", "panel-4"})
+
+			So(panels[1].Title, ShouldEqual, "Load of a")
+			So(panels[2].Title, ShouldEqual, "Load of b")
+			So(panels[3].RepeatVariables, ShouldResemble, url.Values{"var-host": {"c"}})
+			So(panels[3].renderID(), ShouldEqual, "panel-2")
+
+			// Repeats are laid out in rows of maxPerRow panels
+			So(panels[1].GridPos, ShouldResemble, GridPos{X: 0, Y: 0, W: 12, H: 8})
+			So(panels[2].GridPos, ShouldResemble, GridPos{X: 12, Y: 0, W: 12, H: 8})
+			So(panels[3].GridPos, ShouldResemble, GridPos{X: 0, Y: 8, W: 12, H: 8})
+
+			// Panels below are pushed down by the repeats
+			So(panels[4].Row.Title, ShouldEqual, "Network")
+			So(panels[4].GridPos.Y, ShouldEqual, 17)
+			So(panels[0].Row, ShouldBeNil)
+		})
+
+		Convey("Panels of collapsed rows should be included in full mode", func() {
+			conf.DashboardMode = "full"
+
+			panels, _, err := newDashboard().modelPanels()
+			So(err, ShouldBeNil)
+			So(panels, ShouldHaveLength, 8)
+
+			for i, host := range []string{"a", "b", "c"} {
+				p := panels[5+i]
+				So(p.Row.Title, ShouldEqual, "Disks of "+host)
+				So(p.Row.Collapsed, ShouldBeTrue)
+				So(p.RepeatVariables, ShouldResemble, url.Values{"var-host": {host}})
+			}
+
+			So(panels[5].ID, ShouldEqual, "panel-6")
+			So(panels[6].ID, ShouldEqual, "panel-6-clone-1")
+			So(panels[6].Row.ID, ShouldEqual, "panel-5-clone-1")
+		})
+
+		Convey("Collapsed rows should be kept as title only", func() {
+			conf.CollapsedRows = "title"
+
+			panels, rows, err := newDashboard().modelPanels()
+			So(err, ShouldBeNil)
+			So(panels, ShouldHaveLength, 5)
+			So(rows, ShouldHaveLength, 4)
+
+			Convey("Panels should be grouped into sections of their rows", func() {
+				sections := (&Data{Panels: panels, Rows: rows}).Sections()
+				So(sections, ShouldHaveLength, 5)
+
+				So(sections[0].Row, ShouldBeNil)
+				So(sections[0].Panels, ShouldHaveLength, 4)
+
+				So(sections[1].Row.Title, ShouldEqual, "Network")
+				So(sections[1].Panels, ShouldHaveLength, 1)
+				So(sections[1].Panels[0].GridPos.Y, ShouldEqual, 0)
+
+				So(sections[4].Row.Title, ShouldEqual, "Disks of c")
+				So(sections[4].Panels, ShouldBeEmpty)
+			})
+		})
+
+		Convey("Selected values of variables should have precedence over saved ones", func() {
+			model.Dashboard.Variables = url.Values{"var-host": {"b"}}
+
+			dash := newDashboard()
+
+			panels, _, err := dash.modelPanels()
+			So(err, ShouldBeNil)
+			So(panels, ShouldHaveLength, 3)
+			So(panels[1].Title, ShouldEqual, "Load of b")
+
+			// Panels render with their own repeat values
+			panelURL := dash.panelPNGURL(Panel{ID: "panel-2-clone-1", RepeatVariables: url.Values{"var-host": {"c"}}}, false)
+			So(panelURL.Query().Get("panelId"), ShouldEqual, "panel-2")
+			So(panelURL.Query().Get("var-host"), ShouldEqual, "c")
+		})
+
+		Convey("Panels below vertical repeats should be pushed down", func() {
+			So(json.Unmarshal([]byte(`{"dashboard": {"uid": "abc", "panels": [
+				{"id": 1, "type": "timeseries", "title": "Load of $host", "repeat": "host", "repeatDirection": "v",
+				 "gridPos": {"h": 4, "w": 12, "x": 6, "y": 0}},
+				{"id": 2, "type": "stat", "title": "Traffic", "gridPos": {"h": 4, "w": 24, "x": 0, "y": 4}}
+			], "templating": {"list": [
+				{"name": "host", "current": {"value": ["a", "b", "c"]}, "options": [{"value": "a"}, {"value": "b"}, {"value": "c"}]}
+			]}}}`), &model), ShouldBeNil)
+
+			model.Dashboard.Variables = url.Values{}
+
+			panels, _, err := newDashboard().modelPanels()
+			So(err, ShouldBeNil)
+			So(panels, ShouldHaveLength, 4)
+
+			for i := range 3 {
+				So(panels[i].GridPos, ShouldResemble, GridPos{X: 6, Y: float64(4 * i), W: 12, H: 4})
+			}
+
+			So(panels[3].GridPos.Y, ShouldEqual, 12)
+		})
+
+		Convey("Dashboard without panels should fail", func() {
+			model.Dashboard.RowOrPanels = nil
+
+			_, _, err := newDashboard().modelPanels()
+			So(err, ShouldEqual, ErrNoPanels)
+		})
+	})
+}
@@ -1,6 +1,10 @@
 package dashboard
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
 
 var (
 	ErrNoPanels                 = errors.New("no panels found in browser data")
@@ -9,4 +13,33 @@ var (
 	ErrDashboardHTTPError       = errors.New("dashboard request does not return 200 OK")
 	ErrEmptyBlobURL             = errors.New("empty blob URL")
 	ErrEmptyCSVData             = errors.New("empty csv data")
+	ErrDashboardNotFound        = errors.New("dashboard not found")
+	ErrPermissionDenied         = errors.New("permission denied")
+	ErrTimeout                  = errors.New("request to grafana timed out")
 )
+
+// httpError returns the error of a failed request to Grafana for the
+// dashboard model that returned status.
+func httpError(status int) error {
+	switch status {
+	case http.StatusNotFound:
+		return ErrDashboardNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrPermissionDenied
+	case http.StatusGatewayTimeout:
+		return ErrTimeout
+	}
+
+	return ErrDashboardHTTPError
+}
+
+// panelHTTPError returns the error of a failed request to render a panel
+// that returned status. Statuses of renderer are not the ones of dashboard
+// so that they are never reported as missing dashboard or permissions.
+func panelHTTPError(status int) error {
+	if status == http.StatusGatewayTimeout {
+		return fmt.Errorf("%w: %w", ErrDashboardHTTPError, ErrTimeout)
+	}
+
+	return ErrDashboardHTTPError
+}
@@ -0,0 +1,113 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/helpers"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// FetchModel fetches dashboard JSON model from Grafana API and sets the
+// template variables of the model to values.
+func FetchModel(ctx context.Context, httpClient *http.Client, appURL, dashUID string,
+	authHeader http.Header, values url.Values,
+) (_ *Model, err error) {
+	dashURL := fmt.Sprintf("%s/api/dashboards/uid/%s", appURL, dashUID)
+
+	// Requests made with the span context are traced by the HTTP client
+	ctx, span := helpers.StartSpan(ctx, "dashboard.FetchModel",
+		attribute.String("dashboard.uid", dashUID),
+		attribute.String("url", dashURL),
+	)
+	defer func() { helpers.EndSpan(span, err) }()
+
+	// Create a new GET request
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dashURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request for %s: %w", dashURL, err)
+	}
+
+	// Forward auth headers
+	for name, values := range authHeader {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+
+	// Make request
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error executing request for %s: %w", dashURL, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body from %s: %w", dashURL, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf(
+			"failed to fetch dashboard model: %w: URL: %s. Status: %s, message: %s",
+			httpError(resp.StatusCode),
+			dashURL,
+			resp.Status,
+			string(body),
+		)
+	}
+
+	var model Model
+
+	// Read data into Model
+	err = json.Unmarshal(body, &model) //nolint:musttag
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body into dashboard model: %w", err)
+	}
+
+	// Add template variables to model
+	model.Dashboard.Variables = values
+
+	return &model, nil
+}
+
+// Panel returns the panel with the given ID from the JSON model, including
+// the panels of collapsed rows. ID can be either the panel ID of JSON model
+// or the one used by Grafana >= 11.3.0 that is prefixed by "panel-".
+func (m *Model) Panel(id string) (Panel, bool) {
+	id = strings.TrimPrefix(id, "panel-")
+
+	for _, rowOrPanel := range m.Dashboard.RowOrPanels {
+		if rowOrPanel.ID == id && rowOrPanel.Type != "row" {
+			return rowOrPanel.Panel, true
+		}
+
+		for _, p := range rowOrPanel.Panels {
+			if p.ID == id {
+				return p, true
+			}
+		}
+	}
+
+	return Panel{}, false
+}
+
+// row returns the row with the given ID from the JSON model. ID can be either
+// the row ID of JSON model or the one used by Grafana >= 11.3.0 that is
+// prefixed by "panel-".
+func (m *Model) row(id string) (RowOrPanel, bool) {
+	id = strings.TrimPrefix(id, "panel-")
+
+	for _, rowOrPanel := range m.Dashboard.RowOrPanels {
+		if rowOrPanel.ID == id && rowOrPanel.Type == "row" {
+			return rowOrPanel, true
+		}
+	}
+
+	return RowOrPanel{}, false
+}
@@ -0,0 +1,98 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/tracing"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestModel(t *testing.T) {
+	Convey("When finding panels in dashboard JSON model", t, func() {
+		var model Model
+
+		err := json.Unmarshal([]byte(`{"dashboard": {"uid": "abc", "panels": [
+			{"id": 1, "type": "timeseries", "title": "CPU", "gridPos": {"h": 8, "w": 12, "x": 0, "y": 0}},
+			{"id": 2, "type": "row", "title": "Network", "collapsed": true, "gridPos": {"h": 1, "w": 24, "x": 0, "y": 8},
+			 "panels": [{"id": 3, "type": "stat", "title": "Traffic", "gridPos": {"h": 4, "w": 6, "x": 0, "y": 9}}]}
+		]}}`), &model)
+		So(err, ShouldBeNil)
+
+		Convey("Panels should be found by their ID", func() {
+			p, ok := model.Panel("1")
+			So(ok, ShouldBeTrue)
+			So(p.Title, ShouldEqual, "CPU")
+			So(p.GridPos.W, ShouldEqual, 12)
+
+			p, ok = model.Panel("panel-1")
+			So(ok, ShouldBeTrue)
+			So(p.Title, ShouldEqual, "CPU")
+		})
+
+		Convey("Panels of collapsed rows should be found", func() {
+			p, ok := model.Panel("3")
+			So(ok, ShouldBeTrue)
+			So(p.Title, ShouldEqual, "Traffic")
+		})
+
+		Convey("Rows and unknown panels should not be found", func() {
+			_, ok := model.Panel("2")
+			So(ok, ShouldBeFalse)
+
+			_, ok = model.Panel("4")
+			So(ok, ShouldBeFalse)
+		})
+	})
+}
+
+func TestFetchModel(t *testing.T) {
+	Convey("When fetching dashboard JSON model with tracing enabled", t, func() {
+		recorder := tracetest.NewSpanRecorder()
+		tracing.InitDefaultTracer(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test"))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+
+		var traceParent string
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			traceParent = r.Header.Get("Traceparent")
+
+			w.Write([]byte(`{"dashboard": {"uid": "abc", "title": "My dashboard"}}`)) //nolint:errcheck
+		}))
+		defer ts.Close()
+
+		httpClient, err := httpclient.New(httpclient.Options{})
+		So(err, ShouldBeNil)
+
+		model, err := FetchModel(t.Context(), httpClient, ts.URL, "abc", nil, url.Values{"var-host": {"server1"}})
+		So(err, ShouldBeNil)
+		So(model.Dashboard.Title, ShouldEqual, "My dashboard")
+
+		Convey("A span should be recorded with dashboard attributes", func() {
+			var span sdktrace.ReadOnlySpan
+
+			for _, s := range recorder.Ended() {
+				if s.Name() == "dashboard.FetchModel" {
+					span = s
+				}
+			}
+
+			So(span, ShouldNotBeNil)
+			So(span.Attributes(), ShouldContain, attribute.String("dashboard.uid", "abc"))
+			So(span.Attributes(), ShouldContain, attribute.String("url", ts.URL+"/api/dashboards/uid/abc"))
+
+			Convey("And its trace should be propagated to Grafana", func() {
+				So(traceParent, ShouldContainSubstring, span.SpanContext().TraceID().String())
+			})
+		})
+	})
+}
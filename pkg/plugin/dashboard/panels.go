@@ -1,14 +1,17 @@
 package dashboard
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"math"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/helpers"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/metrics"
 	"github.com/chromedp/cdproto/runtime"
 	"github.com/chromedp/chromedp"
 )
@@ -50,12 +53,23 @@ var (
 	viewportHeight int64 = 10800
 )
 
-// panels fetches dashboard panels from Grafana chromium browser instance.
-func (d *Dashboard) panels(ctx context.Context) ([]Panel, error) {
+// panels returns dashboard panels and rows. They are fetched from Grafana
+// chromium browser instance unless model discovery is configured. When
+// discovery from model fails, panels are fetched from browser as well.
+func (d *Dashboard) panels(ctx context.Context) ([]Panel, []Row, error) {
+	if d.conf.PanelDiscovery == "model" {
+		panels, rows, err := d.modelPanels()
+		if err == nil {
+			return panels, rows, nil
+		}
+
+		d.logger.Warn("failed to discover panels from dashboard model. Falling back to browser", "error", err)
+	}
+
 	// Fetch dashboard data from browser
 	dashboardData, err := d.panelMetaData(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get dashboard data from browser: %w", err)
+		return nil, nil, fmt.Errorf("failed to get dashboard data from browser: %w", err)
 	}
 
 	d.logger.Debug("dashboard data fetch from browser", "data", dashboardData, "num_panels", len(dashboardData))
@@ -65,14 +79,15 @@ func (d *Dashboard) panels(ctx context.Context) ([]Panel, error) {
 }
 
 // panelMetaData fetches dashboard panels metadata from Grafana chromium browser instance.
-func (d *Dashboard) panelMetaData(_ context.Context) ([]interface{}, error) {
+func (d *Dashboard) panelMetaData(ctx context.Context) (_ []interface{}, err error) {
 	// Get dashboard URL
 	dashURL := fmt.Sprintf("%s/d/%s/_?%s", d.appURL, d.model.Dashboard.UID, d.model.Dashboard.Variables.Encode())
 
 	defer helpers.TimeTrack(time.Now(), "fetch dashboard panels metadata", d.logger, "url", dashURL)
+	defer func(start time.Time) { metrics.Observe(metrics.StagePanelMetadata, start, err) }(time.Now())
 
 	// Create a new tab
-	tab := d.chromeInstance.NewTab(d.logger, d.conf)
+	tab := d.chromeInstance.NewTab(ctx, d.logger, d.conf)
 	tab.WithTimeout(2 * d.conf.HTTPClientOptions.Timeouts.Timeout)
 	defer tab.Close(d.logger)
 
@@ -84,7 +99,7 @@ func (d *Dashboard) panelMetaData(_ context.Context) ([]interface{}, error) {
 		}
 	}
 
-	err := tab.NavigateAndWaitFor(dashURL, headers, "networkIdle")
+	err = tab.NavigateAndWaitFor(dashURL, headers, "networkIdle")
 	if err != nil {
 		return nil, fmt.Errorf("NavigateAndWaitFor: %w", err)
 	}
@@ -96,9 +111,15 @@ func (d *Dashboard) panelMetaData(_ context.Context) ([]interface{}, error) {
 
 	// var buf []byte
 
+	// Panels of collapsed rows are loaded only when rows are expanded
+	mode := "default"
+	if d.conf.CollapsedRowsMode() == "expand" {
+		mode = "full"
+	}
+
 	js := fmt.Sprintf(
 		`waitForQueriesAndVisualizations(version = '%s', mode = '%s', timeout = %d);`,
-		d.appVersion, d.conf.DashboardMode, d.conf.HTTPClientOptions.Timeouts.Timeout.Milliseconds(),
+		d.appVersion, mode, d.conf.HTTPClientOptions.Timeouts.Timeout.Milliseconds(),
 	)
 
 	// JS that will fetch dashboard model
@@ -125,12 +146,14 @@ func (d *Dashboard) panelMetaData(_ context.Context) ([]interface{}, error) {
 	return dashboardData, nil
 }
 
-// panels creates slice of panels from the data fetched from browser's DOM model.
-func (d *Dashboard) createPanels(dashData []interface{}) ([]Panel, error) {
+// createPanels creates slices of panels and rows from the data fetched from
+// browser's DOM model.
+func (d *Dashboard) createPanels(dashData []interface{}) ([]Panel, []Row, error) {
 	var (
 		allErrs    error
 		err        error
 		panels     []Panel
+		rows       []Panel
 		panelReprs []string
 	)
 
@@ -196,8 +219,11 @@ func (d *Dashboard) createPanels(dashData []interface{}) ([]Panel, error) {
 			}
 		}
 
-		// If height comes to 1 or less, it is row panel and ignore it
+		// If height comes to 1 or less, it is row panel. Keep it to group
+		// panels into sections
 		if math.Round(p.GridPos.H/scales["height"]) <= 1 {
+			rows = append(rows, p)
+
 			continue
 		}
 
@@ -244,10 +270,47 @@ func (d *Dashboard) createPanels(dashData []interface{}) ([]Panel, error) {
 	if len(panels) == 0 {
 		allErrs = errors.Join(err, ErrNoPanels)
 
-		return nil, allErrs
+		return nil, nil, allErrs
 	}
 
 	d.logger.Debug("fetched panels", "panels", strings.Join(panelReprs, ";"))
 
-	return panels, allErrs
+	return panels, d.assignRows(panels, rows, yOffset), allErrs
+}
+
+// assignRows sets the row of panels to the closest row above them and returns
+// the rows. Collapsed state of rows is not in DOM model and it is taken from
+// dashboard JSON model.
+func (d *Dashboard) assignRows(panels []Panel, rowPanels []Panel, yOffset float64) []Row {
+	slices.SortStableFunc(rowPanels, func(a, b Panel) int { return cmp.Compare(a.GridPos.Y, b.GridPos.Y) })
+
+	rows := make([]Row, 0, len(rowPanels))
+	rowsY := make([]float64, 0, len(rowPanels))
+
+	for _, p := range rowPanels {
+		row := Row{ID: p.ID, Title: p.Title}
+
+		if rowOrPanel, ok := d.model.row(p.ID); ok {
+			row.Collapsed = rowOrPanel.Collapsed
+		}
+
+		// Collapsed rows are only shown as title in the browser unless they
+		// are expanded. They must be dropped before panels point to rows
+		if row.Collapsed && d.conf.CollapsedRowsMode() == "skip" {
+			continue
+		}
+
+		rows = append(rows, row)
+		rowsY = append(rowsY, math.Round((p.GridPos.Y-yOffset)/scales["height"]))
+	}
+
+	for ipanel := range panels {
+		for irow := range rows {
+			if rowsY[irow] <= panels[ipanel].GridPos.Y {
+				panels[ipanel].Row = &rows[irow]
+			}
+		}
+	}
+
+	return rows
 }
@@ -109,7 +109,9 @@ func TestDashboardFetchWithLocalChrome(t *testing.T) {
 					UID         string       `json:"uid"`
 					Title       string       `json:"title"`
 					Description string       `json:"description"`
+					Tags        []string     `json:"tags"`
 					RowOrPanels []RowOrPanel `json:"panels"`
+					Templating  Templating   `json:"templating"`
 					Panels      []Panel
 					Variables   url.Values
 				}{
@@ -118,6 +120,7 @@ func TestDashboardFetchWithLocalChrome(t *testing.T) {
 				http.Header{
 					backend.CookiesHeaderName: []string{"cookie"},
 				},
+				nil,
 			)
 
 			Convey("New dashboard should receive no errors", func() {
@@ -213,7 +216,9 @@ func TestDashboardFetchWithRemoteChrome(t *testing.T) {
 					UID         string       `json:"uid"`
 					Title       string       `json:"title"`
 					Description string       `json:"description"`
+					Tags        []string     `json:"tags"`
 					RowOrPanels []RowOrPanel `json:"panels"`
+					Templating  Templating   `json:"templating"`
 					Panels      []Panel
 					Variables   url.Values
 				}{
@@ -222,6 +227,7 @@ func TestDashboardFetchWithRemoteChrome(t *testing.T) {
 				http.Header{
 					backend.CookiesHeaderName: []string{"cookie"},
 				},
+				nil,
 			)
 
 			Convey("New dashboard should receive no errors", func() {
@@ -250,7 +256,7 @@ func TestDashboardCreatePanels(t *testing.T) {
 	Convey("When creating panels for Dashboard", t, func() {
 		dash, err := New(
 			log.NewNullLogger(),
-			nil,
+			&config.Config{},
 			nil,
 			nil,
 			"http://localhost:3000",
@@ -260,13 +266,16 @@ func TestDashboardCreatePanels(t *testing.T) {
 				UID         string       `json:"uid"`
 				Title       string       `json:"title"`
 				Description string       `json:"description"`
+				Tags        []string     `json:"tags"`
 				RowOrPanels []RowOrPanel `json:"panels"`
+				Templating  Templating   `json:"templating"`
 				Panels      []Panel
 				Variables   url.Values
 			}{
 				UID: "randomUID",
 			}},
 			nil,
+			nil,
 		)
 
 		Convey("New dashboard should receive no errors", func() {
@@ -282,7 +291,7 @@ func TestDashboardCreatePanels(t *testing.T) {
 			So(err, ShouldBeNil)
 		})
 
-		panels, err := dash.createPanels(dashData)
+		panels, _, err := dash.createPanels(dashData)
 
 		Convey("It should receive no errors", func() {
 			So(err, ShouldBeNil)
@@ -293,5 +302,41 @@ func TestDashboardCreatePanels(t *testing.T) {
 			So(panels[1].ID, ShouldEqual, "26")
 			So(panels[2].ID, ShouldEqual, "27")
 		})
+
+		Convey("It should group panels below rows", func() {
+			rowDataString := `[{"width":940,"height":258,"x":0,"y":0,"id":"12"},{"width":1880,"height":32,"x":0,"y":258,"id":"30","title":"Network"},{"width":940,"height":258,"x":0,"y":290,"id":"31"}]`
+
+			var rowData []interface{}
+			So(json.Unmarshal([]byte(rowDataString), &rowData), ShouldBeNil)
+
+			panels, rows, err := dash.createPanels(rowData)
+			So(err, ShouldBeNil)
+			So(panels, ShouldHaveLength, 2)
+			So(rows, ShouldResemble, []Row{{ID: "30", Title: "Network"}})
+			So(panels[0].Row, ShouldBeNil)
+			So(panels[1].Row.ID, ShouldEqual, "30")
+		})
+
+		Convey("It should skip collapsed rows followed by expanded rows", func() {
+			dash.model.Dashboard.RowOrPanels = []RowOrPanel{
+				{Panel: Panel{ID: "29", Type: "row", Title: "System"}, Collapsed: true},
+				{Panel: Panel{ID: "30", Type: "row", Title: "Network"}},
+			}
+
+			rowDataString := `[{"width":940,"height":258,"x":0,"y":0,"id":"12"},{"width":1880,"height":32,"x":0,"y":258,"id":"29","title":"System"},{"width":1880,"height":32,"x":0,"y":290,"id":"30","title":"Network"},{"width":940,"height":258,"x":0,"y":322,"id":"31"}]`
+
+			var rowData []interface{}
+			So(json.Unmarshal([]byte(rowDataString), &rowData), ShouldBeNil)
+
+			panels, rows, err := dash.createPanels(rowData)
+			So(err, ShouldBeNil)
+			So(rows, ShouldResemble, []Row{{ID: "30", Title: "Network"}})
+			So(panels[1].ID, ShouldEqual, "31")
+			So(panels[1].Row, ShouldResemble, &Row{ID: "30", Title: "Network"})
+
+			sections := (&Data{Panels: panels, Rows: rows}).Sections()
+			So(sections, ShouldHaveLength, 2)
+			So(sections[1].Panels, ShouldHaveLength, 1)
+		})
 	})
 }
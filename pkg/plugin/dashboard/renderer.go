@@ -6,21 +6,45 @@ import (
 	"encoding/base64"
 	"fmt"
 	"io"
-	"maps"
 	"net/http"
 	"net/url"
 	"strconv"
 	"time"
 
 	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/helpers"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/metrics"
 	"github.com/chromedp/cdproto/runtime"
 	"github.com/chromedp/chromedp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var getPanelRetrySleepTime = time.Duration(10) * time.Second
 
-// PanelPNG returns encoded PNG image of a given panel.
+// PanelPNG returns encoded PNG image of a given panel. Images are served
+// from panel cache when available.
 func (d *Dashboard) PanelPNG(ctx context.Context, p Panel) (PanelImage, error) {
+	return cached(d, panelPNGKind, p, func() (PanelImage, error) {
+		return d.panelPNG(ctx, p)
+	})
+}
+
+// panelPNG renders encoded PNG image of a given panel.
+func (d *Dashboard) panelPNG(ctx context.Context, p Panel) (_ PanelImage, err error) {
+	defer func(start time.Time) { metrics.Observe(metrics.StagePanelPNG, start, err) }(time.Now())
+
+	renderer := "grafana-image-renderer"
+	if d.conf.NativeRendering {
+		renderer = "native"
+	}
+
+	ctx, span := helpers.StartSpan(ctx, "dashboard.PanelPNG",
+		attribute.String("dashboard.uid", d.model.Dashboard.UID),
+		attribute.String("panel.id", p.ID),
+		attribute.String("renderer", renderer),
+	)
+	defer func() { helpers.EndSpan(span, err) }()
+
 	if d.conf.NativeRendering {
 		return d.panelPNGNativeRenderer(ctx, p)
 	}
@@ -29,14 +53,15 @@ func (d *Dashboard) PanelPNG(ctx context.Context, p Panel) (PanelImage, error) {
 }
 
 // panelPNGNativeRenderer returns panel PNG data by capturing screenshot of panel in browser.
-func (d *Dashboard) panelPNGNativeRenderer(_ context.Context, p Panel) (PanelImage, error) {
+func (d *Dashboard) panelPNGNativeRenderer(ctx context.Context, p Panel) (PanelImage, error) {
 	// Get panel URL
 	panelURL := d.panelPNGURL(p, false)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("url", panelURL.String()))
 
 	defer helpers.TimeTrack(time.Now(), "fetch panel PNG", d.logger, "panel_id", p.ID, "renderer", "native", "url", panelURL.String())
 
 	// Create a new tab
-	tab := d.chromeInstance.NewTab(d.logger, d.conf)
+	tab := d.chromeInstance.NewTab(ctx, d.logger, d.conf)
 	tab.WithTimeout(2 * d.conf.HTTPClientOptions.Timeouts.Timeout)
 	defer tab.Close(d.logger)
 
@@ -103,6 +128,7 @@ func (d *Dashboard) panelPNGNativeRenderer(_ context.Context, p Panel) (PanelIma
 func (d *Dashboard) panelPNGImageRenderer(ctx context.Context, p Panel) (PanelImage, error) {
 	// Get panel render URL
 	panelURL := d.panelPNGURL(p, true)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("url", panelURL.String()))
 
 	defer helpers.TimeTrack(time.Now(), "fetch panel PNG", d.logger, "panel_id", p.ID, "renderer", "grafana-image-renderer", "url", panelURL.String())
 
@@ -131,7 +157,12 @@ func (d *Dashboard) panelPNGImageRenderer(ctx context.Context, p Panel) (PanelIm
 		resp.Body.Close()
 
 		delay := getPanelRetrySleepTime * time.Duration(retries)
-		time.Sleep(delay)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return PanelImage{}, fmt.Errorf("error waiting to retry request for %s: %w", panelURL, ctx.Err())
+		}
 
 		resp, err = d.httpClient.Do(req)
 		if err != nil {
@@ -148,7 +179,7 @@ func (d *Dashboard) panelPNGImageRenderer(ctx context.Context, p Panel) (PanelIm
 	if resp.StatusCode != http.StatusOK {
 		return PanelImage{}, fmt.Errorf(
 			"%w: URL: %s. Status: %s, message: %s",
-			ErrDashboardHTTPError,
+			panelHTTPError(resp.StatusCode),
 			panelURL,
 			resp.Status,
 			string(body),
@@ -172,9 +203,9 @@ func (d *Dashboard) panelPNGImageRenderer(ctx context.Context, p Panel) (PanelIm
 
 // panelPNGURL returns the URL to fetch panel PNG.
 func (d *Dashboard) panelPNGURL(p Panel, render bool) *url.URL {
-	values := maps.Clone(d.model.Dashboard.Variables)
+	values := d.panelVariables(p)
 	values.Add("theme", d.conf.Theme)
-	values.Add("panelId", p.ID)
+	values.Add("panelId", p.renderID())
 
 	if d.conf.TimeZone != "" && values.Get("timezone") == "" {
 		values.Add("timezone", d.conf.TimeZone)
@@ -230,5 +261,14 @@ func (d *Dashboard) panelDims(p Panel) (int64, int64) {
 		height = 500
 	}
 
+	// Explicitly requested size has precedence over layout
+	if d.conf.PanelWidth > 0 {
+		width = int64(d.conf.PanelWidth)
+	}
+
+	if d.conf.PanelHeight > 0 {
+		height = int64(d.conf.PanelHeight)
+	}
+
 	return width, height
 }
@@ -1,6 +1,8 @@
 package dashboard
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -52,7 +54,9 @@ func TestFetchPanelPNG(t *testing.T) {
 				UID         string       `json:"uid"`
 				Title       string       `json:"title"`
 				Description string       `json:"description"`
+				Tags        []string     `json:"tags"`
 				RowOrPanels []RowOrPanel `json:"panels"`
+				Templating  Templating   `json:"templating"`
 				Panels      []Panel
 				Variables   url.Values
 			}{
@@ -62,6 +66,7 @@ func TestFetchPanelPNG(t *testing.T) {
 			http.Header{
 				backend.OAuthIdentityTokenHeaderName: []string{"Bearer token"},
 			},
+			nil,
 		)
 
 		Convey("New dashboard should receive no errors", func() {
@@ -116,7 +121,9 @@ func TestFetchPanelPNG(t *testing.T) {
 				UID         string       `json:"uid"`
 				Title       string       `json:"title"`
 				Description string       `json:"description"`
+				Tags        []string     `json:"tags"`
 				RowOrPanels []RowOrPanel `json:"panels"`
+				Templating  Templating   `json:"templating"`
 				Panels      []Panel
 				Variables   url.Values
 			}{
@@ -126,6 +133,7 @@ func TestFetchPanelPNG(t *testing.T) {
 			http.Header{
 				backend.OAuthIdentityTokenHeaderName: []string{"token"},
 			},
+			nil,
 		)
 
 		Convey("New dashboard should receive no errors using grid layout", func() {
@@ -145,6 +153,53 @@ func TestFetchPanelPNG(t *testing.T) {
 	})
 }
 
+func TestFetchPanelPNGErrors(t *testing.T) {
+	Convey("When the image renderer fails to render a panel", t, func() {
+		status := http.StatusNotFound
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "not found", status)
+		}))
+		defer ts.Close()
+
+		dash, err := New(
+			log.NewNullLogger(),
+			&config.Config{Layout: "simple", DashboardMode: "default"},
+			http.DefaultClient,
+			&chrome.LocalInstance{},
+			ts.URL,
+			"v11.1.0",
+			&Model{},
+			http.Header{},
+			nil,
+		)
+		So(err, ShouldBeNil)
+
+		Convey("Not found should not be reported as a missing dashboard", func() {
+			_, err = dash.PanelPNG(t.Context(), Panel{ID: "44", Type: "graph"})
+
+			So(err, ShouldWrap, ErrDashboardHTTPError)
+			So(errors.Is(err, ErrDashboardNotFound), ShouldBeFalse)
+		})
+
+		Convey("Forbidden should not be reported as denied permission", func() {
+			status = http.StatusForbidden
+			_, err = dash.PanelPNG(t.Context(), Panel{ID: "44", Type: "graph"})
+
+			So(err, ShouldWrap, ErrDashboardHTTPError)
+			So(errors.Is(err, ErrPermissionDenied), ShouldBeFalse)
+		})
+
+		Convey("Gateway timeout should be reported as a timeout", func() {
+			status = http.StatusGatewayTimeout
+			_, err = dash.PanelPNG(t.Context(), Panel{ID: "44", Type: "graph"})
+
+			So(err, ShouldWrap, ErrDashboardHTTPError)
+			So(err, ShouldWrap, ErrTimeout)
+		})
+	})
+}
+
 func TestCustomQueryParamsInRenderURL(t *testing.T) {
 	Convey("When fetching a panel PNG with custom query parameters via image renderer", t, func() {
 		requestURI := ""
@@ -180,7 +235,9 @@ func TestCustomQueryParamsInRenderURL(t *testing.T) {
 				UID         string       `json:"uid"`
 				Title       string       `json:"title"`
 				Description string       `json:"description"`
+				Tags        []string     `json:"tags"`
 				RowOrPanels []RowOrPanel `json:"panels"`
+				Templating  Templating   `json:"templating"`
 				Panels      []Panel
 				Variables   url.Values
 			}{
@@ -188,6 +245,7 @@ func TestCustomQueryParamsInRenderURL(t *testing.T) {
 				Variables: variables,
 			}},
 			http.Header{},
+			nil,
 		)
 
 		Convey("New dashboard should receive no errors", func() {
@@ -217,7 +275,7 @@ func TestCustomQueryParamsInRenderURL(t *testing.T) {
 // Mock Chrome instance for testing.
 type mockChromeInstance struct{}
 
-func (m *mockChromeInstance) NewTab(logger log.Logger, conf *config.Config) *chrome.Tab {
+func (m *mockChromeInstance) NewTab(ctx context.Context, logger log.Logger, conf *config.Config) *chrome.Tab {
 	return &chrome.Tab{} // We'll override the methods we need
 }
 
@@ -263,7 +321,9 @@ func TestCustomQueryParamsInChromeURL(t *testing.T) {
 				UID         string       `json:"uid"`
 				Title       string       `json:"title"`
 				Description string       `json:"description"`
+				Tags        []string     `json:"tags"`
 				RowOrPanels []RowOrPanel `json:"panels"`
+				Templating  Templating   `json:"templating"`
 				Panels      []Panel
 				Variables   url.Values
 			}{
@@ -271,6 +331,7 @@ func TestCustomQueryParamsInChromeURL(t *testing.T) {
 				Variables: variables,
 			}},
 			http.Header{},
+			nil,
 		)
 
 		Convey("New dashboard should receive no errors", func() {
@@ -342,7 +403,9 @@ func TestCustomQueryParamsIntegrationChromeNavigation(t *testing.T) {
 				UID         string       `json:"uid"`
 				Title       string       `json:"title"`
 				Description string       `json:"description"`
+				Tags        []string     `json:"tags"`
 				RowOrPanels []RowOrPanel `json:"panels"`
+				Templating  Templating   `json:"templating"`
 				Panels      []Panel
 				Variables   url.Values
 			}{
@@ -350,6 +413,7 @@ func TestCustomQueryParamsIntegrationChromeNavigation(t *testing.T) {
 				Variables: variables,
 			}},
 			http.Header{},
+			nil,
 		)
 
 		So(err, ShouldBeNil)
@@ -1,6 +1,7 @@
 package dashboard
 
 import (
+	"fmt"
 	"regexp"
 	"strconv"
 	"time"
@@ -129,16 +130,37 @@ func NewTimeRange(from, to string) TimeRange {
 
 // Formats Grafana 'From' time spec into absolute printable time.
 func (tr TimeRange) FromFormatted(loc *time.Location, layout string) string {
-	n := newNow()
-
-	return n.parseFrom(tr.From).In(loc).Format(layout)
+	return tr.FromTime().In(loc).Format(layout)
 }
 
 // Formats Grafana 'To' time spec into absolute printable time.
 func (tr TimeRange) ToFormatted(loc *time.Location, layout string) string {
-	n := newNow()
+	return tr.ToTime().In(loc).Format(layout)
+}
+
+// Validate returns an error when either time spec of tr is not recognised.
+func (tr TimeRange) Validate() (err error) {
+	// Time parser panics on unrecognised formats
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid time range: %v", r)
+		}
+	}()
+
+	tr.FromTime()
+	tr.ToTime()
+
+	return nil
+}
+
+// FromTime returns Grafana 'From' time spec as absolute time.
+func (tr TimeRange) FromTime() time.Time {
+	return newNow().parseFrom(tr.From)
+}
 
-	return n.parseTo(tr.To).In(loc).Format(layout)
+// ToTime returns Grafana 'To' time spec as absolute time.
+func (tr TimeRange) ToTime() time.Time {
+	return newNow().parseTo(tr.To)
 }
 
 // Make current time custom struct.
@@ -3,10 +3,12 @@ package dashboard
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
 	"net/url"
 	"strconv"
 
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/cache"
 	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/chrome"
 	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
@@ -23,6 +25,7 @@ type Dashboard struct {
 	jsContent      string
 	model          *Model
 	authHeader     http.Header
+	cache          cache.Cache
 }
 
 // RowOrPanel represents a container for Panels.
@@ -32,30 +35,145 @@ type RowOrPanel struct {
 	Panels    []Panel `json:"panels"`
 }
 
+// UnmarshalJSON decodes the row or panel. It is needed as the method of
+// embedded Panel would otherwise ignore the fields of rows.
+func (r *RowOrPanel) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &r.Panel); err != nil {
+		return err
+	}
+
+	var row struct {
+		Collapsed bool    `json:"collapsed"`
+		Panels    []Panel `json:"panels"`
+	}
+
+	if err := json.Unmarshal(b, &row); err != nil {
+		return err
+	}
+
+	r.Collapsed = row.Collapsed
+	r.Panels = row.Panels
+
+	return nil
+}
+
 // Model represents a Grafana JSON dashboard.
 type Model struct {
 	Meta struct {
 		FolderUID   string `json:"folderUid"`
 		FolderTitle string `json:"folderTitle"`
 		FolderURL   string `json:"folderUrl"`
+		Version     int    `json:"version"`
+		CreatedBy   string `json:"createdBy"`
 	} `json:"meta"`
 	Dashboard struct {
 		ID          int          `json:"id"`
 		UID         string       `json:"uid"`
 		Title       string       `json:"title"`
 		Description string       `json:"description"`
+		Tags        []string     `json:"tags"`
 		RowOrPanels []RowOrPanel `json:"panels"`
+		Templating  Templating   `json:"templating"`
 		Panels      []Panel
 		Variables   url.Values
 	} `json:"dashboard"`
 }
 
+// Templating represents the template variables of a Grafana JSON dashboard.
+type Templating struct {
+	List []TemplateVariable `json:"list"`
+}
+
+// TemplateVariable represents a template variable of a Grafana JSON dashboard.
+// Values are either a string or a list of strings.
+type TemplateVariable struct {
+	Name    string `json:"name"`
+	Current struct {
+		Value any `json:"value"`
+	} `json:"current"`
+	Options []struct {
+		Value any `json:"value"`
+	} `json:"options"`
+}
+
+// Row represents a row of panels in a Grafana dashboard.
+type Row struct {
+	ID        string
+	Title     string
+	Collapsed bool
+}
+
 // Data represents dashboard data that will be included in the report.
 type Data struct {
+	UID       string
 	Title     string
 	TimeRange TimeRange
 	Variables string
 	Panels    []Panel
+
+	// Description, tags and creator of dashboard
+	Description string
+	Tags        []string
+	CreatedBy   string
+
+	// Rows of dashboard in the order of the report. Panels refer to their
+	// row, if any
+	Rows []Row
+
+	// Values of template variables by their name
+	TemplateVariables map[string][]string
+}
+
+// Section represents a row of dashboard and its panels in the report. Panels
+// that are not in a row are in a section without row.
+type Section struct {
+	Row    *Row
+	Panels []Panel
+}
+
+// Sections returns the panels of dashboard grouped by their rows. Positions
+// of panels are relative to the top of their section. Rows shown as title
+// only have a section without panels.
+func (d *Data) Sections() []Section {
+	sections := make([]Section, 0, len(d.Rows)+1)
+
+	// Panels that are not in a row come first
+	var panels []Panel
+
+	for _, p := range d.Panels {
+		if p.Row == nil {
+			panels = append(panels, p)
+		}
+	}
+
+	if len(panels) > 0 {
+		sections = append(sections, Section{Panels: panels})
+	}
+
+	for i := range d.Rows {
+		section := Section{Row: &d.Rows[i]}
+
+		for _, p := range d.Panels {
+			if p.Row != nil && p.Row.ID == d.Rows[i].ID {
+				section.Panels = append(section.Panels, p)
+			}
+		}
+
+		sections = append(sections, section)
+	}
+
+	for _, section := range sections {
+		top := math.MaxFloat64
+		for _, p := range section.Panels {
+			top = min(top, p.GridPos.Y)
+		}
+
+		for i := range section.Panels {
+			section.Panels[i].GridPos.Y -= top
+		}
+	}
+
+	return sections
 }
 
 type PanelType int
@@ -112,6 +230,25 @@ type Panel struct {
 	GridPos      GridPos `json:"gridPos"`
 	EncodedImage PanelImage
 	CSVData      CSVData
+
+	// Repeat options of panel in JSON model
+	Repeat          string `json:"repeat"`
+	RepeatDirection string `json:"repeatDirection"`
+	MaxPerRow       int    `json:"maxPerRow"`
+
+	// Row containing the panel, if any. It is only known when panels are
+	// discovered from JSON model
+	Row *Row `json:"-"`
+
+	// Values of the repeat variables of a repeated panel or of the panels
+	// of a repeated row. They override dashboard variables when rendering
+	// the panel
+	RepeatVariables url.Values `json:"-"`
+
+	// Reasons PNG and CSV data of panel could not be fetched. They are only
+	// set in partial reports
+	PNGError string `json:"-"`
+	CSVError string `json:"-"`
 }
 
 func (p *Panel) String() string {
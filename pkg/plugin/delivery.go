@@ -0,0 +1,95 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/delivery"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/report"
+)
+
+// emailReport sends a generated PDF report to recipients. Subject and body
+// of the email are rendered using the data of the report.
+func (app *App) emailReport(ctx context.Context, conf *config.Config, rep *report.Report,
+	filename string, pdf []byte, recipients []string,
+) error {
+	mailer, err := delivery.NewMailer(conf)
+	if err != nil {
+		return err
+	}
+
+	subject, err := rep.RenderText(conf.EmailSubjectTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to render email subject: %w", err)
+	}
+
+	body, err := rep.RenderText(conf.EmailBodyTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to render email body: %w", err)
+	}
+
+	return mailer.Send(ctx, delivery.Email{
+		To:      recipients,
+		Subject: subject,
+		Body:    body,
+		Attachments: []delivery.Attachment{
+			{Filename: filename, ContentType: "application/pdf", Data: pdf},
+		},
+	})
+}
+
+// resourceURL returns the URL of a resource of the plugin.
+func resourceURL(grafanaAppURL, path string) string {
+	return strings.TrimSuffix(grafanaAppURL, "/") + "/api/plugins/" + Name + "/resources/" + path
+}
+
+// failedReportEvent returns a webhook event about the report of a dashboard
+// that failed before it could be generated. Model is nil when the failure
+// happened before fetching it.
+func failedReportEvent(dashUID string, model *dashboard.Model, err error) delivery.Event {
+	event := delivery.Event{
+		Event:        delivery.ReportFailed,
+		DashboardUID: dashUID,
+		Error:        err.Error(),
+	}
+
+	if model != nil {
+		timeRange := dashboard.NewTimeRange(model.Dashboard.Variables.Get("from"), model.Dashboard.Variables.Get("to"))
+
+		event.Title = model.Dashboard.Title
+		event.From = timeRange.FromTime()
+		event.To = timeRange.ToTime()
+	}
+
+	return event
+}
+
+// reportEvent returns a webhook event about the report of a dashboard that
+// has been generated since start. err is the error of report generation, if any.
+func reportEvent(model *dashboard.Model, rep *report.Report, start time.Time, err error) delivery.Event {
+	timeRange := dashboard.NewTimeRange(model.Dashboard.Variables.Get("from"), model.Dashboard.Variables.Get("to"))
+
+	event := delivery.Event{
+		Event:        delivery.ReportSucceeded,
+		DashboardUID: model.Dashboard.UID,
+		Title:        model.Dashboard.Title,
+		From:         timeRange.FromTime(),
+		To:           timeRange.ToTime(),
+		Duration:     time.Since(start).Seconds(),
+		Size:         rep.Size(),
+	}
+
+	if err != nil {
+		event.Event = delivery.ReportFailed
+		event.Error = err.Error()
+		event.Size = 0
+	} else if sinkErr := rep.SinkError(); sinkErr != nil {
+		event.SinkError = sinkErr.Error()
+	}
+
+	return event
+}
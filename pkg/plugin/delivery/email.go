@@ -0,0 +1,268 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
+)
+
+// Timeout of the whole SMTP session when context does not have a deadline.
+const smtpTimeout = 2 * time.Minute
+
+// Attachment is a file attached to an email.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Email is an email with attachments.
+type Email struct {
+	To          []string
+	Subject     string
+	Body        string
+	Attachments []Attachment
+}
+
+// Mailer sends emails using a SMTP server.
+type Mailer struct {
+	addr     string
+	host     string
+	tlsMode  string
+	user     string
+	password string
+	from     string
+
+	tlsConfig *tls.Config
+}
+
+// NewMailer returns a new Mailer using SMTP settings of conf. It returns an
+// error when SMTP is not configured.
+func NewMailer(conf *config.Config) (*Mailer, error) {
+	if conf.SMTPHost == "" {
+		return nil, errors.New("smtp host is not configured")
+	}
+
+	return &Mailer{
+		addr:     net.JoinHostPort(conf.SMTPHost, strconv.Itoa(conf.SMTPPort)),
+		host:     conf.SMTPHost,
+		tlsMode:  conf.SMTPTLS,
+		user:     conf.SMTPUser,
+		password: conf.SMTPPassword,
+		from:     conf.SMTPFrom,
+		tlsConfig: &tls.Config{
+			ServerName:         conf.SMTPHost,
+			InsecureSkipVerify: conf.SMTPSkipTLSCheck, //nolint:gosec
+			MinVersion:         tls.VersionTLS12,
+		},
+	}, nil
+}
+
+// Send sends the email.
+func (m *Mailer) Send(ctx context.Context, email Email) error {
+	if len(email.To) == 0 {
+		return errors.New("email has no recipients")
+	}
+
+	from, err := mail.ParseAddress(m.from)
+	if err != nil {
+		return fmt.Errorf("invalid from address: %w", err)
+	}
+
+	to := make([]string, len(email.To))
+
+	for i, recipient := range email.To {
+		addr, err := mail.ParseAddress(recipient)
+		if err != nil {
+			return fmt.Errorf("invalid recipient %q: %w", recipient, err)
+		}
+
+		to[i] = addr.Address
+	}
+
+	msg, err := m.message(from, email)
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
+
+	conn, err := m.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	defer conn.Close()
+
+	// Abort the SMTP session when context is cancelled
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		return fmt.Errorf("failed to create smtp client: %w", err)
+	}
+	defer c.Close()
+
+	if m.tlsMode == "starttls" {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("smtp server does not support STARTTLS")
+		}
+
+		if err := c.StartTLS(m.tlsConfig); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+
+	if m.user != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.user, m.password, m.host)); err != nil {
+			return fmt.Errorf("smtp authentication failed: %w", err)
+		}
+	}
+
+	if err := c.Mail(from.Address); err != nil {
+		return fmt.Errorf("smtp MAIL command failed: %w", err)
+	}
+
+	for _, addr := range to {
+		if err := c.Rcpt(addr); err != nil {
+			return fmt.Errorf("smtp RCPT command failed for %s: %w", addr, err)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA command failed: %w", err)
+	}
+
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return c.Quit()
+}
+
+// dial connects to SMTP server using implicit TLS when configured.
+func (m *Mailer) dial(ctx context.Context) (net.Conn, error) {
+	if m.tlsMode == "tls" {
+		dialer := &tls.Dialer{Config: m.tlsConfig}
+
+		return dialer.DialContext(ctx, "tcp", m.addr)
+	}
+
+	var dialer net.Dialer
+
+	return dialer.DialContext(ctx, "tcp", m.addr)
+}
+
+// message returns the MIME message of the email.
+func (m *Mailer) message(from *mail.Address, email Email) ([]byte, error) {
+	var buf bytes.Buffer
+
+	mw := multipart.NewWriter(&buf)
+
+	domain := "localhost"
+	if i := strings.LastIndex(from.Address, "@"); i >= 0 {
+		domain = from.Address[i+1:]
+	}
+
+	headers := []struct{ key, value string }{
+		{"From", from.String()},
+		{"To", strings.Join(email.To, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", email.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", fmt.Sprintf("<%s@%s>", strings.ToLower(rand.Text()), domain)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/mixed; boundary=" + mw.Boundary()},
+	}
+
+	for _, h := range headers {
+		fmt.Fprintf(&buf, "%s: %s\r\n", h.key, h.value)
+	}
+
+	buf.WriteString("\r\n")
+
+	// Body
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	qw := quotedprintable.NewWriter(part)
+	if _, err := qw.Write([]byte(email.Body)); err != nil {
+		return nil, err
+	}
+
+	if err := qw.Close(); err != nil {
+		return nil, err
+	}
+
+	// Attachments
+	for _, a := range email.Attachments {
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {a.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename})},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if err := writeBase64(part, a.Data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeBase64 writes base64 encoded data in lines of 76 characters as required by RFC 2045.
+func writeBase64(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+
+	for len(encoded) > 0 {
+		n := min(76, len(encoded))
+
+		if _, err := io.WriteString(w, encoded[:n]+"\r\n"); err != nil {
+			return err
+		}
+
+		encoded = encoded[n:]
+	}
+
+	return nil
+}
@@ -0,0 +1,260 @@
+package delivery
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// received is what fake SMTP server has received.
+type received struct {
+	auth string
+	from string
+	to   []string
+	tls  bool
+	data []byte
+}
+
+// fakeSMTPServer is a minimal SMTP server that accepts a single email.
+type fakeSMTPServer struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	startTLS  bool
+	received  chan received
+}
+
+// newFakeSMTPServer starts a fake SMTP server. When implicitTLS is true,
+// connections are TLS from the start and when startTLS is true, STARTTLS
+// extension is advertised.
+func newFakeSMTPServer(t *testing.T, tlsConfig *tls.Config, implicitTLS, startTLS bool) *fakeSMTPServer {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if implicitTLS {
+		l = tls.NewListener(l, tlsConfig)
+	}
+
+	s := &fakeSMTPServer{
+		listener:  l,
+		tlsConfig: tlsConfig,
+		startTLS:  startTLS,
+		received:  make(chan received, 1),
+	}
+
+	go s.serve(implicitTLS)
+
+	t.Cleanup(func() { l.Close() })
+
+	return s
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTPServer) serve(implicitTLS bool) {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	var r received
+
+	r.tls = implicitTLS
+	tp := textproto.NewConn(conn)
+
+	tp.PrintfLine("220 localhost ESMTP") //nolint:errcheck
+
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+
+		cmd, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(cmd) {
+		case "EHLO", "HELO":
+			if s.startTLS && !r.tls {
+				tp.PrintfLine("250-localhost\r\n250-STARTTLS\r\n250 AUTH PLAIN") //nolint:errcheck
+			} else {
+				tp.PrintfLine("250-localhost\r\n250 AUTH PLAIN") //nolint:errcheck
+			}
+		case "STARTTLS":
+			tp.PrintfLine("220 ready") //nolint:errcheck
+
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+
+			conn = tlsConn
+			tp = textproto.NewConn(conn)
+			r.tls = true
+		case "AUTH":
+			r.auth = arg
+			tp.PrintfLine("235 authenticated") //nolint:errcheck
+		case "MAIL":
+			r.from = arg
+			tp.PrintfLine("250 ok") //nolint:errcheck
+		case "RCPT":
+			r.to = append(r.to, arg)
+			tp.PrintfLine("250 ok") //nolint:errcheck
+		case "DATA":
+			tp.PrintfLine("354 go ahead") //nolint:errcheck
+
+			if r.data, err = tp.ReadDotBytes(); err != nil {
+				return
+			}
+
+			tp.PrintfLine("250 queued") //nolint:errcheck
+		case "QUIT":
+			tp.PrintfLine("221 bye") //nolint:errcheck
+
+			s.received <- r
+
+			return
+		default:
+			tp.PrintfLine("502 not implemented") //nolint:errcheck
+		}
+	}
+}
+
+// testCerts returns server TLS config and client root CAs using the
+// certificate of a httptest server.
+func testCerts(t *testing.T) (*tls.Config, *x509.CertPool) {
+	t.Helper()
+
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	t.Cleanup(srv.Close)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+
+	return &tls.Config{Certificates: srv.TLS.Certificates, MinVersion: tls.VersionTLS12}, roots
+}
+
+func TestMailer(t *testing.T) {
+	Convey("When creating a mailer without SMTP host", t, func() {
+		_, err := NewMailer(&config.Config{})
+
+		Convey("An error should be returned", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("When sending emails", t, func() {
+		serverTLS, roots := testCerts(t)
+
+		email := Email{
+			To:      []string{"Ops <ops@example.com>", "dev@example.com"},
+			Subject: "Rapport hebdomadaire",
+			Body:    "Please find attached the report.",
+			Attachments: []Attachment{
+				{Filename: "My dashboard.pdf", ContentType: "application/pdf", Data: []byte("%PDF-1.4 fake")},
+			},
+		}
+
+		for _, mode := range []string{"none", "starttls", "tls"} {
+			Convey("Email should be delivered with TLS mode "+mode, func() {
+				server := newFakeSMTPServer(t, serverTLS, mode == "tls", mode == "starttls")
+
+				mailer, err := NewMailer(&config.Config{
+					SMTPHost:     "127.0.0.1",
+					SMTPPort:     server.port(),
+					SMTPTLS:      mode,
+					SMTPUser:     "reporter",
+					SMTPPassword: "secret",
+					SMTPFrom:     "Grafana Reporter <reporter@example.com>",
+				})
+				So(err, ShouldBeNil)
+
+				mailer.tlsConfig.RootCAs = roots
+
+				So(mailer.Send(t.Context(), email), ShouldBeNil)
+
+				r := <-server.received
+				So(r.tls, ShouldEqual, mode != "none")
+				So(r.from, ShouldEqual, "FROM:<reporter@example.com>")
+				So(r.to, ShouldResemble, []string{"TO:<ops@example.com>", "TO:<dev@example.com>"})
+
+				auth, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(r.auth, "PLAIN "))
+				So(err, ShouldBeNil)
+				So(string(auth), ShouldEqual, "\x00reporter\x00secret")
+
+				msg, err := mail.ReadMessage(strings.NewReader(string(r.data)))
+				So(err, ShouldBeNil)
+
+				subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+				So(err, ShouldBeNil)
+				So(subject, ShouldEqual, "Rapport hebdomadaire")
+
+				_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+				So(err, ShouldBeNil)
+
+				mr := multipart.NewReader(msg.Body, params["boundary"])
+
+				body, err := mr.NextPart()
+				So(err, ShouldBeNil)
+
+				content, err := io.ReadAll(body)
+				So(err, ShouldBeNil)
+				So(string(content), ShouldEqual, email.Body)
+
+				attachment, err := mr.NextPart()
+				So(err, ShouldBeNil)
+				So(attachment.FileName(), ShouldEqual, "My dashboard.pdf")
+
+				content, err = io.ReadAll(base64.NewDecoder(base64.StdEncoding, attachment))
+				So(err, ShouldBeNil)
+				So(string(content), ShouldEqual, "%PDF-1.4 fake")
+			})
+		}
+
+		Convey("STARTTLS should be required when configured", func() {
+			server := newFakeSMTPServer(t, serverTLS, false, false)
+
+			mailer, err := NewMailer(&config.Config{
+				SMTPHost: "127.0.0.1",
+				SMTPPort: server.port(),
+				SMTPTLS:  "starttls",
+				SMTPFrom: "reporter@example.com",
+			})
+			So(err, ShouldBeNil)
+
+			err = mailer.Send(t.Context(), email)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "STARTTLS")
+		})
+
+		Convey("Invalid recipients should be rejected", func() {
+			mailer, err := NewMailer(&config.Config{
+				SMTPHost: "127.0.0.1",
+				SMTPPort: 25,
+				SMTPFrom: "reporter@example.com",
+			})
+			So(err, ShouldBeNil)
+
+			So(mailer.Send(t.Context(), Email{To: []string{"not an address"}}), ShouldNotBeNil)
+			So(mailer.Send(t.Context(), Email{}), ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,199 @@
+package delivery
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/report"
+)
+
+// S3 uploads reports to a bucket of a S3 compatible object storage.
+type S3 struct {
+	client    *http.Client
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	prefix    *template.Template
+}
+
+// Make sure S3 can be used as a report sink.
+var _ report.Sink = (*S3)(nil)
+
+// objectKeyData is the data available in the prefix template of object keys.
+type objectKeyData struct {
+	DashboardUID string
+	Title        string
+	From         time.Time
+	To           time.Time
+	Date         time.Time
+}
+
+// NewS3 returns a new S3 sink using settings of conf.
+func NewS3(conf *config.Config) (*S3, error) {
+	if conf.S3Bucket == "" {
+		return nil, errors.New("s3 bucket is not configured")
+	}
+
+	endpoint, err := url.Parse(conf.S3Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %w", err)
+	}
+
+	prefix, err := template.New("prefix").Parse(conf.S3Prefix)
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 prefix: %w", err)
+	}
+
+	return &S3{
+		client:    &http.Client{Timeout: time.Duration(conf.Timeout) * time.Second},
+		endpoint:  endpoint,
+		region:    conf.S3Region,
+		bucket:    conf.S3Bucket,
+		accessKey: conf.S3AccessKey,
+		secretKey: conf.S3SecretKey,
+		prefix:    prefix,
+	}, nil
+}
+
+// Key returns the object key of the document.
+func (s *S3) Key(doc report.Document) (string, error) {
+	data := objectKeyData{
+		DashboardUID: doc.DashboardUID,
+		Title:        doc.Title,
+		From:         doc.TimeRange.FromTime(),
+		To:           doc.TimeRange.ToTime(),
+		Date:         doc.GeneratedAt,
+	}
+
+	var prefix strings.Builder
+	if err := s.prefix.Execute(&prefix, data); err != nil {
+		return "", fmt.Errorf("error executing prefix template: %w", err)
+	}
+
+	// Slashes in title must not create new folders
+	name := strings.ReplaceAll(doc.Title, "/", "-")
+
+	return prefix.String() + fmt.Sprintf("%s_%s.%s", name, doc.GeneratedAt.UTC().Format("20060102T150405Z"), cmp.Or(doc.Format, report.FormatPDF)), nil
+}
+
+// Store uploads the document to the bucket.
+func (s *S3) Store(ctx context.Context, doc report.Document) error {
+	key, err := s.Key(doc)
+	if err != nil {
+		return err
+	}
+
+	// Always use path style URLs as they are supported by all S3 compatible
+	// object storages
+	u := *s.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.bucket + "/" + strings.TrimPrefix(key, "/")
+	u.RawPath = uriEncode(u.Path)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewReader(doc.Data))
+	if err != nil {
+		return fmt.Errorf("failed to create s3 request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", doc.ContentType)
+
+	if s.accessKey != "" {
+		s.sign(req, doc.Data, time.Now())
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload report to s3: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+		return fmt.Errorf("failed to upload report to s3: %s: %s", resp.Status, body)
+	}
+
+	return nil
+}
+
+// sign signs the request using AWS Signature Version 4.
+func (s *S3) sign(req *http.Request, payload []byte, t time.Time) {
+	t = t.UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+
+	payloadHash := sha256.Sum256(payload)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := fmt.Sprintf("host:%s\nx-amz-content-sha256:%s\nx-amz-date:%s\n",
+		req.URL.Host, hex.EncodeToString(payloadHash[:]), amzDate)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	signature := hex.EncodeToString(hmacSHA256(signingKey(s.secretKey, date, s.region, "s3"), stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+// signingKey derives the signing key of AWS Signature Version 4.
+func signingKey(secret, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+
+	return h.Sum(nil)
+}
+
+// uriEncode encodes path s as required by AWS Signature Version 4 where only
+// unreserved characters and slashes are kept as such.
+func uriEncode(s string) string {
+	var b strings.Builder
+
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
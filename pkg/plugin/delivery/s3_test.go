@@ -0,0 +1,183 @@
+package delivery
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/report"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeS3Server is a minimal S3 compatible server like MinIO that verifies
+// signatures of PUT requests and keeps objects in memory.
+type fakeS3Server struct {
+	*httptest.Server
+
+	accessKey, secretKey, region string
+
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newFakeS3Server(t *testing.T, accessKey, secretKey, region string) *fakeS3Server {
+	t.Helper()
+
+	s := &fakeS3Server{
+		accessKey: accessKey,
+		secretKey: secretKey,
+		region:    region,
+		objects:   make(map[string][]byte),
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *fakeS3Server) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	if err := s.verify(r, body); err != nil {
+		http.Error(w, "<Error><Code>SignatureDoesNotMatch</Code></Error>", http.StatusForbidden)
+
+		return
+	}
+
+	s.mu.Lock()
+	s.objects[r.URL.Path] = body
+	s.mu.Unlock()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// verify checks the AWS Signature Version 4 of the request.
+func (s *fakeS3Server) verify(r *http.Request, body []byte) error {
+	payloadHash := sha256.Sum256(body)
+	if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(payloadHash[:]) {
+		return errors.New("payload hash mismatch")
+	}
+
+	amzDate := r.Header.Get("X-Amz-Date")
+
+	t, err := time.Parse("20060102T150405Z", amzDate)
+	if err != nil {
+		return err
+	}
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", t.Format("20060102"), s.region)
+
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		r.URL.EscapedPath(),
+		r.URL.RawQuery,
+		fmt.Sprintf("host:%s\nx-amz-content-sha256:%s\nx-amz-date:%s\n", r.Host, hex.EncodeToString(payloadHash[:]), amzDate),
+		"host;x-amz-content-sha256;x-amz-date",
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+	signature := hex.EncodeToString(hmacSHA256(signingKey(s.secretKey, t.Format("20060102"), s.region, "s3"), stringToSign))
+
+	expected := fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=%s",
+		s.accessKey, scope, signature)
+	if r.Header.Get("Authorization") != expected {
+		return errors.New("signature mismatch")
+	}
+
+	return nil
+}
+
+func (s *fakeS3Server) object(path string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, ok := s.objects[path]
+
+	return data, ok
+}
+
+func TestS3(t *testing.T) {
+	Convey("When deriving AWS signing key", t, func() {
+		// Example from AWS documentation of Signature Version 4
+		key := signingKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam")
+
+		Convey("Signing key should match the reference", func() {
+			So(hex.EncodeToString(key), ShouldEqual, "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d")
+		})
+	})
+
+	Convey("When uploading reports to S3", t, func() {
+		server := newFakeS3Server(t, "minioadmin", "miniosecret", "eu-west-3")
+
+		conf := &config.Config{
+			S3Endpoint:  server.URL,
+			S3Region:    "eu-west-3",
+			S3Bucket:    "reports",
+			S3Prefix:    `archive/{{.DashboardUID}}/{{.From.UTC.Format "2006-01-02"}}_{{.To.UTC.Format "2006-01-02"}}/`,
+			S3AccessKey: "minioadmin",
+			S3SecretKey: "miniosecret",
+			Timeout:     10,
+		}
+
+		doc := report.Document{
+			DashboardUID: "abc",
+			Title:        "Ops / Overview",
+			TimeRange:    dashboard.TimeRange{From: "1734134400000", To: "1734220800000"},
+			GeneratedAt:  time.Date(2024, time.December, 15, 8, 30, 0, 0, time.UTC),
+			ContentType:  "application/pdf",
+			Data:         []byte("%PDF-1.4 fake"),
+		}
+
+		s3, err := NewS3(conf)
+		So(err, ShouldBeNil)
+
+		Convey("Object key should be rendered from the prefix template", func() {
+			key, err := s3.Key(doc)
+			So(err, ShouldBeNil)
+			So(key, ShouldEqual, "archive/abc/2024-12-14_2024-12-15/Ops - Overview_20241215T083000Z.pdf")
+		})
+
+		Convey("Signed upload should store the report in the bucket", func() {
+			So(s3.Store(t.Context(), doc), ShouldBeNil)
+
+			data, ok := server.object("/reports/archive/abc/2024-12-14_2024-12-15/Ops - Overview_20241215T083000Z.pdf")
+			So(ok, ShouldBeTrue)
+			So(string(data), ShouldEqual, "%PDF-1.4 fake")
+		})
+
+		Convey("Upload with wrong credentials should fail", func() {
+			conf.S3SecretKey = "wrong"
+
+			s3, err := NewS3(conf)
+			So(err, ShouldBeNil)
+
+			err = s3.Store(t.Context(), doc)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "403")
+		})
+	})
+}
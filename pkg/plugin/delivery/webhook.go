@@ -0,0 +1,197 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+// Webhook events.
+const (
+	ReportSucceeded = "report.succeeded"
+	ReportFailed    = "report.failed"
+)
+
+// Headers of webhook requests.
+const (
+	EventHeader     = "X-Reporter-Event"
+	SignatureHeader = "X-Reporter-Signature-256"
+)
+
+// Initial delay between retries of webhook requests. It doubles after every retry.
+var webhookBackoff = time.Second
+
+// Event is the JSON payload of webhook requests.
+type Event struct {
+	Event        string    `json:"event"`
+	DashboardUID string    `json:"dashUid"`
+	Title        string    `json:"title"`
+	From         time.Time `json:"from"`
+	To           time.Time `json:"to"`
+	Duration     float64   `json:"durationSeconds"`
+	Size         int64     `json:"size"`
+	Error        string    `json:"error,omitempty"`
+	SinkError    string    `json:"sinkError,omitempty"`
+	DownloadURL  string    `json:"downloadUrl,omitempty"`
+	JobID        string    `json:"jobId,omitempty"`
+	ScheduleID   string    `json:"scheduleId,omitempty"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// Webhooks sends events to the configured webhooks in the background.
+type Webhooks struct {
+	logger     log.Logger
+	client     *http.Client
+	urls       []string
+	secret     string
+	maxRetries int
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// mu guards stopped so that no request is added once Stop waits for them
+	mu      sync.Mutex
+	stopped bool
+}
+
+// NewWebhooks returns a new Webhooks using settings of conf.
+func NewWebhooks(ctx context.Context, logger log.Logger, conf *config.Config) *Webhooks {
+	ctx, cancel := context.WithCancel(ctx)
+
+	return &Webhooks{
+		logger:     logger.With("subsystem", "webhooks"),
+		client:     &http.Client{Timeout: time.Duration(conf.Timeout) * time.Second},
+		urls:       conf.WebhookURLs,
+		secret:     conf.WebhookSecret,
+		maxRetries: conf.WebhookMaxRetries,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Notify sends the event to all webhooks without waiting for the responses.
+// Events are dropped after Stop.
+func (w *Webhooks) Notify(event Event) {
+	if w == nil || len(w.urls) == 0 {
+		return
+	}
+
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		w.logger.Error("failed to encode webhook event", "err", err)
+
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stopped {
+		w.logger.Warn("webhooks are stopped, dropping event", "event", event.Event)
+
+		return
+	}
+
+	for _, url := range w.urls {
+		w.wg.Add(1)
+
+		go func() {
+			defer w.wg.Done()
+
+			if err := w.send(w.ctx, url, event.Event, body); err != nil {
+				w.logger.Error("failed to send webhook", "url", url, "event", event.Event, "err", err)
+			}
+		}()
+	}
+}
+
+// Stop cancels pending webhook requests and waits for them to return.
+func (w *Webhooks) Stop() {
+	w.mu.Lock()
+	w.stopped = true
+	w.mu.Unlock()
+
+	w.cancel()
+	w.wg.Wait()
+}
+
+// send posts the body to url and retries with exponential backoff on
+// network errors and server side errors.
+func (w *Webhooks) send(ctx context.Context, url, event string, body []byte) error {
+	backoff := webhookBackoff
+
+	var err error
+
+	for attempt := 0; attempt <= w.maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+				backoff *= 2
+			case <-ctx.Done():
+				return fmt.Errorf("%w: last error: %w", ctx.Err(), err)
+			}
+		}
+
+		var retry bool
+		if retry, err = w.post(ctx, url, event, body); err == nil || !retry {
+			return err
+		}
+	}
+
+	return fmt.Errorf("giving up after %d attempts: %w", w.maxRetries+1, err)
+}
+
+// post makes a single webhook request. It returns true when the request
+// can be retried.
+func (w *Webhooks) post(ctx context.Context, url, event string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event)
+
+	if w.secret != "" {
+		req.Header.Set(SignatureHeader, Sign(w.secret, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return !errors.Is(err, context.Canceled), err
+	}
+	defer resp.Body.Close()
+
+	// Drain body to reuse connection
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096)) //nolint:errcheck
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("webhook returned %s", resp.Status)
+	default:
+		return false, fmt.Errorf("webhook returned %s", resp.Status)
+	}
+}
+
+// Sign returns the signature of the webhook body which is the hex encoded
+// HMAC SHA256 of the body using secret as key, prefixed by "sha256=".
+func Sign(secret string, body []byte) string {
+	return "sha256=" + hex.EncodeToString(hmacSHA256([]byte(secret), string(body)))
+}
@@ -0,0 +1,148 @@
+package delivery
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWebhooks(t *testing.T) {
+	// Do not wait for long between retries in tests
+	webhookBackoff = time.Millisecond
+
+	Convey("When sending webhooks", t, func() {
+		var (
+			attempts  atomic.Int32
+			failFirst atomic.Int32
+			status    atomic.Int32
+			signature atomic.Value
+			event     atomic.Value
+			payload   atomic.Value
+		)
+
+		status.Store(http.StatusInternalServerError)
+
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := attempts.Add(1)
+
+			body, _ := io.ReadAll(r.Body)
+
+			signature.Store(r.Header.Get(SignatureHeader))
+			event.Store(r.Header.Get(EventHeader))
+			payload.Store(body)
+
+			if n <= failFirst.Load() {
+				w.WriteHeader(int(status.Load()))
+
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer receiver.Close()
+
+		webhooks := NewWebhooks(t.Context(), log.NewNullLogger(), &config.Config{
+			WebhookURLs:       []string{receiver.URL},
+			WebhookSecret:     "secret",
+			WebhookMaxRetries: 3,
+			Timeout:           10,
+		})
+		defer webhooks.Stop()
+
+		e := Event{
+			Event:        ReportSucceeded,
+			DashboardUID: "abc",
+			Title:        "Overview",
+			Size:         1024,
+			DownloadURL:  "http://localhost:3000/report",
+		}
+
+		Convey("Signed payload should be delivered", func() {
+			webhooks.Notify(e)
+			webhooks.wg.Wait()
+
+			So(attempts.Load(), ShouldEqual, 1)
+			So(event.Load(), ShouldEqual, ReportSucceeded)
+
+			body, _ := payload.Load().([]byte)
+			So(signature.Load(), ShouldEqual, Sign("secret", body))
+
+			var got Event
+			So(json.Unmarshal(body, &got), ShouldBeNil)
+			So(got.DashboardUID, ShouldEqual, "abc")
+			So(got.Size, ShouldEqual, 1024)
+			So(got.DownloadURL, ShouldEqual, "http://localhost:3000/report")
+			So(got.Timestamp.IsZero(), ShouldBeFalse)
+		})
+
+		Convey("Server errors should be retried", func() {
+			failFirst.Store(2)
+
+			webhooks.Notify(e)
+			webhooks.wg.Wait()
+
+			So(attempts.Load(), ShouldEqual, 3)
+		})
+
+		Convey("Retries should stop after max retries", func() {
+			failFirst.Store(10)
+
+			webhooks.Notify(e)
+			webhooks.wg.Wait()
+
+			So(attempts.Load(), ShouldEqual, 4)
+		})
+
+		Convey("Client errors should not be retried", func() {
+			failFirst.Store(10)
+			status.Store(http.StatusBadRequest)
+
+			webhooks.Notify(e)
+			webhooks.wg.Wait()
+
+			So(attempts.Load(), ShouldEqual, 1)
+		})
+
+		Convey("Notifying while stopping should not race with waiting for requests", func() {
+			var wg sync.WaitGroup
+
+			for range 50 {
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+
+					webhooks.Notify(e)
+				}()
+			}
+
+			webhooks.Stop()
+			wg.Wait()
+
+			Convey("Events notified after stop should be dropped", func() {
+				n := attempts.Load()
+
+				webhooks.Notify(e)
+				webhooks.wg.Wait()
+
+				So(attempts.Load(), ShouldEqual, n)
+			})
+		})
+	})
+
+	Convey("When computing signatures", t, func() {
+		Convey("Signature should be HMAC SHA256 of body", func() {
+			So(Sign("key", []byte("The quick brown fox jumps over the lazy dog")), ShouldEqual,
+				"sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8")
+		})
+	})
+}
@@ -0,0 +1,120 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/report"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/worker"
+)
+
+// Error codes of failed report requests.
+const (
+	codeBadRequest        = "bad_request"
+	codeMethodNotAllowed  = "method_not_allowed"
+	codeDashboardNotFound = "dashboard_not_found"
+	codePermissionDenied  = "permission_denied"
+	codeTimeout           = "timeout"
+	codeServerBusy        = "server_busy"
+	codeNoPanels          = "no_panels"
+	codeReportFailed      = "report_failed"
+)
+
+// Error codes and HTTP statuses of sentinel errors. First matching error wins.
+var errorCodes = []struct {
+	err    error
+	code   string
+	status int
+}{
+	{dashboard.ErrDashboardNotFound, codeDashboardNotFound, http.StatusNotFound},
+	{dashboard.ErrPermissionDenied, codePermissionDenied, http.StatusForbidden},
+	{worker.ErrQueueFull, codeServerBusy, http.StatusTooManyRequests},
+	{dashboard.ErrTimeout, codeTimeout, http.StatusGatewayTimeout},
+	{context.DeadlineExceeded, codeTimeout, http.StatusGatewayTimeout},
+	{dashboard.ErrNoPanels, codeNoPanels, http.StatusInternalServerError},
+}
+
+// errorResponse is the JSON body of failed report requests.
+type errorResponse struct {
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Stage   string         `json:"stage,omitempty"`
+	Panels  []panelFailure `json:"panels,omitempty"`
+
+	// Position in queue of workers the report would have had when the
+	// server is busy
+	QueuePosition int `json:"queuePosition,omitempty"`
+}
+
+// panelFailure is the failure of a panel in errorResponse.
+type panelFailure struct {
+	PanelID string `json:"panelId"`
+	Title   string `json:"title,omitempty"`
+	Stage   string `json:"stage"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// errorCode returns error code and HTTP status of err.
+func errorCode(err error) (string, int) {
+	for _, c := range errorCodes {
+		if errors.Is(err, c.err) {
+			return c.code, c.status
+		}
+	}
+
+	return codeReportFailed, http.StatusInternalServerError
+}
+
+// newErrorResponse returns the error response of err. Stage and failures of
+// panels are taken from the report.StageError wrapped by err and queue
+// position from the worker.QueueFullError wrapped by err.
+func newErrorResponse(err error) (errorResponse, int) {
+	code, status := errorCode(err)
+
+	resp := errorResponse{
+		Code:    code,
+		Message: err.Error(),
+	}
+
+	var queueErr *worker.QueueFullError
+	if errors.As(err, &queueErr) {
+		resp.QueuePosition = queueErr.Position
+	}
+
+	var stageErr *report.StageError
+	if errors.As(err, &stageErr) {
+		resp.Stage = stageErr.Stage
+
+		for _, failure := range stageErr.Panels {
+			panelCode, _ := errorCode(failure.Err)
+
+			resp.Panels = append(resp.Panels, panelFailure{
+				PanelID: failure.PanelID,
+				Title:   failure.Title,
+				Stage:   failure.Stage,
+				Code:    panelCode,
+				Message: failure.Err.Error(),
+			})
+		}
+	}
+
+	return resp, status
+}
+
+// writeError writes err as JSON error response.
+func writeError(w http.ResponseWriter, err error) {
+	resp, status := newErrorResponse(err)
+
+	// Headers of the report might have been set before it failed
+	w.Header().Del("Content-Disposition")
+
+	writeJSON(w, status, resp)
+}
+
+// writeBadRequest writes a JSON error response for an invalid request.
+func writeBadRequest(w http.ResponseWriter, message string) {
+	writeJSON(w, http.StatusBadRequest, errorResponse{Code: codeBadRequest, Message: message})
+}
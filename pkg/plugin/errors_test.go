@@ -0,0 +1,180 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/chrome"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/delivery"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/report"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/worker"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestErrorResponses(t *testing.T) {
+	Convey("When the report handler fails", t, func() {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/dashboards/uid/private":
+				http.Error(w, `{"message": "access denied"}`, http.StatusForbidden)
+			case "/api/dashboards/uid/ops":
+				w.Write([]byte(`{"dashboard": {"uid": "ops", "title": "Ops"}}`))
+			default:
+				http.Error(w, `{"message": "Dashboard not found"}`, http.StatusNotFound)
+			}
+		}))
+		defer ts.Close()
+
+		conf := config.Config{
+			Theme:         "light",
+			Orientation:   "portrait",
+			Layout:        "simple",
+			DashboardMode: "default",
+			Token:         "token",
+		}
+		So(conf.Validate(), ShouldBeNil)
+
+		app := &App{
+			conf:           conf,
+			httpClient:     ts.Client(),
+			chromeInstance: &chrome.LocalInstance{},
+			grafanaSemVer:  "v11.4.0",
+		}
+
+		ctx := backend.WithGrafanaConfig(t.Context(), backend.NewGrafanaCfg(map[string]string{
+			backend.AppURL: ts.URL,
+		}))
+		ctx = backend.WithPluginContext(ctx, backend.PluginContext{User: &backend.User{Login: "foo"}})
+
+		get := func(query string) (*httptest.ResponseRecorder, errorResponse) {
+			req := httptest.NewRequestWithContext(ctx, http.MethodGet, "/report?"+query, nil)
+			rec := httptest.NewRecorder()
+			app.handleReport(rec, req)
+
+			var resp errorResponse
+			So(json.Unmarshal(rec.Body.Bytes(), &resp), ShouldBeNil)
+
+			return rec, resp
+		}
+
+		Convey("It should return JSON errors with code and stage", func() {
+			rec, resp := get("dashUid=missing")
+			So(rec.Code, ShouldEqual, http.StatusNotFound)
+			So(rec.Header().Get("Content-Type"), ShouldEqual, "application/json")
+			So(resp.Code, ShouldEqual, "dashboard_not_found")
+			So(resp.Stage, ShouldEqual, report.StageModel)
+
+			rec, resp = get("dashUid=private")
+			So(rec.Code, ShouldEqual, http.StatusForbidden)
+			So(resp.Code, ShouldEqual, "permission_denied")
+		})
+
+		Convey("It should reject invalid time ranges", func() {
+			rec, resp := get("dashUid=missing&from=yesterday")
+			So(rec.Code, ShouldEqual, http.StatusBadRequest)
+			So(resp.Code, ShouldEqual, "bad_request")
+		})
+
+		Convey("It should return the stage of failed panel discovery of every format", func() {
+			// Browser is unreachable so that panels cannot be discovered
+			chromeInstance, err := chrome.NewRemoteBrowserInstance(t.Context(), log.NewNullLogger(), "ws://127.0.0.1:1")
+			So(err, ShouldBeNil)
+
+			defer chromeInstance.Close(log.NewNullLogger())
+
+			app.chromeInstance = chromeInstance
+			// Do not wait long for the browser to be reachable
+			app.conf.HTTPClientOptions.Timeouts = &httpclient.TimeoutOptions{Timeout: 100 * time.Millisecond}
+
+			for _, format := range []string{"pdf", "xlsx", "zip"} {
+				rec, resp := get("dashUid=ops&format=" + format)
+				So(rec.Code, ShouldEqual, http.StatusInternalServerError)
+				So(resp.Code, ShouldEqual, "report_failed")
+				So(resp.Stage, ShouldEqual, report.StageDiscovery)
+			}
+		})
+
+		Convey("It should notify webhooks about the failed report", func() {
+			events := make(chan delivery.Event, 1)
+
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var event delivery.Event
+				if err := json.NewDecoder(r.Body).Decode(&event); err == nil {
+					events <- event
+				}
+			}))
+			defer receiver.Close()
+
+			app.webhooks = delivery.NewWebhooks(t.Context(), log.NewNullLogger(), &config.Config{
+				WebhookURLs: []string{receiver.URL},
+			})
+			defer app.webhooks.Stop()
+
+			get("dashUid=private")
+
+			select {
+			case event := <-events:
+				So(event.Event, ShouldEqual, delivery.ReportFailed)
+				So(event.DashboardUID, ShouldEqual, "private")
+				So(event.Error, ShouldContainSubstring, "permission denied")
+			case <-time.After(5 * time.Second):
+				So("no webhook event received", ShouldBeEmpty)
+			}
+		})
+
+		Convey("It should reject invalid requests", func() {
+			rec, resp := get("theme=blue")
+			So(rec.Code, ShouldEqual, http.StatusBadRequest)
+			So(resp.Code, ShouldEqual, "bad_request")
+
+			rec, resp = get("dashUid=abc&theme=blue")
+			So(rec.Code, ShouldEqual, http.StatusBadRequest)
+			So(resp.Message, ShouldContainSubstring, "theme: blue")
+		})
+	})
+
+	Convey("When panels of a report fail", t, func() {
+		err := fmt.Errorf("failed to populate panels: %w", &report.StageError{
+			Stage: report.StagePanelPNG,
+			Panels: []*report.PanelError{
+				{PanelID: "2", Title: "CPU", Stage: report.StagePanelPNG, Err: fmt.Errorf("render: %w", dashboard.ErrTimeout)},
+				{PanelID: "3", Stage: report.StagePanelCSV, Err: dashboard.ErrEmptyCSVData},
+			},
+			Err: dashboard.ErrTimeout,
+		})
+
+		resp, status := newErrorResponse(err)
+
+		Convey("Every failure of panels should be in the response", func() {
+			So(status, ShouldEqual, http.StatusGatewayTimeout)
+			So(resp.Code, ShouldEqual, "timeout")
+			So(resp.Stage, ShouldEqual, report.StagePanelPNG)
+			So(resp.Panels, ShouldResemble, []panelFailure{
+				{PanelID: "2", Title: "CPU", Stage: report.StagePanelPNG, Code: "timeout", Message: "render: request to grafana timed out"},
+				{PanelID: "3", Stage: report.StagePanelCSV, Code: "report_failed", Message: "empty csv data"},
+			})
+		})
+
+		Convey("Full queues of workers should be reported as busy server", func() {
+			resp, status := newErrorResponse(fmt.Errorf("failed to populate panels: %w", worker.ErrQueueFull))
+			So(status, ShouldEqual, http.StatusTooManyRequests)
+			So(resp.Code, ShouldEqual, "server_busy")
+		})
+
+		Convey("Busy server should report the queue position of the report", func() {
+			resp, status := newErrorResponse(fmt.Errorf("failed to populate panels: %w", &worker.QueueFullError{Position: 4}))
+			So(status, ShouldEqual, http.StatusTooManyRequests)
+			So(resp.Code, ShouldEqual, "server_busy")
+			So(resp.QueuePosition, ShouldEqual, 4)
+		})
+	})
+}
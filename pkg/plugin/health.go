@@ -0,0 +1,194 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+// Maximum duration of each health check.
+const healthCheckTimeout = 10 * time.Second
+
+// Status of health checks.
+const (
+	healthCheckOK      = "ok"
+	healthCheckError   = "error"
+	healthCheckSkipped = "skipped"
+)
+
+// healthCheck is the result of a single health check.
+type healthCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// frontendSettings are the fields of Grafana frontend settings used by
+// health checks.
+type frontendSettings struct {
+	RendererAvailable bool   `json:"rendererAvailable"`
+	RendererVersion   string `json:"rendererVersion"`
+	BuildInfo         struct {
+		Version string `json:"version"`
+	} `json:"buildInfo"`
+}
+
+// CheckHealth handles health checks sent from Grafana to the plugin. It
+// checks that the browser is working, Grafana API is reachable with the
+// configured token and, when native rendering is disabled, image renderer
+// is available in Grafana.
+func (app *App) CheckHealth(ctx context.Context, _ *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	logger := app.ctxLogger.FromContext(ctx).With("subsystem", "health")
+
+	grafanaCheck, settings := app.checkGrafana(ctx, logger)
+
+	checks := []healthCheck{
+		app.checkChrome(ctx, logger),
+		grafanaCheck,
+		app.checkImageRenderer(grafanaCheck, settings),
+	}
+
+	details, err := json.Marshal(map[string]any{"checks": checks})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode health check details: %w", err)
+	}
+
+	var failed []string
+
+	for _, check := range checks {
+		if check.Status == healthCheckError {
+			failed = append(failed, check.Name+": "+check.Message)
+		}
+	}
+
+	if len(failed) > 0 {
+		return &backend.CheckHealthResult{
+			Status:      backend.HealthStatusError,
+			Message:     strings.Join(failed, "; "),
+			JSONDetails: details,
+		}, nil
+	}
+
+	return &backend.CheckHealthResult{
+		Status:      backend.HealthStatusOk,
+		Message:     "ok",
+		JSONDetails: details,
+	}, nil
+}
+
+// checkChrome checks that a new tab can be opened in the browser.
+func (app *App) checkChrome(ctx context.Context, logger log.Logger) healthCheck {
+	check := healthCheck{Name: "chrome"}
+
+	tab := app.chromeInstance.NewTab(ctx, logger, &app.conf)
+	tab.WithTimeout(healthCheckTimeout)
+	defer tab.Close(logger)
+
+	if err := tab.Run(chromedp.Navigate("about:blank")); err != nil {
+		check.Status = healthCheckError
+		check.Message = fmt.Sprintf("failed to open a tab in %s browser: %s", app.chromeInstance.Name(), err)
+
+		return check
+	}
+
+	check.Status = healthCheckOK
+	check.Message = app.chromeInstance.Name() + " browser is working"
+
+	return check
+}
+
+// checkGrafana checks that Grafana API is reachable with the configured
+// token and returns the frontend settings of Grafana.
+func (app *App) checkGrafana(ctx context.Context, logger log.Logger) (healthCheck, frontendSettings) {
+	check := healthCheck{Name: "grafana", Status: healthCheckError}
+
+	var settings frontendSettings
+
+	grafanaConfig := backend.GrafanaConfigFromContext(ctx)
+	if grafanaConfig == nil {
+		grafanaConfig = app.grafanaConfig
+	}
+
+	appURL, err := app.grafanaAppURL(grafanaConfig)
+	if err != nil {
+		check.Message = fmt.Sprintf("failed to get Grafana URL: %s", err)
+
+		return check, settings
+	}
+
+	authHeader, err := app.tokenAuthHeader(logger, grafanaConfig, &app.conf)
+	if err != nil {
+		check.Message = fmt.Sprintf("failed to get token: %s", err)
+
+		return check, settings
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, appURL+"/api/frontend/settings", nil)
+	if err != nil {
+		check.Message = fmt.Sprintf("failed to create request: %s", err)
+
+		return check, settings
+	}
+
+	req.Header = authHeader
+
+	resp, err := app.httpClient.Do(req)
+	if err != nil {
+		check.Message = fmt.Sprintf("failed to reach Grafana at %s: %s", appURL, err)
+
+		return check, settings
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		check.Message = fmt.Sprintf("Grafana API at %s returned %s: %s", appURL, resp.Status, strings.TrimSpace(string(body)))
+
+		return check, settings
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&settings); err != nil {
+		check.Message = fmt.Sprintf("failed to decode Grafana settings: %s", err)
+
+		return check, settings
+	}
+
+	check.Status = healthCheckOK
+	check.Message = fmt.Sprintf("Grafana %s is reachable at %s", settings.BuildInfo.Version, appURL)
+
+	return check, settings
+}
+
+// checkImageRenderer checks that image renderer is available in Grafana
+// when native rendering is disabled.
+func (app *App) checkImageRenderer(grafanaCheck healthCheck, settings frontendSettings) healthCheck {
+	check := healthCheck{Name: "imageRenderer"}
+
+	switch {
+	case app.conf.NativeRendering:
+		check.Status = healthCheckSkipped
+		check.Message = "native rendering is enabled"
+	case grafanaCheck.Status != healthCheckOK:
+		check.Status = healthCheckError
+		check.Message = "cannot be checked as Grafana is not reachable"
+	case !settings.RendererAvailable:
+		check.Status = healthCheckError
+		check.Message = "grafana-image-renderer is not available in Grafana. Install it or enable native rendering"
+	default:
+		check.Status = healthCheckOK
+		check.Message = "grafana-image-renderer " + settings.RendererVersion + " is available"
+	}
+
+	return check
+}
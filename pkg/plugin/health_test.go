@@ -0,0 +1,121 @@
+package plugin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/chrome"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCheckHealth(t *testing.T) {
+	Convey("When Grafana health is checked", t, func() {
+		rendererAvailable := true
+
+		var authorization string
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/api/frontend/settings" {
+				http.NotFound(w, r)
+
+				return
+			}
+
+			authorization = r.Header.Get("Authorization")
+			if authorization != "Bearer token" {
+				http.Error(w, "invalid API key", http.StatusUnauthorized)
+
+				return
+			}
+
+			json.NewEncoder(w).Encode(map[string]any{ //nolint:errcheck
+				"rendererAvailable": rendererAvailable,
+				"rendererVersion":   "3.12.0",
+				"buildInfo":         map[string]string{"version": "11.4.0"},
+			})
+		}))
+		defer ts.Close()
+
+		// Nothing listens on the remote Chrome URL
+		chromeInstance, err := chrome.NewRemoteBrowserInstance(t.Context(), log.NewNullLogger(), "ws://127.0.0.1:1")
+		So(err, ShouldBeNil)
+
+		defer chromeInstance.Close(log.NewNullLogger())
+
+		app := &App{
+			conf:           config.Config{AppURL: ts.URL, Token: "token"},
+			httpClient:     ts.Client(),
+			chromeInstance: chromeInstance,
+			ctxLogger:      log.NewNullLogger(),
+		}
+
+		checkHealth := func() (*backend.CheckHealthResult, map[string]healthCheck) {
+			result, err := app.CheckHealth(t.Context(), &backend.CheckHealthRequest{})
+			So(err, ShouldBeNil)
+
+			var details struct {
+				Checks []healthCheck `json:"checks"`
+			}
+
+			So(json.Unmarshal(result.JSONDetails, &details), ShouldBeNil)
+
+			checks := make(map[string]healthCheck)
+			for _, check := range details.Checks {
+				checks[check.Name] = check
+			}
+
+			return result, checks
+		}
+
+		Convey("Unreachable browser should be reported", func() {
+			result, checks := checkHealth()
+
+			So(result.Status, ShouldEqual, backend.HealthStatusError)
+			So(result.Message, ShouldStartWith, "chrome: ")
+			So(checks["chrome"].Status, ShouldEqual, healthCheckError)
+			So(checks["chrome"].Message, ShouldContainSubstring, "remote browser")
+		})
+
+		Convey("Grafana API should be called with the configured token", func() {
+			_, checks := checkHealth()
+
+			So(authorization, ShouldEqual, "Bearer token")
+			So(checks["grafana"].Status, ShouldEqual, healthCheckOK)
+			So(checks["grafana"].Message, ShouldContainSubstring, "11.4.0")
+			So(checks["imageRenderer"].Status, ShouldEqual, healthCheckOK)
+		})
+
+		Convey("Invalid token should be reported", func() {
+			app.conf.Token = "invalid"
+
+			result, checks := checkHealth()
+
+			So(result.Message, ShouldContainSubstring, "grafana: ")
+			So(checks["grafana"].Status, ShouldEqual, healthCheckError)
+			So(checks["grafana"].Message, ShouldContainSubstring, "401")
+			So(checks["imageRenderer"].Status, ShouldEqual, healthCheckError)
+		})
+
+		Convey("Missing image renderer should be reported", func() {
+			rendererAvailable = false
+
+			_, checks := checkHealth()
+
+			So(checks["imageRenderer"].Status, ShouldEqual, healthCheckError)
+		})
+
+		Convey("Image renderer should not be checked with native rendering", func() {
+			rendererAvailable = false
+			app.conf.NativeRendering = true
+
+			_, checks := checkHealth()
+
+			So(checks["imageRenderer"].Status, ShouldEqual, healthCheckSkipped)
+		})
+	})
+}
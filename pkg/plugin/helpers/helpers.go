@@ -1,6 +1,8 @@
 package helpers
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -15,6 +17,17 @@ func TimeTrack(start time.Time, name string, logger log.Logger, args ...interfac
 	logger.Debug(name, args...)
 }
 
+// GrafanaDataPath returns Grafana's data path based on the path of current executable.
+// Generally the plugin executable should be at data_path/plugins/mahendrapaipuri-dashboardreporter-app/exe.
+func GrafanaDataPath() (string, error) {
+	pluginExe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Dir(filepath.Dir(filepath.Dir(pluginExe))), nil
+}
+
 // SemverCompare compares the semantic version of Grafana versions.
 // Grafana uses "+" as post release suffix and "-" as pre-release
 // suffixes. We take that into account when calling upstream semver
@@ -33,3 +46,24 @@ func SemverCompare(a, b string) int {
 
 	return semver.Compare(a, b)
 }
+
+// PanelIDs returns panel IDs in the format used by the given Grafana version.
+// Starting from Grafana 11.3.0, panel IDs are prefixed by "panel-".
+func PanelIDs(grafanaSemVer string, ids []string) []string {
+	// For Grafana < 11.3.0, we can use the IDs as such
+	if SemverCompare(grafanaSemVer, "v11.3.0") == -1 {
+		return ids
+	}
+
+	panelIDs := make([]string, len(ids))
+
+	for i, id := range ids {
+		if !strings.HasPrefix(id, "panel") {
+			panelIDs[i] = "panel-" + id
+		} else {
+			panelIDs[i] = id
+		}
+	}
+
+	return panelIDs
+}
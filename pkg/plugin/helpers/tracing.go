@@ -0,0 +1,26 @@
+package helpers
+
+import (
+	"context"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// StartSpan starts a new span with the given name and attributes using the
+// tracer of plugin SDK.
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracing.DefaultTracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan ends span and marks it as failed when err is not nil.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
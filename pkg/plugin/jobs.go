@@ -0,0 +1,144 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/jobs"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/report"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/worker"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// startJobs starts the manager of report jobs of the current org.
+func (app *App) startJobs(ctx context.Context) {
+	dir := app.conf.StoragePath
+	if dir == "" {
+		dir = os.TempDir()
+	}
+
+	app.jobs = jobs.New(
+		ctx,
+		app.ctxLogger,
+		filepath.Join(dir, "jobs", strconv.FormatInt(app.orgID, 10)),
+		time.Duration(app.conf.JobRetention)*time.Second,
+	)
+}
+
+// getJob returns the job with the ID in request path. Users can only access
+// their own jobs. If not found, an error response is written.
+func (app *App) getJob(w http.ResponseWriter, req *http.Request) (jobs.Job, bool) {
+	job, err := app.jobs.Get(req.PathValue("id"))
+	if err != nil || job.User != backend.PluginConfigFromContext(req.Context()).User.Login {
+		http.Error(w, "job not found", http.StatusNotFound)
+
+		return jobs.Job{}, false
+	}
+
+	return job, true
+}
+
+// handleCreateJob starts generating a report of a given dashboard UID in
+// the background. It accepts the same query parameters as report endpoint
+// POST /api/plugins/mahendrapaipuri-dashboardreporter-app/resources/jobs.
+func (app *App) handleCreateJob(w http.ResponseWriter, req *http.Request) {
+	// Validate the request and check permissions before accepting the job
+	// so that user gets the errors right away
+	reportReq, ok := app.newReportRequest(w, req)
+	if !ok {
+		return
+	}
+
+	filename := reportReq.model.Dashboard.Title + reportReq.report.Extension()
+
+	job := app.jobs.Submit(reportReq.user, reportReq.model.Dashboard.UID,
+		func(ctx context.Context, jobID string, progress jobs.ProgressFunc, w io.Writer) (string, error) {
+			start := time.Now()
+
+			reportReq.report.OnProgress(progress)
+
+			err := reportReq.report.Generate(worker.WithUser(ctx, reportReq.user, worker.Interactive), w)
+
+			event := reportEvent(reportReq.model, reportReq.report, start, err)
+			event.JobID = jobID
+			event.DownloadURL = resourceURL(reportReq.appURL, "jobs/"+jobID+"/result")
+			app.webhooks.Notify(event)
+
+			if err != nil {
+				return "", fmt.Errorf("error generating report: %w", err)
+			}
+
+			return filename, nil
+		},
+	)
+
+	reportReq.logger.Info("report job submitted", "job_id", job.ID)
+
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// handleGetJob returns the state, progress and queue position of a job
+// GET /api/plugins/mahendrapaipuri-dashboardreporter-app/resources/jobs/{id}.
+func (app *App) handleGetJob(w http.ResponseWriter, req *http.Request) {
+	if job, ok := app.getJob(w, req); ok {
+		if !job.Finished() {
+			job.QueuePosition = app.workerPools.Position(job.User)
+		}
+
+		writeJSON(w, http.StatusOK, job)
+	}
+}
+
+// handleCancelJob cancels a running job
+// DELETE /api/plugins/mahendrapaipuri-dashboardreporter-app/resources/jobs/{id}.
+func (app *App) handleCancelJob(w http.ResponseWriter, req *http.Request) {
+	job, ok := app.getJob(w, req)
+	if !ok {
+		return
+	}
+
+	if err := app.jobs.Cancel(job.ID); err != nil {
+		http.Error(w, "job not found", http.StatusNotFound)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleJobResult returns the report generated by a successful job
+// GET /api/plugins/mahendrapaipuri-dashboardreporter-app/resources/jobs/{id}/result.
+func (app *App) handleJobResult(w http.ResponseWriter, req *http.Request) {
+	job, ok := app.getJob(w, req)
+	if !ok {
+		return
+	}
+
+	f, err := app.jobs.Result(job.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, jobs.ErrNotFinished):
+			http.Error(w, fmt.Sprintf("job is %s", job.State), http.StatusConflict)
+		case errors.Is(err, jobs.ErrNotFound), errors.Is(err, os.ErrNotExist):
+			http.Error(w, "job not found", http.StatusNotFound)
+		default:
+			http.Error(w, "failed to read job result", http.StatusInternalServerError)
+		}
+
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", report.ContentType(strings.TrimPrefix(filepath.Ext(job.Filename), ".")))
+	w.Header().Set("Content-Disposition", "inline; filename*=UTF-8''"+url.PathEscape(job.Filename))
+	http.ServeContent(w, req, "", job.FinishedAt, f)
+}
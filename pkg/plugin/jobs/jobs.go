@@ -0,0 +1,288 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+// Job states.
+const (
+	Running   = "running"
+	Succeeded = "succeeded"
+	Failed    = "failed"
+	Cancelled = "cancelled"
+)
+
+var (
+	ErrNotFound    = errors.New("job not found")
+	ErrNotFinished = errors.New("job has not finished successfully")
+)
+
+// Interval at which expired jobs are cleaned up.
+var cleanupInterval = time.Minute
+
+// Progress is the progress of a job in terms of panels.
+type Progress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// Job is a report generated in the background.
+type Job struct {
+	ID           string   `json:"id"`
+	User         string   `json:"user"`
+	DashboardUID string   `json:"dashUid"`
+	State        string   `json:"state"`
+	Progress     Progress `json:"progress"`
+	// Position of the next queued task of user in worker pools. It is set
+	// only when job is running and has queued tasks
+	QueuePosition int       `json:"queuePosition,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	Filename      string    `json:"filename,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+	FinishedAt    time.Time `json:"finishedAt,omitzero"`
+	ExpiresAt     time.Time `json:"expiresAt,omitzero"`
+
+	cancel     context.CancelFunc
+	resultPath string
+}
+
+// Finished returns true if job is not running anymore.
+func (j *Job) Finished() bool {
+	return j.State != Running
+}
+
+// ProgressFunc reports number of done and total panels of a job.
+type ProgressFunc func(done, total int)
+
+// RunFunc generates the report of the job with given ID and writes it to w.
+// It returns the file name of the report.
+type RunFunc func(ctx context.Context, id string, progress ProgressFunc, w io.Writer) (string, error)
+
+// Manager runs jobs and keeps their results for a retention period.
+type Manager struct {
+	logger    log.Logger
+	dir       string
+	retention time.Duration
+
+	mu   sync.RWMutex
+	jobs map[string]*Job
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New returns a new job manager that saves results of jobs in dir.
+func New(ctx context.Context, logger log.Logger, dir string, retention time.Duration) *Manager {
+	ctx, cancel := context.WithCancel(ctx)
+
+	m := &Manager{
+		logger:    logger.With("subsystem", "jobs"),
+		dir:       dir,
+		retention: retention,
+		jobs:      make(map[string]*Job),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+
+	m.wg.Add(1)
+
+	go func() {
+		defer m.wg.Done()
+
+		ticker := time.NewTicker(cleanupInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				m.cleanup(now)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return m
+}
+
+// Submit starts a new job in the background and returns it.
+func (m *Manager) Submit(user, dashboardUID string, run RunFunc) Job {
+	ctx, cancel := context.WithCancel(m.ctx)
+
+	job := &Job{
+		ID:           strings.ToLower(rand.Text()),
+		User:         user,
+		DashboardUID: dashboardUID,
+		State:        Running,
+		CreatedAt:    time.Now(),
+		cancel:       cancel,
+	}
+	job.resultPath = filepath.Join(m.dir, job.ID+".result")
+
+	m.mu.Lock()
+	m.jobs[job.ID] = job
+	snapshot := *job
+	m.mu.Unlock()
+
+	m.wg.Add(1)
+
+	go func() {
+		defer m.wg.Done()
+		defer cancel()
+
+		filename, err := m.run(ctx, job, run)
+
+		m.finish(job.ID, filename, err, ctx.Err())
+	}()
+
+	return snapshot
+}
+
+// run executes the job and writes its result to the job's result file.
+func (m *Manager) run(ctx context.Context, job *Job, run RunFunc) (string, error) {
+	if err := os.MkdirAll(m.dir, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create jobs directory: %w", err)
+	}
+
+	f, err := os.Create(job.resultPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create result file: %w", err)
+	}
+	defer f.Close()
+
+	progress := func(done, total int) {
+		m.mu.Lock()
+		job.Progress = Progress{done, total}
+		m.mu.Unlock()
+	}
+
+	filename, err := run(ctx, job.ID, progress, f)
+	if err != nil {
+		return "", err
+	}
+
+	return filename, f.Close()
+}
+
+// finish records the result of a job.
+func (m *Manager) finish(id, filename string, err, ctxErr error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return
+	}
+
+	job.FinishedAt = time.Now()
+	job.ExpiresAt = job.FinishedAt.Add(m.retention)
+
+	switch {
+	case ctxErr != nil:
+		job.State = Cancelled
+	case err != nil:
+		job.State = Failed
+		job.Error = err.Error()
+	default:
+		job.State = Succeeded
+		job.Filename = filename
+	}
+
+	// Results of unsuccessful jobs are not needed
+	if job.State != Succeeded {
+		m.removeResult(job)
+	}
+
+	m.logger.Info("job finished", "job_id", id, "state", job.State, "err", job.Error)
+}
+
+// Get returns the job with given ID.
+func (m *Manager) Get(id string) (Job, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+
+	return *job, nil
+}
+
+// Cancel cancels a running job.
+func (m *Manager) Cancel(id string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	job.cancel()
+
+	return nil
+}
+
+// Result returns the result of a successful job. Caller must close the returned file.
+func (m *Manager) Result(id string) (*os.File, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	if job.State != Succeeded {
+		return nil, ErrNotFinished
+	}
+
+	return os.Open(job.resultPath)
+}
+
+// Stop cancels all running jobs and removes all the results.
+func (m *Manager) Stop() {
+	m.cancel()
+	m.wg.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, job := range m.jobs {
+		m.removeResult(job)
+		delete(m.jobs, id)
+	}
+}
+
+// cleanup removes jobs whose retention period has expired.
+func (m *Manager) cleanup(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, job := range m.jobs {
+		if job.Finished() && job.ExpiresAt.Before(now) {
+			m.removeResult(job)
+			delete(m.jobs, id)
+		}
+	}
+}
+
+// removeResult removes result file of the job.
+func (m *Manager) removeResult(job *Job) {
+	if err := os.Remove(job.resultPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		m.logger.Error("failed to remove job result", "job_id", job.ID, "err", err)
+	}
+}
@@ -0,0 +1,116 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// waitFinished waits until the job with given ID finishes.
+func waitFinished(m *Manager, id string) Job {
+	for {
+		job, err := m.Get(id)
+		if err != nil || job.Finished() {
+			return job
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestJobs(t *testing.T) {
+	Convey("When running jobs", t, func() {
+		m := New(t.Context(), log.NewNullLogger(), t.TempDir(), time.Hour)
+		defer m.Stop()
+
+		Convey("Result of a successful job should be available", func() {
+			job := m.Submit("user", "dash", func(_ context.Context, _ string, progress ProgressFunc, w io.Writer) (string, error) {
+				progress(2, 2)
+
+				_, err := w.Write([]byte("report"))
+
+				return "report.pdf", err
+			})
+			So(job.State, ShouldEqual, Running)
+
+			job = waitFinished(m, job.ID)
+			So(job.State, ShouldEqual, Succeeded)
+			So(job.Filename, ShouldEqual, "report.pdf")
+			So(job.Progress, ShouldResemble, Progress{2, 2})
+			So(job.ExpiresAt, ShouldEqual, job.FinishedAt.Add(time.Hour))
+
+			f, err := m.Result(job.ID)
+			So(err, ShouldBeNil)
+
+			defer f.Close()
+
+			content, err := io.ReadAll(f)
+			So(err, ShouldBeNil)
+			So(string(content), ShouldEqual, "report")
+		})
+
+		Convey("Failed job should report the error", func() {
+			job := m.Submit("user", "dash", func(context.Context, string, ProgressFunc, io.Writer) (string, error) {
+				return "", errors.New("boom")
+			})
+
+			job = waitFinished(m, job.ID)
+			So(job.State, ShouldEqual, Failed)
+			So(job.Error, ShouldEqual, "boom")
+
+			_, err := m.Result(job.ID)
+			So(err, ShouldEqual, ErrNotFinished)
+		})
+
+		Convey("Cancelled job should stop", func() {
+			started := make(chan struct{})
+
+			job := m.Submit("user", "dash", func(ctx context.Context, _ string, _ ProgressFunc, _ io.Writer) (string, error) {
+				close(started)
+				<-ctx.Done()
+
+				return "", ctx.Err()
+			})
+
+			<-started
+			So(m.Cancel(job.ID), ShouldBeNil)
+
+			job = waitFinished(m, job.ID)
+			So(job.State, ShouldEqual, Cancelled)
+			So(job.Error, ShouldBeEmpty)
+		})
+
+		Convey("Unknown jobs should not be found", func() {
+			_, err := m.Get("unknown")
+			So(err, ShouldEqual, ErrNotFound)
+			So(m.Cancel("unknown"), ShouldEqual, ErrNotFound)
+		})
+
+		Convey("Expired jobs should be removed with their results", func() {
+			job := m.Submit("user", "dash", func(context.Context, string, ProgressFunc, io.Writer) (string, error) {
+				return "report.pdf", nil
+			})
+
+			job = waitFinished(m, job.ID)
+
+			m.cleanup(job.ExpiresAt.Add(-time.Second))
+
+			_, err := m.Get(job.ID)
+			So(err, ShouldBeNil)
+
+			m.cleanup(job.ExpiresAt.Add(time.Second))
+
+			_, err = m.Get(job.ID)
+			So(err, ShouldEqual, ErrNotFound)
+
+			_, err = os.Stat(job.resultPath)
+			So(errors.Is(err, os.ErrNotExist), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,77 @@
+// Package metrics defines the Prometheus metrics of the plugin. Metrics are
+// registered in the default registry, which is exposed by the plugin SDK at
+// /api/plugins/mahendrapaipuri-dashboardreporter-app/metrics of Grafana.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "dashboardreporter"
+
+// Stages of report generation.
+const (
+	StageReport         = "report"
+	StagePopulatePanels = "populate_panels"
+	StagePanelPNG       = "panel_png"
+	StagePanelCSV       = "panel_csv"
+	StagePanelMetadata  = "panel_metadata"
+	StageRenderPDF      = "render_pdf"
+)
+
+var (
+	stageTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stage_total",
+		Help:      "Total number of runs of report generation stages.",
+	}, []string{"stage"})
+
+	stageErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stage_errors_total",
+		Help:      "Total number of failed runs of report generation stages.",
+	}, []string{"stage"})
+
+	stageDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "stage_duration_seconds",
+		Help:      "Duration of report generation stages in seconds.",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 12),
+	}, []string{"stage"})
+
+	// WorkerQueued is the number of tasks waiting for a worker of a pool.
+	WorkerQueued = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "worker_queued_tasks",
+		Help:      "Number of tasks waiting for a worker.",
+	}, []string{"pool"})
+
+	// WorkerBusy is the number of busy workers of a pool.
+	WorkerBusy = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "worker_busy_workers",
+		Help:      "Number of workers running a task.",
+	}, []string{"pool"})
+
+	// PanelCacheRequests is the number of lookups of panel data in cache by
+	// the kind of data and their result, which is hit, miss or bypass.
+	PanelCacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "panel_cache_requests_total",
+		Help:      "Total number of lookups of panel PNGs and CSV data in cache.",
+	}, []string{"kind", "result"})
+)
+
+// Observe records a run of stage that started at start. The run is counted
+// as failed when err is not nil.
+func Observe(stage string, start time.Time, err error) {
+	stageTotal.WithLabelValues(stage).Inc()
+	stageDuration.WithLabelValues(stage).Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		stageErrors.WithLabelValues(stage).Inc()
+	}
+}
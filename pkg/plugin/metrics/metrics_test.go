@@ -0,0 +1,26 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestObserve(t *testing.T) {
+	Convey("When observing runs of a stage", t, func() {
+		Observe(StageRenderPDF, time.Now().Add(-time.Second), nil)
+		Observe(StageRenderPDF, time.Now(), errors.New("failed"))
+
+		Convey("Runs and errors should be counted", func() {
+			So(testutil.ToFloat64(stageTotal.WithLabelValues(StageRenderPDF)), ShouldEqual, 2)
+			So(testutil.ToFloat64(stageErrors.WithLabelValues(StageRenderPDF)), ShouldEqual, 1)
+		})
+
+		Convey("Durations should be recorded", func() {
+			So(testutil.CollectAndCount(stageDuration, "dashboardreporter_stage_duration_seconds"), ShouldEqual, 1)
+		})
+	})
+}
@@ -0,0 +1,110 @@
+package plugin
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Maximum width and height of panel images in pixels.
+const maxPanelSize = 10000
+
+// panelSize returns the size of panel image from query parameters. Zero is
+// returned for unset parameters so that layout decides the size.
+func panelSize(values url.Values) (int, int, error) {
+	size := make([]int, 2)
+
+	for i, name := range []string{"width", "height"} {
+		if !values.Has(name) {
+			continue
+		}
+
+		v, err := strconv.Atoi(values.Get(name))
+		if err != nil || v <= 0 || v > maxPanelSize {
+			return 0, 0, fmt.Errorf("%s must be an integer between 1 and %d", name, maxPanelSize)
+		}
+
+		size[i] = v
+	}
+
+	return size[0], size[1], nil
+}
+
+// handlePanel handles creating a PNG image of a single panel of a given dashboard UID
+// GET /api/plugins/mahendrapaipuri-dashboardreporter-app/resources/panel.
+func (app *App) handlePanel(w http.ResponseWriter, req *http.Request) {
+	panelID := req.URL.Query().Get("panelId")
+	if panelID == "" {
+		http.Error(w, "missing panelId query parameter", http.StatusBadRequest)
+
+		return
+	}
+
+	width, height, err := panelSize(req.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	// Validate the request and check permissions in the same way as reports
+	reportReq, ok := app.newReportRequest(w, req)
+	if !ok {
+		return
+	}
+
+	ctxLogger := reportReq.logger.With("panel_id", panelID)
+
+	panel, ok := reportReq.model.Panel(panelID)
+	if !ok {
+		http.Error(w, "panel not found", http.StatusNotFound)
+
+		return
+	}
+
+	// Use the panel ID as understood by the current Grafana version
+	panel.ID = app.convertPanelIDs([]string{panel.ID})[0]
+
+	reportReq.conf.PanelWidth = width
+	reportReq.conf.PanelHeight = height
+
+	grafanaDashboard, err := app.newDashboard(ctxLogger, reportReq.conf, reportReq.appURL, reportReq.model, reportReq.authHeader)
+	if err != nil {
+		ctxLogger.Error("failed to create a new dashboard", "err", err)
+		http.Error(w, "error generating panel image", http.StatusInternalServerError)
+
+		return
+	}
+
+	image, err := grafanaDashboard.PanelPNG(req.Context(), panel)
+	if err != nil {
+		ctxLogger.Error("failed to fetch panel PNG", "err", err)
+		http.Error(w, "error generating panel image", http.StatusInternalServerError)
+
+		return
+	}
+
+	data, err := base64.StdEncoding.DecodeString(image.Image)
+	if err != nil {
+		ctxLogger.Error("failed to decode panel PNG", "err", err)
+		http.Error(w, "error generating panel image", http.StatusInternalServerError)
+
+		return
+	}
+
+	filename := fmt.Sprintf("%s - %s.png", reportReq.model.Dashboard.Title, panel.Title)
+
+	w.Header().Set("Content-Type", image.MimeType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("Content-Disposition", "inline; filename*=UTF-8''"+url.PathEscape(filename))
+
+	if _, err := w.Write(data); err != nil {
+		ctxLogger.Error("failed to write panel PNG", "err", err)
+
+		return
+	}
+
+	ctxLogger.Info("panel image generated")
+}
@@ -0,0 +1,120 @@
+package plugin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/cache"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/chrome"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPanelResource(t *testing.T) {
+	Convey("When the panel handler is called", t, func() {
+		png := []byte("\x89PNG\r\n\x1a\nfake")
+
+		var (
+			renderQuery url.Values
+			renders     int
+		)
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/dashboards/uid/abc":
+				w.Write([]byte(`{"dashboard": {"uid": "abc", "title": "Ops", "panels": [
+					{"id": 2, "type": "timeseries", "title": "CPU", "gridPos": {"h": 8, "w": 12, "x": 0, "y": 0}}
+				]}}`)) //nolint:errcheck
+			case "/render/d-solo/abc/_":
+				renderQuery = r.URL.Query()
+				renders++
+
+				w.Header().Set("Content-Type", "image/png")
+				w.Write(png) //nolint:errcheck
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		defer ts.Close()
+
+		conf := config.Config{
+			Theme:         "light",
+			Orientation:   "portrait",
+			Layout:        "simple",
+			DashboardMode: "default",
+			Token:         "token",
+		}
+		So(conf.Validate(), ShouldBeNil)
+
+		app := &App{
+			conf:           conf,
+			httpClient:     ts.Client(),
+			chromeInstance: &chrome.LocalInstance{},
+			grafanaSemVer:  "v11.4.0",
+		}
+
+		ctx := backend.WithGrafanaConfig(t.Context(), backend.NewGrafanaCfg(map[string]string{
+			backend.AppURL: ts.URL,
+		}))
+		ctx = backend.WithPluginContext(ctx, backend.PluginContext{User: &backend.User{Login: "foo"}})
+
+		get := func(query string) *httptest.ResponseRecorder {
+			req := httptest.NewRequestWithContext(ctx, http.MethodGet, "/panel?"+query, nil)
+			rec := httptest.NewRecorder()
+			app.handlePanel(rec, req)
+
+			return rec
+		}
+
+		Convey("It should return the PNG of the panel", func() {
+			rec := get("dashUid=abc&panelId=2&width=800&height=400&theme=dark&from=now-6h&to=now")
+			So(rec.Code, ShouldEqual, http.StatusOK)
+			So(rec.Header().Get("Content-Type"), ShouldEqual, "image/png")
+			So(rec.Header().Get("Content-Disposition"), ShouldContainSubstring, "Ops%20-%20CPU.png")
+			So(rec.Body.Bytes(), ShouldResemble, png)
+
+			So(renderQuery.Get("panelId"), ShouldEqual, "panel-2")
+			So(renderQuery.Get("width"), ShouldEqual, "800")
+			So(renderQuery.Get("height"), ShouldEqual, "400")
+			So(renderQuery.Get("theme"), ShouldEqual, "dark")
+		})
+
+		Convey("It should use the size of layout by default", func() {
+			rec := get("dashUid=abc&panelId=panel-2")
+			So(rec.Code, ShouldEqual, http.StatusOK)
+			So(renderQuery.Get("width"), ShouldEqual, "1000")
+			So(renderQuery.Get("height"), ShouldEqual, "500")
+		})
+
+		Convey("It should render panels with the time range of request", func() {
+			rec := get("dashUid=abc&panelId=2&from=1700000000000&to=1700003600000")
+			So(rec.Code, ShouldEqual, http.StatusOK)
+			So(renderQuery.Get("from"), ShouldEqual, "1700000000000")
+			So(renderQuery.Get("to"), ShouldEqual, "1700003600000")
+		})
+
+		Convey("It should serve panels of absolute time ranges from cache", func() {
+			app.panelCache = cache.NewMemory(1024, time.Hour)
+
+			for range 2 {
+				rec := get("dashUid=abc&panelId=2&from=1700000000000&to=1700003600000")
+				So(rec.Code, ShouldEqual, http.StatusOK)
+				So(rec.Body.Bytes(), ShouldResemble, png)
+			}
+
+			So(renders, ShouldEqual, 1)
+		})
+
+		Convey("It should reject invalid requests", func() {
+			So(get("dashUid=abc").Code, ShouldEqual, http.StatusBadRequest)
+			So(get("dashUid=abc&panelId=2&width=0").Code, ShouldEqual, http.StatusBadRequest)
+			So(get("dashUid=abc&panelId=2&height=abc").Code, ShouldEqual, http.StatusBadRequest)
+			So(get("dashUid=abc&panelId=2&theme=blue").Code, ShouldEqual, http.StatusBadRequest)
+			So(get("dashUid=abc&panelId=3").Code, ShouldEqual, http.StatusNotFound)
+		})
+	})
+}
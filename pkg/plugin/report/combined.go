@@ -0,0 +1,93 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/chrome"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/helpers"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+// Combined is a single PDF report of several dashboards with a cover page,
+// a table of contents and a section per dashboard.
+type Combined struct {
+	logger         log.Logger
+	conf           *config.Config
+	chromeInstance chrome.Instance
+	title          string
+	timeRange      dashboard.TimeRange
+	reports        []*Report
+}
+
+// NewCombined returns a new combined report of reports. Title and time range
+// are shown on the cover page while every section uses the config and time
+// range of its own report.
+func NewCombined(logger log.Logger, conf *config.Config, chromeInstance chrome.Instance,
+	title string, timeRange dashboard.TimeRange, reports []*Report,
+) *Combined {
+	return &Combined{
+		logger:         logger,
+		conf:           conf,
+		chromeInstance: chromeInstance,
+		title:          title,
+		timeRange:      timeRange,
+		reports:        reports,
+	}
+}
+
+// Generate generates the combined report and writes it to writer. When writer
+// is a http.ResponseWriter, Content-Disposition header is set as well.
+func (c *Combined) Generate(ctx context.Context, writer io.Writer) error {
+	defer helpers.TimeTrack(time.Now(), "combined report generation", c.logger)
+
+	data := combinedData{
+		templateData: templateData{
+			time.Now().Local().In(c.conf.Location).Format(c.conf.TimeFormat),
+			&dashboard.Data{Title: c.title, TimeRange: c.timeRange},
+			c.conf,
+			nil,
+		},
+		Sections: make([]section, len(c.reports)),
+	}
+
+	for i, r := range c.reports {
+		dashboardData, err := r.collect(ctx)
+		if err != nil {
+			return fmt.Errorf("dashboard %d: %w", i+1, err)
+		}
+
+		data.Sections[i] = r.newTemplateData(dashboardData).Section("section-" + strconv.Itoa(i+1))
+
+		// Keywords of combined report are the tags of all dashboards
+		for _, tag := range dashboardData.Tags {
+			if !slices.Contains(data.Dashboard.Tags, tag) {
+				data.Dashboard.Tags = append(data.Dashboard.Tags, tag)
+			}
+		}
+	}
+
+	if w, ok := writer.(http.ResponseWriter); ok {
+		header := fmt.Sprintf(`inline; filename*=UTF-8''%s.pdf`, url.PathEscape(c.title))
+		w.Header().Add("Content-Disposition", header)
+	}
+
+	htmlReport, err := generateHTML(c.conf, "combined.gohtml", data)
+	if err != nil {
+		return fmt.Errorf("failed to generate HTML file: %w", err)
+	}
+
+	if err = renderPDF(ctx, c.logger, c.conf, c.chromeInstance, data.pdfInfo(), htmlReport, writer); err != nil {
+		return fmt.Errorf("failed to render PDF: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Stages of report generation that can fail.
+const (
+	StageModel      = "model_fetch"
+	StagePermission = "permission"
+	StageDiscovery  = "panel_discovery"
+	StagePanelPNG   = "panel_png"
+	StagePanelCSV   = "panel_csv"
+	StageRenderPDF  = "render_pdf"
+)
+
+// StageError is the failure of a stage of report generation. Failures of
+// individual panels are kept in Panels.
+type StageError struct {
+	Stage  string
+	Panels []*PanelError
+	Err    error
+}
+
+// Error implements the error interface.
+func (e *StageError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// PanelError is the failure of fetching PNG or CSV data of a panel.
+type PanelError struct {
+	PanelID string
+	Title   string
+	Stage   string
+	Err     error
+}
+
+// Error implements the error interface.
+func (e *PanelError) Error() string {
+	data := "PNG"
+	if e.Stage == StagePanelCSV {
+		data = "CSV"
+	}
+
+	return fmt.Sprintf("failed to fetch %s data for panel %s: %v", data, e.PanelID, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *PanelError) Unwrap() error {
+	return e.Err
+}
+
+// newPanelsError returns the stage error of the given failures of panels. The
+// stage is the one of first failure.
+func newPanelsError(failures []*PanelError) error {
+	errs := make([]error, len(failures))
+	for i, failure := range failures {
+		errs[i] = failure
+	}
+
+	return &StageError{
+		Stage:  failures[0].Stage,
+		Panels: failures,
+		Err:    fmt.Errorf("failed to generate report: %w", errors.Join(errs...)),
+	}
+}
@@ -0,0 +1,49 @@
+package report
+
+import (
+	"cmp"
+	"io"
+)
+
+// Output formats of reports.
+const (
+	FormatPDF  = "pdf"
+	FormatXLSX = "xlsx"
+	FormatZIP  = "zip"
+	FormatHTML = "html"
+)
+
+// Content types of output formats.
+var contentTypes = map[string]string{
+	FormatPDF:  "application/pdf",
+	FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	FormatZIP:  "application/zip",
+	FormatHTML: "text/html; charset=utf-8",
+}
+
+// renderFunc writes a prepared report to writer.
+type renderFunc func(w io.Writer) error
+
+// ContentType returns the content type of the given output format.
+func ContentType(format string) string {
+	if contentType, ok := contentTypes[format]; ok {
+		return contentType
+	}
+
+	return "application/octet-stream"
+}
+
+// format returns the output format of the report.
+func (r *Report) format() string {
+	return cmp.Or(r.conf.Format, FormatPDF)
+}
+
+// ContentType returns the content type of the report.
+func (r *Report) ContentType() string {
+	return ContentType(r.format())
+}
+
+// Extension returns the file extension of the report including the dot.
+func (r *Report) Extension() string {
+	return "." + r.format()
+}
@@ -1,6 +1,7 @@
 package report
 
 import (
+	"io"
 	"slices"
 	"strconv"
 	"strings"
@@ -55,3 +56,16 @@ func selectPanels(panels []dashboard.Panel, includeIDs, excludeIDs []string, def
 
 	return renderPanels
 }
+
+// countingWriter counts the bytes written to the underlying writer.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+
+	return n, err
+}
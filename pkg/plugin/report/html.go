@@ -0,0 +1,91 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+)
+
+// Style added to standalone HTML reports. Chromium fills the elements with
+// these classes in header and footer of PDF reports and as standalone HTML
+// reports are a single page, they are filled with 1 instead.
+const standaloneStyle = `<meta name="viewport" content="width=device-width, initial-scale=1">
+<style>
+    .pageNumber::after,
+    .totalPages::after {
+        content: "1";
+    }
+
+    .report-header,
+    .report-footer {
+        display: block;
+        overflow: hidden;
+    }
+</style>
+`
+
+var (
+	// Tags wrapping HTML documents that must be removed from header and
+	// footer before folding them into the body.
+	documentTagsRegexp = regexp.MustCompile(`(?is)<!DOCTYPE[^>]*>|</?(html|head|body)\b[^>]*>`)
+
+	bodyOpenRegexp  = regexp.MustCompile(`(?is)<body\b[^>]*>`)
+	bodyCloseRegexp = regexp.MustCompile(`(?i)</body\s*>`)
+	headCloseRegexp = regexp.MustCompile(`(?i)</head\s*>`)
+)
+
+// prepareHTML collects dashboard data and returns a function that writes it
+// as a standalone HTML document.
+func (r *Report) prepareHTML(ctx context.Context) (*dashboard.Data, renderFunc, error) {
+	dashboardData, err := r.collect(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	htmlReport, err := r.generateHTMLFile(dashboardData)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate HTML file: %w", err)
+	}
+
+	return dashboardData, func(w io.Writer) error {
+		if _, err := io.WriteString(w, standaloneHTML(htmlReport)); err != nil {
+			return fmt.Errorf("failed to write HTML: %w", err)
+		}
+
+		return nil
+	}, nil
+}
+
+// standaloneHTML returns the body of HTML report with header and footer
+// folded into the page. Images and styles are already inlined in the HTML
+// files and hence, the returned document does not need any other resource.
+func standaloneHTML(htmlReport HTML) string {
+	header := `<header class="report-header">` + documentTagsRegexp.ReplaceAllString(htmlReport.Header, "") + `</header>`
+	footer := `<footer class="report-footer">` + documentTagsRegexp.ReplaceAllString(htmlReport.Footer, "") + `</footer>`
+
+	body := htmlReport.Body
+
+	// Header goes right after the opening body tag and footer right before
+	// the closing one
+	if loc := bodyOpenRegexp.FindStringIndex(body); loc != nil {
+		body = body[:loc[1]] + "\n" + header + body[loc[1]:]
+	} else {
+		body = header + body
+	}
+
+	if locs := bodyCloseRegexp.FindAllStringIndex(body, -1); len(locs) > 0 {
+		i := locs[len(locs)-1][0]
+		body = body[:i] + footer + "\n" + body[i:]
+	} else {
+		body += footer
+	}
+
+	if loc := headCloseRegexp.FindStringIndex(body); loc != nil {
+		return body[:loc[0]] + standaloneStyle + body[loc[0]:]
+	}
+
+	return standaloneStyle + body
+}
@@ -0,0 +1,80 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStandaloneHTML(t *testing.T) {
+	Convey("When generating a standalone HTML report", t, func() {
+		rep := New(
+			logger,
+			&config.Config{
+				Theme:       "light",
+				TimeFormat:  time.UnixDate,
+				Location:    time.UTC,
+				EncodedLogo: "iVBORw0KGgologo",
+			},
+			nil, nil, nil, nil,
+		)
+
+		dashData := dashboard.Data{
+			Title: "My first dashboard",
+			Panels: []dashboard.Panel{
+				{ID: "1", EncodedImage: dashboard.PanelImage{Image: "iVBORw0KGgofsdfsdfsdf", MimeType: "image/png"}},
+				{ID: "2", CSVData: [][]string{{"1", "2", "3"}, {"value1", "value2", "value3"}}},
+			},
+			Variables: "testvarvalue",
+			TimeRange: dashboard.TimeRange{
+				From: "1734194455000",
+				To:   "1734194465000",
+			},
+		}
+
+		htmlReport, err := rep.generateHTMLFile(&dashData)
+		So(err, ShouldBeNil)
+
+		s := standaloneHTML(htmlReport)
+
+		Convey("It should be a single document", func() {
+			So(strings.Count(s, "<html"), ShouldEqual, 1)
+			So(strings.Count(s, "<body"), ShouldEqual, 1)
+			So(strings.Count(s, "</body>"), ShouldEqual, 1)
+			So(s, ShouldContainSubstring, `name="viewport"`)
+		})
+
+		Convey("Header and footer should be folded into the body", func() {
+			body := s[strings.Index(s, "<body"):]
+
+			So(body, ShouldContainSubstring, `<header class="report-header">`)
+			So(body, ShouldContainSubstring, "testvarvalue")
+			So(body, ShouldContainSubstring, `<footer class="report-footer">`)
+			So(strings.Index(body, "report-header"), ShouldBeLessThan, strings.Index(body, "value1"))
+			So(strings.Index(body, "report-footer"), ShouldBeGreaterThan, strings.Index(body, "value1"))
+		})
+
+		Convey("Images should be inlined", func() {
+			So(s, ShouldContainSubstring, "data:image/png;base64,iVBORw0KGgofsdfsdfsdf")
+			So(s, ShouldContainSubstring, "data:image/png;base64,iVBORw0KGgologo")
+		})
+	})
+
+	Convey("When folding custom header and footer", t, func() {
+		s := standaloneHTML(HTML{
+			Header: "<div>custom header</div>",
+			Footer: "<!DOCTYPE html><html><body><p>custom footer</p></body></html>",
+			Body:   "<html><head><title>Report</title></head><body class=\"report\"><p>content</p></body></html>",
+		})
+
+		Convey("They should be placed around the content", func() {
+			So(s, ShouldEqual, "<html><head><title>Report</title>"+standaloneStyle+"</head><body class=\"report\">\n"+
+				"<header class=\"report-header\"><div>custom header</div></header><p>content</p>"+
+				"<footer class=\"report-footer\"><p>custom footer</p></footer>\n</body></html>")
+		})
+	})
+}
@@ -0,0 +1,841 @@
+package report
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// Chromium writes PDFs with a cross reference table and without object
+// streams. Only what is needed to read named destinations and to update
+// document information of such PDFs is implemented here. Other PDFs, like
+// the ones with cross reference streams, are rejected with errInvalidPDF and
+// malformed PDFs must fail with an error rather than panic.
+
+var (
+	startXRefRegexp = regexp.MustCompile(`startxref\s+(\d+)\s+%%EOF\s*$`)
+	xrefEntryRegexp = regexp.MustCompile(`^(\d{10}) (\d{5}) ([nf])`)
+
+	errInvalidPDF = errors.New("invalid pdf")
+)
+
+// maxPDFNesting is the maximum nesting of arrays and dictionaries of objects.
+const maxPDFNesting = 64
+
+// pdfInfo is the document information of a PDF.
+type pdfInfo struct {
+	Title    string
+	Author   string
+	Subject  string
+	Keywords []string
+}
+
+// PDF objects.
+type (
+	pdfDict  map[string]any
+	pdfArray []any
+	pdfName  string
+	pdfRef   int
+)
+
+// pdfDocument is a parsed PDF.
+type pdfDocument struct {
+	data    []byte
+	offsets map[int]int // By object number
+	trailer pdfDict
+	xref    int
+}
+
+// parsePDF reads the cross reference tables and trailer of PDF data.
+func parsePDF(data []byte) (*pdfDocument, error) {
+	m := startXRefRegexp.FindSubmatch(data)
+	if m == nil {
+		return nil, fmt.Errorf("%w: startxref not found", errInvalidPDF)
+	}
+
+	xref, _ := strconv.Atoi(string(m[1]))
+
+	doc := &pdfDocument{data: data, offsets: make(map[int]int), xref: xref}
+
+	// Follow the chain of cross reference tables of incremental updates.
+	// Entries of newer tables take precedence
+	for offset, visited := xref, 0; ; visited++ {
+		if visited > 100 {
+			return nil, fmt.Errorf("%w: too many cross reference tables", errInvalidPDF)
+		}
+
+		trailer, err := doc.readXRef(offset)
+		if err != nil {
+			return nil, err
+		}
+
+		if doc.trailer == nil {
+			doc.trailer = trailer
+		}
+
+		prev, ok := trailer["Prev"].(float64)
+		if !ok {
+			break
+		}
+
+		offset = int(prev)
+	}
+
+	return doc, nil
+}
+
+// readXRef reads the cross reference table at offset and returns its trailer.
+func (d *pdfDocument) readXRef(offset int) (pdfDict, error) {
+	if offset < 0 || offset >= len(d.data) || !bytes.HasPrefix(d.data[offset:], []byte("xref")) {
+		return nil, fmt.Errorf("%w: cross reference table not found at %d", errInvalidPDF, offset)
+	}
+
+	p := &pdfParser{data: d.data, pos: offset + len("xref")}
+
+	for {
+		p.skipSpaces()
+
+		if bytes.HasPrefix(d.data[p.pos:], []byte("trailer")) {
+			p.pos += len("trailer")
+
+			trailer, err := p.value()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read trailer: %w", err)
+			}
+
+			dict, ok := trailer.(pdfDict)
+			if !ok {
+				return nil, fmt.Errorf("%w: trailer is not a dictionary", errInvalidPDF)
+			}
+
+			return dict, nil
+		}
+
+		// Subsection header is the first object number and number of entries
+		first, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+
+		count, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok1 := first.(float64)
+		n, ok2 := count.(float64)
+
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("%w: invalid cross reference subsection", errInvalidPDF)
+		}
+
+		for i := range int(n) {
+			p.skipSpaces()
+
+			m := xrefEntryRegexp.FindSubmatch(d.data[p.pos:])
+			if m == nil {
+				return nil, fmt.Errorf("%w: invalid cross reference entry", errInvalidPDF)
+			}
+
+			p.pos += len(m[0])
+
+			num := int(start) + i
+			if _, ok := d.offsets[num]; !ok && string(m[3]) == "n" {
+				d.offsets[num], _ = strconv.Atoi(string(m[1]))
+			}
+		}
+	}
+}
+
+// object returns v or, when v is a reference, the object it refers to.
+func (d *pdfDocument) object(v any) (any, error) {
+	for range 32 {
+		ref, ok := v.(pdfRef)
+		if !ok {
+			return v, nil
+		}
+
+		offset, ok := d.offsets[int(ref)]
+		if !ok || offset < 0 || offset >= len(d.data) {
+			return nil, fmt.Errorf("%w: object %d not found", errInvalidPDF, ref)
+		}
+
+		p := &pdfParser{data: d.data, pos: offset}
+
+		// Skip object header "num gen obj"
+		for range 3 {
+			p.skipSpaces()
+			p.token()
+		}
+
+		var err error
+		if v, err = p.value(); err != nil {
+			return nil, fmt.Errorf("failed to read object %d: %w", ref, err)
+		}
+	}
+
+	return nil, fmt.Errorf("%w: too many indirect references", errInvalidPDF)
+}
+
+// dict returns the dictionary v or the one it refers to.
+func (d *pdfDocument) dict(v any) (pdfDict, error) {
+	v, err := d.object(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dict, ok := v.(pdfDict)
+	if !ok {
+		return nil, fmt.Errorf("%w: object is not a dictionary", errInvalidPDF)
+	}
+
+	return dict, nil
+}
+
+// pages returns the object numbers of pages in the order of document.
+func (d *pdfDocument) pages() ([]pdfRef, error) {
+	catalog, err := d.dict(d.trailer["Root"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog: %w", err)
+	}
+
+	var (
+		pages   []pdfRef
+		visited = make(map[pdfRef]bool)
+		walk    func(node any, depth int) error
+	)
+
+	walk = func(node any, depth int) error {
+		if depth > 32 {
+			return fmt.Errorf("%w: page tree is too deep", errInvalidPDF)
+		}
+
+		// Nodes of a valid page tree are visited once
+		if ref, ok := node.(pdfRef); ok {
+			if visited[ref] {
+				return fmt.Errorf("%w: page tree node %d is visited twice", errInvalidPDF, ref)
+			}
+
+			visited[ref] = true
+		}
+
+		dict, err := d.dict(node)
+		if err != nil {
+			return err
+		}
+
+		if dict["Type"] != pdfName("Pages") {
+			if ref, ok := node.(pdfRef); ok {
+				pages = append(pages, ref)
+			}
+
+			return nil
+		}
+
+		kids, _ := d.object(dict["Kids"])
+		for _, kid := range asArray(kids) {
+			if err := walk(kid, depth+1); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if err := walk(catalog["Pages"], 0); err != nil {
+		return nil, fmt.Errorf("failed to read pages: %w", err)
+	}
+
+	return pages, nil
+}
+
+// destinations returns the page numbers, starting from 1, of the named
+// destinations of document. Named destinations are read from both the
+// dictionary of destinations and the name tree of catalog.
+func (d *pdfDocument) destinations() (map[string]int, error) {
+	pages, err := d.pages()
+	if err != nil {
+		return nil, err
+	}
+
+	pageNumbers := make(map[pdfRef]int, len(pages))
+	for i, ref := range pages {
+		pageNumbers[ref] = i + 1
+	}
+
+	catalog, err := d.dict(d.trailer["Root"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog: %w", err)
+	}
+
+	destinations := make(map[string]int)
+
+	add := func(name string, dest any) {
+		dest, err := d.object(dest)
+		if err != nil {
+			return
+		}
+
+		// Destination is either an array or a dictionary with the array
+		if dict, ok := dest.(pdfDict); ok {
+			if dest, err = d.object(dict["D"]); err != nil {
+				return
+			}
+		}
+
+		if array := asArray(dest); len(array) > 0 {
+			if ref, ok := array[0].(pdfRef); ok && pageNumbers[ref] > 0 {
+				destinations[name] = pageNumbers[ref]
+			}
+		}
+	}
+
+	if dests, err := d.dict(catalog["Dests"]); err == nil {
+		for name, dest := range dests {
+			add(name, dest)
+		}
+	}
+
+	if names, err := d.dict(catalog["Names"]); err == nil {
+		if err := d.walkNameTree(names["Dests"], add, 0, make(map[pdfRef]bool)); err != nil {
+			return nil, fmt.Errorf("failed to read named destinations: %w", err)
+		}
+	}
+
+	return destinations, nil
+}
+
+// walkNameTree calls f for every name and value of the name tree of node.
+// Nodes that are already visited are skipped.
+func (d *pdfDocument) walkNameTree(node any, f func(string, any), depth int, visited map[pdfRef]bool) error {
+	if node == nil {
+		return nil
+	}
+
+	if depth > 32 {
+		return fmt.Errorf("%w: name tree is too deep", errInvalidPDF)
+	}
+
+	if ref, ok := node.(pdfRef); ok {
+		if visited[ref] {
+			return nil
+		}
+
+		visited[ref] = true
+	}
+
+	dict, err := d.dict(node)
+	if err != nil {
+		return err
+	}
+
+	names, _ := d.object(dict["Names"])
+	array := asArray(names)
+
+	for i := 0; i+1 < len(array); i += 2 {
+		if name, ok := array[i].(string); ok {
+			f(decodePDFText(name), array[i+1])
+		}
+	}
+
+	kids, _ := d.object(dict["Kids"])
+	for _, kid := range asArray(kids) {
+		if err := d.walkNameTree(kid, f, depth+1, visited); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pdfDestinations returns the page numbers, starting from 1, of the named
+// destinations of PDF data. Chromium creates named destinations for the
+// elements that are targets of links in the document.
+func pdfDestinations(data []byte) (map[string]int, error) {
+	doc, err := parsePDF(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return doc.destinations()
+}
+
+// setPDFInfo returns PDF data with an incremental update that sets the
+// document information to info. Other entries of the existing document
+// information, like producer, are kept.
+func setPDFInfo(data []byte, info pdfInfo, now time.Time) ([]byte, error) {
+	doc, err := parsePDF(data)
+	if err != nil {
+		return nil, err
+	}
+
+	size, ok := doc.trailer["Size"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("%w: size of trailer not found", errInvalidPDF)
+	}
+
+	root, ok := doc.trailer["Root"].(pdfRef)
+	if !ok {
+		return nil, fmt.Errorf("%w: root of trailer not found", errInvalidPDF)
+	}
+
+	dict := pdfDict{}
+
+	if oldInfo, err := doc.dict(doc.trailer["Info"]); err == nil {
+		for name, value := range oldInfo {
+			dict[name] = value
+		}
+	}
+
+	for name, value := range map[string]string{
+		"Title":    info.Title,
+		"Author":   info.Author,
+		"Subject":  info.Subject,
+		"Keywords": strings.Join(info.Keywords, ", "),
+	} {
+		if value != "" {
+			dict[name] = encodePDFText(value)
+		}
+	}
+
+	dict["ModDate"] = now.UTC().Format("D:20060102150405Z")
+
+	num := int(size)
+
+	var buf bytes.Buffer
+
+	buf.Write(data)
+
+	if !bytes.HasSuffix(data, []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+
+	offset := buf.Len()
+
+	fmt.Fprintf(&buf, "%d 0 obj\n", num)
+	writePDFValue(&buf, dict)
+	buf.WriteString("\nendobj\n")
+
+	xref := buf.Len()
+
+	fmt.Fprintf(&buf, "xref\n0 1\n0000000000 65535 f \n%d 1\n%010d 00000 n \n", num, offset)
+
+	trailer := pdfDict{
+		"Size": float64(num + 1),
+		"Root": root,
+		"Info": pdfRef(num),
+		"Prev": float64(doc.xref),
+	}
+
+	if id, ok := doc.trailer["ID"]; ok {
+		trailer["ID"] = id
+	}
+
+	buf.WriteString("trailer\n")
+	writePDFValue(&buf, trailer)
+	fmt.Fprintf(&buf, "\nstartxref\n%d\n%%%%EOF\n", xref)
+
+	return buf.Bytes(), nil
+}
+
+// asArray returns v as an array or nil if it is not one.
+func asArray(v any) pdfArray {
+	array, _ := v.(pdfArray)
+
+	return array
+}
+
+// encodePDFText encodes s as a PDF text string. Non ASCII text is encoded
+// in UTF-16 with a byte order mark.
+func encodePDFText(s string) string {
+	ascii := true
+
+	for _, r := range s {
+		if r > 0x7e || r < 0x20 {
+			ascii = false
+
+			break
+		}
+	}
+
+	if ascii {
+		return s
+	}
+
+	var b strings.Builder
+
+	b.WriteString("\xfe\xff")
+
+	for _, u := range utf16.Encode([]rune(s)) {
+		b.WriteByte(byte(u >> 8))
+		b.WriteByte(byte(u))
+	}
+
+	return b.String()
+}
+
+// decodePDFText decodes a PDF text string.
+func decodePDFText(s string) string {
+	if !strings.HasPrefix(s, "\xfe\xff") {
+		return s
+	}
+
+	s = s[2:]
+	units := make([]uint16, 0, len(s)/2)
+
+	for i := 0; i+1 < len(s); i += 2 {
+		units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+	}
+
+	return string(utf16.Decode(units))
+}
+
+// writePDFValue writes v in PDF syntax. Strings are written in hexadecimal
+// so that they do not need escaping.
+func writePDFValue(buf *bytes.Buffer, v any) {
+	switch v := v.(type) {
+	case pdfDict:
+		buf.WriteString("<<")
+
+		for _, name := range slices.Sorted(maps.Keys(v)) {
+			writePDFValue(buf, pdfName(name))
+			buf.WriteByte(' ')
+			writePDFValue(buf, v[name])
+			buf.WriteByte('\n')
+		}
+
+		buf.WriteString(">>")
+	case pdfArray:
+		buf.WriteByte('[')
+
+		for i, value := range v {
+			if i > 0 {
+				buf.WriteByte(' ')
+			}
+
+			writePDFValue(buf, value)
+		}
+
+		buf.WriteByte(']')
+	case pdfName:
+		buf.WriteByte('/')
+
+		for _, c := range []byte(v) {
+			if c <= ' ' || c > '~' || strings.IndexByte("#/()<>[]{}%", c) >= 0 {
+				fmt.Fprintf(buf, "#%02X", c)
+			} else {
+				buf.WriteByte(c)
+			}
+		}
+	case pdfRef:
+		fmt.Fprintf(buf, "%d 0 R", v)
+	case string:
+		fmt.Fprintf(buf, "<%X>", v)
+	case float64:
+		buf.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	default:
+		buf.WriteString("null")
+	}
+}
+
+// pdfParser parses PDF objects from data.
+type pdfParser struct {
+	data []byte
+	pos  int
+
+	// nesting is the number of arrays and dictionaries being parsed
+	nesting int
+}
+
+// skipSpaces skips white spaces and comments.
+func (p *pdfParser) skipSpaces() {
+	for p.pos < len(p.data) {
+		switch c := p.data[p.pos]; {
+		case c == '%':
+			for p.pos < len(p.data) && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' {
+				p.pos++
+			}
+		case isPDFSpace(c):
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// token returns the next regular token, like a number or a keyword.
+func (p *pdfParser) token() string {
+	start := p.pos
+
+	for p.pos < len(p.data) && !isPDFSpace(p.data[p.pos]) && !isPDFDelimiter(p.data[p.pos]) {
+		p.pos++
+	}
+
+	return string(p.data[start:p.pos])
+}
+
+// value parses the next object.
+func (p *pdfParser) value() (any, error) {
+	p.skipSpaces()
+
+	if p.pos >= len(p.data) {
+		return nil, fmt.Errorf("%w: unexpected end of data", errInvalidPDF)
+	}
+
+	switch c := p.data[p.pos]; c {
+	case '<':
+		if p.pos+1 < len(p.data) && p.data[p.pos+1] == '<' {
+			return p.nested(func() (any, error) { return p.dict() })
+		}
+
+		return p.hexString()
+	case '[':
+		return p.nested(func() (any, error) { return p.array() })
+	case '(':
+		return p.literalString()
+	case '/':
+		return p.name(), nil
+	}
+
+	token := p.token()
+
+	switch token {
+	case "true", "false":
+		return token == "true", nil
+	case "null":
+		return nil, nil
+	case "":
+		return nil, fmt.Errorf("%w: unexpected character %q", errInvalidPDF, p.data[p.pos])
+	}
+
+	num, err := strconv.ParseFloat(token, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unexpected token %q", errInvalidPDF, token)
+	}
+
+	// Integers followed by a generation number and R are references
+	pos := p.pos
+
+	p.skipSpaces()
+
+	if gen := p.token(); gen != "" {
+		if _, err := strconv.Atoi(gen); err == nil {
+			p.skipSpaces()
+
+			if p.token() == "R" {
+				return pdfRef(int(num)), nil
+			}
+		}
+	}
+
+	p.pos = pos
+
+	return num, nil
+}
+
+// nested parses an array or a dictionary with parse and limits their nesting.
+func (p *pdfParser) nested(parse func() (any, error)) (any, error) {
+	if p.nesting >= maxPDFNesting {
+		return nil, fmt.Errorf("%w: objects are nested too deeply", errInvalidPDF)
+	}
+
+	p.nesting++
+	defer func() { p.nesting-- }()
+
+	return parse()
+}
+
+// dict parses a dictionary.
+func (p *pdfParser) dict() (pdfDict, error) {
+	p.pos += 2
+	dict := pdfDict{}
+
+	for {
+		p.skipSpaces()
+
+		if bytes.HasPrefix(p.data[p.pos:], []byte(">>")) {
+			p.pos += 2
+
+			return dict, nil
+		}
+
+		if p.pos >= len(p.data) || p.data[p.pos] != '/' {
+			return nil, fmt.Errorf("%w: dictionary key is not a name", errInvalidPDF)
+		}
+
+		name := p.name()
+
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+
+		dict[string(name)] = value
+	}
+}
+
+// array parses an array.
+func (p *pdfParser) array() (pdfArray, error) {
+	p.pos++
+
+	var array pdfArray
+
+	for {
+		p.skipSpaces()
+
+		if p.pos < len(p.data) && p.data[p.pos] == ']' {
+			p.pos++
+
+			return array, nil
+		}
+
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+
+		array = append(array, value)
+	}
+}
+
+// name parses a name and decodes its escaped characters.
+func (p *pdfParser) name() pdfName {
+	p.pos++
+	raw := p.token()
+
+	var b strings.Builder
+
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '#' && i+2 < len(raw) {
+			if c, err := strconv.ParseUint(raw[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(c))
+
+				i += 2
+
+				continue
+			}
+		}
+
+		b.WriteByte(raw[i])
+	}
+
+	return pdfName(b.String())
+}
+
+// hexString parses a hexadecimal string.
+func (p *pdfParser) hexString() (string, error) {
+	end := bytes.IndexByte(p.data[p.pos:], '>')
+	if end < 0 {
+		return "", fmt.Errorf("%w: unterminated hexadecimal string", errInvalidPDF)
+	}
+
+	var digits []byte
+
+	for _, c := range p.data[p.pos+1 : p.pos+end] {
+		if !isPDFSpace(c) {
+			digits = append(digits, c)
+		}
+	}
+
+	p.pos += end + 1
+
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+
+	s := make([]byte, len(digits)/2)
+
+	for i := range s {
+		c, err := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+		if err != nil {
+			return "", fmt.Errorf("%w: invalid hexadecimal string", errInvalidPDF)
+		}
+
+		s[i] = byte(c)
+	}
+
+	return string(s), nil
+}
+
+// literalString parses a literal string with balanced parentheses and
+// escape sequences.
+func (p *pdfParser) literalString() (string, error) {
+	p.pos++
+
+	var b strings.Builder
+
+	for depth := 0; p.pos < len(p.data); p.pos++ {
+		c := p.data[p.pos]
+
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				p.pos++
+
+				return b.String(), nil
+			}
+
+			depth--
+		case '\\':
+			p.pos++
+			if p.pos >= len(p.data) {
+				break
+			}
+
+			switch e := p.data[p.pos]; e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r', '\n':
+				// Line continuation
+				continue
+			default:
+				if e < '0' || e > '7' {
+					c = e
+
+					break
+				}
+
+				// Octal character code of up to 3 digits
+				n := 0
+				for i := 0; i < 3 && p.pos < len(p.data) && p.data[p.pos] >= '0' && p.data[p.pos] <= '7'; i++ {
+					n = n*8 + int(p.data[p.pos]-'0')
+					p.pos++
+				}
+
+				p.pos--
+				c = byte(n)
+			}
+		}
+
+		b.WriteByte(c)
+	}
+
+	return "", fmt.Errorf("%w: unterminated literal string", errInvalidPDF)
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
@@ -0,0 +1,180 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// newTestPDF returns a PDF with the given objects, numbered from 1, and a
+// cross reference table like the ones written by Chromium.
+func newTestPDF(objects ...string) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := buf.Len()
+
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+
+	fmt.Fprintf(&buf, "trailer\n<</Size %d\n/Root 7 0 R\n/Info 1 0 R>>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return buf.Bytes()
+}
+
+func TestPDF(t *testing.T) {
+	Convey("When reading a PDF with named destinations", t, func() {
+		pdf := newTestPDF(
+			`<</Creator (Chromium)
+/Producer (Skia/PDF m131)>>`,
+			`<</Type /Page
+/Parent 5 0 R>>`,
+			`<</Type /Page
+/Parent 5 0 R>>`,
+			`<</Type /Page
+/Parent 6 0 R>>`,
+			`<</Type /Pages
+/Count 2
+/Kids [2 0 R 3 0 R]
+/Parent 6 0 R>>`,
+			`<</Type /Pages
+/Count 3
+/Kids [5 0 R 4 0 R]>>`,
+			`<</Type /Catalog
+/Pages 6 0 R
+/Dests <</panel-row-0 [3 0 R /XYZ 0 792 0]
+/panel-image#2Dpanel-2 [4 0 R /XYZ 0 400 0]>>
+/Names <</Dests 8 0 R>>>>`,
+			`<</Names [(panel-image\(1\)) <</D [2 0 R /XYZ 0 792 0]>> <FEFF0072006F0077> [4 0 R /Fit]]>>`,
+		)
+
+		Convey("Page numbers of destinations should be returned", func() {
+			destinations, err := pdfDestinations(pdf)
+			So(err, ShouldBeNil)
+			So(destinations, ShouldResemble, map[string]int{
+				"panel-row-0":         2,
+				"panel-image-panel-2": 3,
+				"panel-image(1)":      1,
+				"row":                 3,
+			})
+		})
+
+		Convey("Document information should be updated", func() {
+			now := time.Date(2024, 12, 14, 10, 0, 0, 0, time.UTC)
+
+			updated, err := setPDFInfo(pdf, pdfInfo{
+				Title:    "Überblick",
+				Author:   "admin",
+				Keywords: []string{"linux", "servers"},
+			}, now)
+			So(err, ShouldBeNil)
+			So(bytes.HasPrefix(updated, pdf), ShouldBeTrue)
+
+			doc, err := parsePDF(updated)
+			So(err, ShouldBeNil)
+
+			info, err := doc.dict(doc.trailer["Info"])
+			So(err, ShouldBeNil)
+			So(decodePDFText(info["Title"].(string)), ShouldEqual, "Überblick")
+			So(info["Author"], ShouldEqual, "admin")
+			So(info["Keywords"], ShouldEqual, "linux, servers")
+			So(info["Producer"], ShouldEqual, "Skia/PDF m131")
+			So(info["ModDate"], ShouldEqual, "D:20241214100000Z")
+			So(info, ShouldNotContainKey, "Subject")
+
+			// Other objects are still readable through the previous table
+			destinations, err := doc.destinations()
+			So(err, ShouldBeNil)
+			So(destinations, ShouldHaveLength, 4)
+		})
+	})
+
+	Convey("When reading a PDF rendered by Chromium", t, func() {
+		pdf, err := os.ReadFile("../../../docs/reports/report_portrait_simple_full_table.pdf")
+		So(err, ShouldBeNil)
+
+		doc, err := parsePDF(pdf)
+		So(err, ShouldBeNil)
+
+		Convey("All the pages should be found", func() {
+			pages, err := doc.pages()
+			So(err, ShouldBeNil)
+			So(pages, ShouldHaveLength, 11)
+		})
+
+		Convey("Document information should be updated", func() {
+			updated, err := setPDFInfo(pdf, pdfInfo{Title: "Report"}, time.Now())
+			So(err, ShouldBeNil)
+
+			doc, err := parsePDF(updated)
+			So(err, ShouldBeNil)
+
+			info, err := doc.dict(doc.trailer["Info"])
+			So(err, ShouldBeNil)
+			So(info["Title"], ShouldEqual, "Report")
+			So(info["Creator"], ShouldContainSubstring, "(X11; Linux x86_64)")
+		})
+	})
+
+	Convey("When reading invalid PDFs", t, func() {
+		_, err := pdfDestinations([]byte("%PDF-1.4\n"))
+		So(err, ShouldWrap, errInvalidPDF)
+
+		Convey("Page trees with cycles should be rejected", func() {
+			_, err := pdfDestinations(newTestPDF(`<<>>`, `<</Type /Pages /Kids [2 0 R]>>`, `<<>>`, `<<>>`, `<<>>`, `<<>>`,
+				`<</Type /Catalog /Pages 2 0 R>>`))
+			So(err, ShouldWrap, errInvalidPDF)
+		})
+
+		Convey("Objects nested too deeply should be rejected", func() {
+			p := &pdfParser{data: bytes.Repeat([]byte("["), 1000)}
+			_, err := p.value()
+			So(err, ShouldWrap, errInvalidPDF)
+		})
+	})
+}
+
+func FuzzParsePDF(f *testing.F) {
+	f.Add(newTestPDF(`<</Creator (Chromium)>>`, `<</Type /Pages /Count 0 /Kids []>>`,
+		`<</Type /Catalog /Pages 2 0 R /Dests <</a [1 0 R /Fit]>>>>`))
+	f.Add([]byte("%PDF-1.4\nxref\n0 1\n0000000000 65535 f \ntrailer\n<</Size 1 /Prev 9>>\nstartxref\n9\n%%EOF\n"))
+	f.Add([]byte("%PDF-1.4\nstartxref\n-1\n%%EOF\n"))
+
+	f.Fuzz(func(_ *testing.T, data []byte) {
+		// Errors are expected. Malformed PDFs must not panic or hang
+		if doc, err := parsePDF(data); err == nil {
+			_, _ = doc.destinations()
+		}
+
+		_, _ = setPDFInfo(data, pdfInfo{Title: "Report"}, time.Now())
+	})
+}
+
+func FuzzPDFValue(f *testing.F) {
+	for _, seed := range []string{
+		`<</A [1 0 R (a\(b\)) <FEFF0041> /N#41 -1.5 true null]>>`,
+		`(unbalanced`, `<0`, `<<`, `[`, `/`, `(\`, `%comment`,
+	} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(_ *testing.T, data []byte) {
+		p := &pdfParser{data: data}
+		_, _ = p.value()
+	})
+}
@@ -13,14 +13,18 @@ import (
 	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
+	texttemplate "text/template"
 	"time"
 
 	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/chrome"
 	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
 	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
 	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/helpers"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/metrics"
 	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/worker"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Embed the entire directory.
@@ -42,55 +46,155 @@ func New(logger log.Logger, conf *config.Config, httpClient *http.Client, chrome
 	pools worker.Pools, dashboard *dashboard.Dashboard,
 ) *Report {
 	return &Report{
-		logger,
-		conf,
-		httpClient,
-		chromeInstance,
-		pools,
-		dashboard,
+		logger:         logger,
+		conf:           conf,
+		httpClient:     httpClient,
+		chromeInstance: chromeInstance,
+		pools:          pools,
+		dashboard:      dashboard,
 	}
 }
 
-func (r *Report) Generate(ctx context.Context, writer http.ResponseWriter) error {
-	defer helpers.TimeTrack(time.Now(), "report generation", r.logger)
+// Size returns the size of the generated report in bytes.
+func (r *Report) Size() int64 {
+	return r.size
+}
 
-	// Get panel data from dashboard
-	dashboardData, err := r.dashboard.GetData(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get dashboard data: %w", err)
+// OnProgress sets a function that will be called with the number of done and
+// total panels every time a panel is populated.
+func (r *Report) OnProgress(f func(done, total int)) {
+	r.progress = f
+}
+
+// reportProgress reports the progress of panels population, if requested.
+func (r *Report) reportProgress(done, total int) {
+	if r.progress != nil {
+		r.progress(done, total)
 	}
+}
 
-	// Populate panels with PNG and tabular data
-	if err := r.populatePanels(ctx, dashboardData); err != nil {
-		return fmt.Errorf("failed to populate panels: %w", err)
+// Generate generates the report in the format of config and writes it to
+// writer. When writer is a http.ResponseWriter, Content-Type and
+// Content-Disposition headers are set as well.
+func (r *Report) Generate(ctx context.Context, writer io.Writer) (err error) {
+	defer helpers.TimeTrack(time.Now(), "report generation", r.logger)
+	defer func(start time.Time) { metrics.Observe(metrics.StageReport, start, err) }(time.Now())
+
+	var (
+		dashboardData *dashboard.Data
+		render        renderFunc
+	)
+
+	switch r.format() {
+	case FormatXLSX:
+		dashboardData, render, err = r.prepareXLSX(ctx)
+	case FormatZIP:
+		dashboardData, render, err = r.prepareZIP(ctx)
+	case FormatHTML:
+		dashboardData, render, err = r.prepareHTML(ctx)
+	default:
+		dashboardData, render, err = r.preparePDF(ctx)
 	}
 
-	// panelTables = slices.DeleteFunc(panelTables, func(panelTable dashboard.PanelTable) bool {
-	// 	return panelTable.Data == nil
-	// })
+	if err != nil {
+		return err
+	}
 
 	// Sanitize title to escape non ASCII characters
 	// Ref: https://stackoverflow.com/questions/62705546/unicode-characters-in-attachment-name
 	// Ref: https://medium.com/@JeremyLaine/non-ascii-content-disposition-header-in-django-3a20acc05f0d
-	filename := url.PathEscape(dashboardData.Title)
-	header := fmt.Sprintf(`inline; filename*=UTF-8''%s.pdf`, filename)
-	writer.Header().Add("Content-Disposition", header)
+	if w, ok := writer.(http.ResponseWriter); ok {
+		filename := url.PathEscape(dashboardData.Title + r.Extension())
+		header := "inline; filename*=UTF-8''" + filename
+		w.Header().Set("Content-Type", r.ContentType())
+		w.Header().Add("Content-Disposition", header)
+	}
 
-	htmlReport, err := r.generateHTMLFile(dashboardData)
-	if err != nil {
-		return fmt.Errorf("failed to generate HTML file: %w", err)
+	out := writer
+
+	// Keep a copy of the report for sinks
+	var buf bytes.Buffer
+	if len(r.sinks) > 0 {
+		writer = io.MultiWriter(writer, &buf)
 	}
 
-	if err = r.renderPDF(htmlReport, writer); err != nil {
-		return fmt.Errorf("failed to render PDF: %w", err)
+	counter := &countingWriter{w: writer}
+	writer = counter
+
+	defer func() { r.size = counter.n }()
+
+	if err = render(writer); err != nil {
+		return err
+	}
+
+	// Report has already been written, so failures of sinks do not fail the
+	// report. They are only logged and kept for webhook events
+	if len(r.sinks) > 0 {
+		// Send the report to client before uploading it
+		if f, ok := out.(http.Flusher); ok {
+			f.Flush()
+		}
+
+		if err := r.storeInSinks(context.WithoutCancel(ctx), buf.Bytes()); err != nil {
+			r.logger.Error("failed to store report in sinks", "err", err)
+
+			r.sinkErr = fmt.Errorf("failed to store report: %w", err)
+		}
 	}
 
 	return nil
 }
 
+// SinkError returns the error of storing the generated report in sinks, if
+// any.
+func (r *Report) SinkError() error {
+	return r.sinkErr
+}
+
+// preparePDF collects dashboard data and returns a function that renders it
+// into PDF.
+func (r *Report) preparePDF(ctx context.Context) (*dashboard.Data, renderFunc, error) {
+	dashboardData, err := r.collect(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	htmlReport, err := r.generatePDFHTMLFile(ctx, dashboardData)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate HTML file: %w", err)
+	}
+
+	return dashboardData, func(w io.Writer) error {
+		if err := r.renderPDF(ctx, dashboardData, htmlReport, w); err != nil {
+			return fmt.Errorf("failed to render PDF: %w", err)
+		}
+
+		return nil
+	}, nil
+}
+
+// collect fetches dashboard data and populates its panels.
+func (r *Report) collect(ctx context.Context) (*dashboard.Data, error) {
+	// Get panel data from dashboard
+	dashboardData, err := r.dashboard.GetData(ctx)
+	if err != nil {
+		return nil, &StageError{Stage: StageDiscovery, Err: fmt.Errorf("failed to get dashboard data: %w", err)}
+	}
+
+	// Populate panels with PNG and tabular data
+	if err := r.populatePanels(ctx, dashboardData); err != nil {
+		return nil, fmt.Errorf("failed to populate panels: %w", err)
+	}
+
+	r.data = dashboardData
+
+	return dashboardData, nil
+}
+
 // populatePanels populates the panels with PNG and tabular data.
-func (r *Report) populatePanels(ctx context.Context, dashboardData *dashboard.Data) error {
+func (r *Report) populatePanels(ctx context.Context, dashboardData *dashboard.Data) (err error) {
 	defer helpers.TimeTrack(time.Now(), "panel PNGs and/or data generation", r.logger)
+	defer func(start time.Time) { metrics.Observe(metrics.StagePopulatePanels, start, err) }(time.Now())
 
 	// Get the indexes of PNG panels that need to be included in the report
 	pngPanels := selectPanels(dashboardData.Panels, r.conf.IncludePanelIDs, r.conf.ExcludePanelIDs, true)
@@ -98,7 +202,25 @@ func (r *Report) populatePanels(ctx context.Context, dashboardData *dashboard.Da
 	// Get the indexes of table panels that need to be included in the report
 	tablePanels := selectPanels(dashboardData.Panels, r.conf.IncludePanelDataIDs, nil, false)
 
-	errorCh := make(chan error, len(pngPanels)+len(tablePanels))
+	// Every panel has a slot for the failures of its PNG and CSV data
+	failures := make([]*PanelError, 2*len(dashboardData.Panels))
+
+	// Track the progress of panels population
+	var done atomic.Int64
+
+	// Admit all panels of report at once so that a busy server rejects the
+	// report before any panel is fetched
+	ctx, release, err := r.pools.Reserve(ctx, map[string]int{
+		worker.Renderer: len(pngPanels),
+		worker.Browser:  len(tablePanels),
+	})
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	total := len(pngPanels) + len(tablePanels)
+	r.reportProgress(0, total)
 
 	wg := sync.WaitGroup{}
 
@@ -106,52 +228,161 @@ func (r *Report) populatePanels(ctx context.Context, dashboardData *dashboard.Da
 		if slices.Contains(pngPanels, idx) {
 			wg.Add(1)
 
-			r.pools[worker.Renderer].Do(func() {
+			if err := r.pools[worker.Renderer].Do(ctx, func() {
 				defer wg.Done()
 
 				panelPNG, err := r.dashboard.PanelPNG(ctx, panel)
 				if err != nil {
-					errorCh <- fmt.Errorf("failed to fetch PNG data for panel %s: %w", panel.ID, err)
+					failures[2*idx] = &PanelError{PanelID: panel.ID, Title: panel.Title, Stage: StagePanelPNG, Err: err}
 				}
 
 				dashboardData.Panels[idx].EncodedImage = panelPNG
-			})
+
+				r.reportProgress(int(done.Add(1)), total)
+			}); err != nil {
+				// Request has been cancelled before panel could be queued
+				wg.Done()
+
+				failures[2*idx] = &PanelError{PanelID: panel.ID, Title: panel.Title, Stage: StagePanelPNG, Err: err}
+			}
 		}
 
 		if slices.Contains(tablePanels, idx) {
 			wg.Add(1)
 
-			r.pools[worker.Browser].Do(func() {
+			if err := r.pools[worker.Browser].Do(ctx, func() {
 				defer wg.Done()
 
 				panelData, err := r.dashboard.PanelCSV(ctx, panel)
 				if err != nil {
-					errorCh <- fmt.Errorf("failed to fetch CSV data for panel %s: %w", panel.ID, err)
+					failures[2*idx+1] = &PanelError{PanelID: panel.ID, Title: panel.Title, Stage: StagePanelCSV, Err: err}
 				}
 
 				dashboardData.Panels[idx].CSVData = panelData
-			})
+
+				r.reportProgress(int(done.Add(1)), total)
+			}); err != nil {
+				// Request has been cancelled before panel could be queued
+				wg.Done()
+
+				failures[2*idx+1] = &PanelError{PanelID: panel.ID, Title: panel.Title, Stage: StagePanelCSV, Err: err}
+			}
 		}
 	}
 
 	wg.Wait()
-	close(errorCh)
 
-	errs := make([]error, 0, len(pngPanels)+len(tablePanels))
+	// Count every panel once even when both its PNG and CSV data are fetched
+	panels := len(pngPanels)
+
+	for _, idx := range tablePanels {
+		if !slices.Contains(pngPanels, idx) {
+			panels++
+		}
+	}
 
-	for err := range errorCh {
-		errs = append(errs, err)
+	if r.tolerateFailures(ctx, dashboardData, failures, panels) {
+		return nil
 	}
 
-	if len(errs) > 0 {
-		return fmt.Errorf("failed to generate report: %w", errors.Join(errs...))
+	failures = slices.DeleteFunc(failures, func(failure *PanelError) bool { return failure == nil })
+	if len(failures) > 0 {
+		return newPanelsError(failures)
 	}
 
 	return nil
 }
 
+// tolerateFailures returns true when partial reports are enabled and at most
+// the configured fraction of panels failed. Failed panels of dashboardData are
+// then marked with the reasons of their failures so that they are rendered as
+// error cards. failures has a slot for PNG and CSV data of every panel.
+func (r *Report) tolerateFailures(ctx context.Context, dashboardData *dashboard.Data, failures []*PanelError, panels int) bool {
+	// Failures of a cancelled report are never tolerated
+	if !r.conf.TolerateFailures || ctx.Err() != nil {
+		return false
+	}
+
+	// A busy server is not a failure of panels
+	for _, failure := range failures {
+		if failure != nil && errors.Is(failure.Err, worker.ErrQueueFull) {
+			return false
+		}
+	}
+
+	var failed int
+
+	for idx := range dashboardData.Panels {
+		if failures[2*idx] != nil || failures[2*idx+1] != nil {
+			failed++
+		}
+	}
+
+	if failed == 0 {
+		return true
+	}
+
+	if float64(failed) > r.conf.MaxFailedPanels*float64(panels) {
+		return false
+	}
+
+	for idx := range dashboardData.Panels {
+		if failure := failures[2*idx]; failure != nil {
+			dashboardData.Panels[idx].PNGError = failure.Err.Error()
+		}
+
+		if failure := failures[2*idx+1]; failure != nil {
+			dashboardData.Panels[idx].CSVError = failure.Err.Error()
+		}
+	}
+
+	r.logger.Warn("generating partial report", "failed_panels", failed, "panels", panels)
+
+	return true
+}
+
 // generateHTMLFile generates HTML files for PDF.
 func (r *Report) generateHTMLFile(dashboardData *dashboard.Data) (HTML, error) {
+	return generateHTML(r.conf, "report.gohtml", r.newTemplateData(dashboardData))
+}
+
+// generatePDFHTMLFile generates HTML files for PDF. When table of contents is
+// enabled, the report is rendered once to find the pages of rows and panels
+// and HTML files are generated again with the page numbers. The first render
+// ignores page ranges as they would shift the pages of the full report.
+func (r *Report) generatePDFHTMLFile(ctx context.Context, dashboardData *dashboard.Data) (HTML, error) {
+	htmlReport, err := r.generateHTMLFile(dashboardData)
+	if err != nil || !r.conf.TableOfContents {
+		return htmlReport, err
+	}
+
+	// Page numbers of table of contents refer to the full report
+	conf := *r.conf
+	conf.PageRanges = ""
+
+	var buf bytes.Buffer
+	if err := renderPDF(ctx, r.logger, &conf, r.chromeInstance, r.newTemplateData(dashboardData).pdfInfo(), htmlReport, &buf); err != nil {
+		return HTML{}, fmt.Errorf("failed to paginate table of contents: %w", err)
+	}
+
+	// Only PDFs written by Chromium can be read for the pages of destinations
+	pages, err := pdfDestinations(buf.Bytes())
+	if err != nil {
+		// Table of contents is still useful without page numbers
+		r.logger.Warn("failed to find pages of table of contents", "err", err)
+
+		return htmlReport, nil
+	}
+
+	data := r.newTemplateData(dashboardData)
+	data.Pages = pages
+
+	return generateHTML(r.conf, "report.gohtml", data)
+}
+
+// generateHTML renders the body template with given name and, header and
+// footer templates of conf into HTML files for PDF.
+func generateHTML(conf *config.Config, name string, data any) (HTML, error) {
 	var tmpl *template.Template
 
 	var html HTML
@@ -189,28 +420,21 @@ func (r *Report) generateHTMLFile(dashboardData *dashboard.Data) (HTML, error) {
 	}
 
 	// Make a new template for Body of the PDF
-	if tmpl, err = template.New("report").Funcs(funcMap).ParseFS(templateFS, "templates/report.gohtml"); err != nil {
+	if tmpl, err = template.New("report").Funcs(funcMap).ParseFS(templateFS, "templates/"+name, "templates/dashboard.gohtml"); err != nil {
 		return HTML{}, fmt.Errorf("error parsing PDF template: %w", err)
 	}
 
-	// Template data
-	data := templateData{
-		time.Now().Local().In(r.conf.Location).Format(r.conf.TimeFormat),
-		dashboardData,
-		r.conf,
-	}
-
 	// Render the template for Body of the PDF
 	bufBody := &bytes.Buffer{}
-	if err = tmpl.ExecuteTemplate(bufBody, "report.gohtml", data); err != nil {
+	if err = tmpl.ExecuteTemplate(bufBody, name, data); err != nil {
 		return HTML{}, fmt.Errorf("error executing PDF template: %w", err)
 	}
 
 	html.Body = bufBody.String()
 
 	// Make a new template for Header of the PDF
-	if r.conf.HeaderTemplate != "" {
-		tmpl, err = template.New("header").Funcs(funcMap).Parse(fmt.Sprintf(`{{define "header.gohtml"}}%s{{end}}`, r.conf.HeaderTemplate))
+	if conf.HeaderTemplate != "" {
+		tmpl, err = template.New("header").Funcs(funcMap).Parse(fmt.Sprintf(`{{define "header.gohtml"}}%s{{end}}`, conf.HeaderTemplate))
 	} else {
 		tmpl, err = template.New("header").Funcs(funcMap).ParseFS(templateFS, "templates/header.gohtml")
 	}
@@ -228,8 +452,8 @@ func (r *Report) generateHTMLFile(dashboardData *dashboard.Data) (HTML, error) {
 	html.Header = bufHeader.String()
 
 	// Make a new template for Footer of the PDF
-	if r.conf.FooterTemplate != "" {
-		tmpl, err = template.New("footer").Funcs(funcMap).Parse(fmt.Sprintf(`{{define "footer.gohtml"}}%s{{end}}`, r.conf.FooterTemplate))
+	if conf.FooterTemplate != "" {
+		tmpl, err = template.New("footer").Funcs(funcMap).Parse(fmt.Sprintf(`{{define "footer.gohtml"}}%s{{end}}`, conf.FooterTemplate))
 	} else {
 		tmpl, err = template.New("footer").Funcs(funcMap).ParseFS(templateFS, "templates/footer.gohtml")
 	}
@@ -249,22 +473,89 @@ func (r *Report) generateHTMLFile(dashboardData *dashboard.Data) (HTML, error) {
 	return html, nil
 }
 
-// renderPDF renders HTML page into PDF using Chromium.
-func (r *Report) renderPDF(htmlReport HTML, writer io.Writer) error {
-	defer helpers.TimeTrack(time.Now(), "pdf rendering", r.logger)
+// newTemplateData returns the data used in report templates.
+func (r *Report) newTemplateData(dashboardData *dashboard.Data) templateData {
+	return templateData{
+		time.Now().Local().In(r.conf.Location).Format(r.conf.TimeFormat),
+		dashboardData,
+		r.conf,
+		nil,
+	}
+}
+
+// RenderText renders a text template, like subject of an email, using the
+// same data as report templates. It must be called after Generate.
+func (r *Report) RenderText(text string) (string, error) {
+	if r.data == nil {
+		return "", errors.New("report has not been generated")
+	}
+
+	tmpl, err := texttemplate.New("text").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("error parsing template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, r.newTemplateData(r.data)); err != nil {
+		return "", fmt.Errorf("error executing template: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// renderPDF renders HTML page of dashboard into PDF using Chromium.
+func (r *Report) renderPDF(ctx context.Context, dashboardData *dashboard.Data, htmlReport HTML, writer io.Writer) error {
+	return renderPDF(ctx, r.logger, r.conf, r.chromeInstance, r.newTemplateData(dashboardData).pdfInfo(), htmlReport, writer)
+}
+
+// renderPDF renders HTML page into PDF using a new tab of chromeInstance and
+// sets the document information of PDF to info.
+func renderPDF(ctx context.Context, logger log.Logger, conf *config.Config, chromeInstance chrome.Instance,
+	info pdfInfo, htmlReport HTML, writer io.Writer,
+) (err error) {
+	defer helpers.TimeTrack(time.Now(), "pdf rendering", logger)
+	defer func(start time.Time) { metrics.Observe(metrics.StageRenderPDF, start, err) }(time.Now())
+
+	ctx, span := helpers.StartSpan(ctx, "report.renderPDF", attribute.String("orientation", conf.Orientation))
+	defer func() { helpers.EndSpan(span, err) }()
 
 	// Create a new tab
-	tab := r.chromeInstance.NewTab(r.logger, r.conf)
-	defer tab.Close(r.logger)
+	tab := chromeInstance.NewTab(ctx, logger, conf)
+	defer tab.Close(logger)
 
-	err := tab.PrintToPDF(chrome.PDFOptions{
+	// PDF is buffered to append the document information to it
+	var buf bytes.Buffer
+
+	paperWidth, paperHeight := conf.PaperDimensions()
+
+	err = tab.PrintToPDF(chrome.PDFOptions{
 		Header:      htmlReport.Header,
 		Body:        htmlReport.Body,
 		Footer:      htmlReport.Footer,
-		Orientation: r.conf.Orientation,
-	}, writer)
+		Orientation: conf.Orientation,
+		PaperWidth:  paperWidth,
+		PaperHeight: paperHeight,
+		Margins:     conf.PageMargins(),
+		Scale:       conf.Scale,
+		PageRanges:  conf.PageRanges,
+		Outline:     true,
+	}, &buf)
 	if err != nil {
-		return fmt.Errorf("error rendering PDF: %w", err)
+		return &StageError{Stage: StageRenderPDF, Err: fmt.Errorf("error rendering PDF: %w", err)}
+	}
+
+	pdf := buf.Bytes()
+
+	// Document information is nice to have. Do not fail the report for it.
+	// PDF is written by Chromium, the only producer setPDFInfo supports
+	if updated, infoErr := setPDFInfo(pdf, info, time.Now()); infoErr != nil {
+		logger.Warn("failed to set PDF document information", "err", infoErr)
+	} else {
+		pdf = updated
+	}
+
+	if _, err = writer.Write(pdf); err != nil {
+		return fmt.Errorf("error writing PDF: %w", err)
 	}
 
 	return nil
@@ -2,6 +2,8 @@ package report
 
 import (
 	"context"
+	"errors"
+	"os/exec"
 	"strings"
 	"testing"
 	"time"
@@ -16,14 +18,30 @@ import (
 
 var logger = log.NewNullLogger()
 
+// fakeSink keeps the stored documents in memory.
+type fakeSink struct {
+	docs []Document
+	err  error
+}
+
+func (s *fakeSink) Store(_ context.Context, doc Document) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	s.docs = append(s.docs, doc)
+
+	return nil
+}
+
 func TestReport(t *testing.T) {
 	Convey("When generating a PDF", t, func() {
 		ctx, cancel := context.WithCancel(t.Context())
 		defer cancel()
 
 		workerPools := worker.Pools{
-			worker.Browser:  worker.New(ctx, 6),
-			worker.Renderer: worker.New(ctx, 2),
+			worker.Browser:  worker.New(ctx, worker.Browser, 6, 0),
+			worker.Renderer: worker.New(ctx, worker.Renderer, 2, 0),
 		}
 
 		rep := New(
@@ -76,6 +94,9 @@ func TestReport(t *testing.T) {
 
 					So(s, ShouldContainSubstring, "image1")
 				})
+				Convey("but no failed panels", func() {
+					So(s, ShouldNotContainSubstring, "<h2>Failed panels</h2>")
+				})
 				Convey("and the time range", func() {
 					// server time zone by shift hours timestamp
 					// so just test for day and year
@@ -84,5 +105,244 @@ func TestReport(t *testing.T) {
 				})
 			})
 		})
+
+		Convey("When some panels fail", func() {
+			failures := make([]*PanelError, 2*len(dashData.Panels))
+			failures[0] = &PanelError{PanelID: "1", Stage: StagePanelPNG, Err: dashboard.ErrTimeout}
+
+			Convey("Failures should not be tolerated by default", func() {
+				So(rep.tolerateFailures(t.Context(), &dashData, failures, 2), ShouldBeFalse)
+			})
+
+			rep.conf.TolerateFailures = true
+			rep.conf.MaxFailedPanels = 0.5
+
+			Convey("Failures should be tolerated up to the fraction of panels", func() {
+				So(rep.tolerateFailures(t.Context(), &dashData, failures, 2), ShouldBeTrue)
+				So(dashData.Panels[0].PNGError, ShouldEqual, "request to grafana timed out")
+
+				failures[3] = &PanelError{PanelID: "2", Stage: StagePanelCSV, Err: dashboard.ErrEmptyCSVData}
+				So(rep.tolerateFailures(t.Context(), &dashData, failures, 2), ShouldBeFalse)
+			})
+
+			Convey("Failures of a busy server should never be tolerated", func() {
+				failures[0].Err = worker.ErrQueueFull
+				So(rep.tolerateFailures(t.Context(), &dashData, failures, 2), ShouldBeFalse)
+			})
+
+			Convey("Failed panels should be rendered as error cards", func() {
+				dashData.Panels[0].EncodedImage = dashboard.PanelImage{}
+				dashData.Panels[0].Title = "CPU"
+				dashData.Panels[0].PNGError = "request to grafana timed out"
+
+				html, err := rep.generateHTMLFile(&dashData)
+				So(err, ShouldBeNil)
+
+				So(html.Body, ShouldContainSubstring, `<figure class="grid-image panel-error panel-0-image-0" id="panel-image1">`)
+				So(html.Body, ShouldContainSubstring, "<h3>CPU</h3>")
+				So(html.Body, ShouldContainSubstring, "<h2>Failed panels</h2>")
+				So(html.Body, ShouldContainSubstring, "<td>PNG</td>")
+			})
+		})
+
+		Convey("When generating the HTML files on a custom paper", func() {
+			rep.conf.PaperSize = "A4"
+			rep.conf.Orientation = "landscape"
+			rep.conf.Margins = "2cm  1cm"
+
+			html, err := rep.generateHTMLFile(&dashData)
+			So(err, ShouldBeNil)
+
+			Convey("Page rules should follow paper size and margins", func() {
+				So(html.Body, ShouldContainSubstring, "size: 297mm 210mm;")
+				So(html.Body, ShouldContainSubstring, "margin: 2cm 1cm;")
+			})
+		})
+
+		Convey("When generating the HTML files of a dashboard with rows", func() {
+			rows := []dashboard.Row{{ID: "3", Title: "Network"}, {ID: "4", Title: "Disks", Collapsed: true}}
+			dashData.Rows = rows
+			dashData.Panels = append(dashData.Panels, dashboard.Panel{
+				ID:           "5",
+				EncodedImage: dashboard.PanelImage{Image: "iVBORw0KGgorow", MimeType: "image/png"},
+				Row:          &rows[0],
+			})
+
+			rep.conf.RowPageBreak = true
+
+			html, err := rep.generateHTMLFile(&dashData)
+			So(err, ShouldBeNil)
+
+			s := html.Body
+
+			Convey("Every row should be a titled section", func() {
+				So(s, ShouldContainSubstring, "<h2>Network</h2>")
+				So(s, ShouldContainSubstring, "<h2>Disks</h2>")
+				So(s, ShouldContainSubstring, "panel-1-image-0")
+				So(strings.Index(s, "<h2>Network</h2>"), ShouldBeLessThan, strings.Index(s, "iVBORw0KGgorow"))
+			})
+
+			Convey("Sections of rows should start on a new page", func() {
+				So(s, ShouldContainSubstring, `id="panel-row-1" style="break-before:page"`)
+				So(s, ShouldContainSubstring, `id="panel-row-2" style="break-before:page"`)
+			})
+
+			Convey("Table of contents should list rows and panels with their pages", func() {
+				So(s, ShouldNotContainSubstring, `class="container report-toc"`)
+
+				rep.conf.TableOfContents = true
+
+				data := rep.newTemplateData(&dashData)
+				data.Pages = map[string]int{"panel-row-1": 3, "panel-image5": 4}
+
+				html, err := generateHTML(rep.conf, "report.gohtml", data)
+				So(err, ShouldBeNil)
+
+				So(html.Body, ShouldContainSubstring, `<a href="#panel-row-1">Network</a><span class="toc-page">3</span>`)
+				So(html.Body, ShouldContainSubstring, `<a href="#panel-image5"></a><span class="toc-page">4</span>`)
+				So(html.Body, ShouldContainSubstring, `<a href="#panel-row-2">Disks</a><span class="toc-page"></span>`)
+			})
+		})
+
+		Convey("When generating the HTML files of a combined report", func() {
+			otherData := dashboard.Data{
+				Title:     "My second dashboard",
+				Panels:    []dashboard.Panel{{ID: "1", EncodedImage: dashboard.PanelImage{Image: "iVBORw0KGgoother", MimeType: "image/png"}}},
+				TimeRange: dashData.TimeRange,
+			}
+
+			data := combinedData{
+				templateData: templateData{"today", &dashboard.Data{Title: "Weekly review", TimeRange: dashData.TimeRange}, rep.conf, nil},
+				Sections: []section{
+					rep.newTemplateData(&dashData).Section("section-1"),
+					rep.newTemplateData(&otherData).Section("section-2"),
+				},
+			}
+
+			html, err := generateHTML(rep.conf, "combined.gohtml", data)
+			So(err, ShouldBeNil)
+
+			s := html.Body
+
+			Convey("The cover page should contain the title", func() {
+				So(s, ShouldContainSubstring, "<h1>Weekly review</h1>")
+				So(html.Header, ShouldContainSubstring, "Weekly review")
+			})
+
+			Convey("The table of contents should link to every section", func() {
+				So(s, ShouldContainSubstring, `<a href="#section-1">My first dashboard</a>`)
+				So(s, ShouldContainSubstring, `<a href="#section-2">My second dashboard</a>`)
+				So(s, ShouldContainSubstring, `id="section-1"`)
+				So(s, ShouldContainSubstring, `id="section-2"`)
+			})
+
+			Convey("Every section should contain its own panels", func() {
+				So(s, ShouldContainSubstring, "section-1-image1")
+				So(s, ShouldContainSubstring, "section-2-image1")
+				So(strings.Count(s, "data:image/png"), ShouldEqual, 2)
+				So(s, ShouldContainSubstring, "value1")
+			})
+		})
+
+		Convey("When storing the report in sinks", func() {
+			sink := &fakeSink{}
+			rep.AddSink(sink)
+			rep.data = &dashData
+
+			err := rep.storeInSinks(t.Context(), []byte("pdf"))
+
+			Convey("Sinks should receive the document", func() {
+				So(err, ShouldBeNil)
+				So(sink.docs, ShouldHaveLength, 1)
+				So(sink.docs[0].Title, ShouldEqual, "My first dashboard")
+				So(string(sink.docs[0].Data), ShouldEqual, "pdf")
+			})
+		})
+
+		Convey("When a sink fails", func() {
+			sink := &fakeSink{}
+			rep.AddSink(&fakeSink{err: errors.New("upload failed")})
+			rep.AddSink(sink)
+			rep.data = &dashData
+
+			err := rep.storeInSinks(t.Context(), []byte("pdf"))
+
+			Convey("Other sinks should still receive the document", func() {
+				So(err, ShouldNotBeNil)
+				So(sink.docs, ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("When rendering text templates", func() {
+			_, err := rep.RenderText("{{.Title}}")
+
+			Convey("Rendering should fail before generating the report", func() {
+				So(err, ShouldNotBeNil)
+			})
+
+			rep.data = &dashData
+
+			text, err := rep.RenderText("Report of {{.Title}} ({{.VariableValues}})")
+
+			Convey("Text should contain template data", func() {
+				So(err, ShouldBeNil)
+				So(text, ShouldEqual, "Report of My first dashboard (testvarvalue)")
+			})
+		})
+	})
+}
+
+func TestGeneratePDFHTMLFile(t *testing.T) {
+	var execPath string
+
+	for _, path := range []string{"google-chrome", "chrome", "chromium", "chromium-browser"} {
+		if found, err := exec.LookPath(path); err == nil {
+			execPath = found
+
+			break
+		}
+	}
+
+	// Skip test if chrome is not available
+	if execPath == "" {
+		t.Skip("Chrome not found. Skipping test")
+	}
+
+	Convey("When generating the table of contents of a PDF with page ranges", t, func() {
+		chromeInstance, err := chrome.NewLocalBrowserInstance(t.Context(), logger, false)
+		So(err, ShouldBeNil)
+
+		defer chromeInstance.Close(logger)
+
+		rep := New(
+			logger,
+			&config.Config{
+				TimeFormat:      time.RFC3339,
+				Location:        time.UTC,
+				RowPageBreak:    true,
+				TableOfContents: true,
+				PageRanges:      "1",
+			},
+			nil, chromeInstance, nil, nil,
+		)
+
+		rows := []dashboard.Row{{ID: "1", Title: "Network"}, {ID: "2", Title: "Disks"}}
+		dashData := dashboard.Data{
+			Title: "My dashboard",
+			Rows:  rows,
+			Panels: []dashboard.Panel{
+				{ID: "3", EncodedImage: dashboard.PanelImage{Image: "iVBORw0KGgo=", MimeType: "image/png"}, Row: &rows[0]},
+				{ID: "4", EncodedImage: dashboard.PanelImage{Image: "iVBORw0KGgo=", MimeType: "image/png"}, Row: &rows[1]},
+			},
+			TimeRange: dashboard.NewTimeRange("now-1h", "now"),
+		}
+
+		html, err := rep.generatePDFHTMLFile(t.Context(), &dashData)
+		So(err, ShouldBeNil)
+
+		Convey("Page numbers should refer to the pages of the full report", func() {
+			So(html.Body, ShouldContainSubstring, `<a href="#panel-row-1">Network</a><span class="toc-page">2</span>`)
+			So(html.Body, ShouldContainSubstring, `<a href="#panel-row-2">Disks</a><span class="toc-page">3</span>`)
+		})
 	})
 }
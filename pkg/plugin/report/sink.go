@@ -0,0 +1,54 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+)
+
+// Document is a generated report.
+type Document struct {
+	DashboardUID string
+	Title        string
+	TimeRange    dashboard.TimeRange
+	GeneratedAt  time.Time
+	Format       string
+	ContentType  string
+	Data         []byte
+}
+
+// Sink is an additional output of generated reports like an object storage.
+type Sink interface {
+	Store(ctx context.Context, doc Document) error
+}
+
+// AddSink adds a sink to which the report will be stored after it is generated.
+func (r *Report) AddSink(sink Sink) {
+	r.sinks = append(r.sinks, sink)
+}
+
+// storeInSinks stores the generated report in all sinks. A failing sink does
+// not prevent storing the report in the others.
+func (r *Report) storeInSinks(ctx context.Context, data []byte) error {
+	doc := Document{
+		DashboardUID: r.data.UID,
+		Title:        r.data.Title,
+		TimeRange:    r.data.TimeRange,
+		GeneratedAt:  time.Now(),
+		Format:       r.format(),
+		ContentType:  r.ContentType(),
+		Data:         data,
+	}
+
+	var errs []error
+
+	for _, sink := range r.sinks {
+		if err := sink.Store(ctx, doc); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -1,7 +1,9 @@
 package report
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/chrome"
@@ -18,6 +20,15 @@ type Report struct {
 	chromeInstance chrome.Instance
 	pools          worker.Pools
 	dashboard      *dashboard.Dashboard
+	progress       func(done, total int)
+	sinks          []Sink
+
+	// Dashboard data and size of the generated report
+	data *dashboard.Data
+	size int64
+
+	// Error of storing the generated report in sinks
+	sinkErr error
 }
 
 type HTML struct {
@@ -31,6 +42,10 @@ type templateData struct {
 	Date      string
 	Dashboard *dashboard.Data
 	Conf      *config.Config
+
+	// Page numbers of the elements of report by their ID. They are only
+	// known once the report has been rendered into PDF
+	Pages map[string]int
 }
 
 // IsGridLayout returns true if layout config is grid.
@@ -64,6 +79,35 @@ func (t templateData) Panels() []dashboard.Panel {
 	return t.Dashboard.Panels
 }
 
+// RowSections returns dashboard's panels grouped by their rows.
+func (t templateData) RowSections() []dashboard.Section {
+	return t.Dashboard.Sections()
+}
+
+// FailedPanels returns the panels of dashboard that failed in a partial
+// report.
+func (t templateData) FailedPanels() []dashboard.Panel {
+	var panels []dashboard.Panel
+
+	for _, panel := range t.Dashboard.Panels {
+		if panel.PNGError != "" || panel.CSVError != "" {
+			panels = append(panels, panel)
+		}
+	}
+
+	return panels
+}
+
+// Page returns the page number of the element of report with given ID or
+// an empty string when it is unknown.
+func (t templateData) Page(id string) string {
+	if page, ok := t.Pages[id]; ok {
+		return strconv.Itoa(page)
+	}
+
+	return ""
+}
+
 // Title returns dashboard's title.
 func (t templateData) Title() string {
 	return t.Dashboard.Title
@@ -78,3 +122,40 @@ func (t templateData) VariableValues() string {
 func (t templateData) Theme() string {
 	return t.Conf.Theme
 }
+
+// pdfInfo returns the document information of PDF report. Description of
+// dashboard is the subject of document, falling back to its time range.
+func (t templateData) pdfInfo() pdfInfo {
+	subject := t.Dashboard.Description
+	if subject == "" {
+		subject = fmt.Sprintf("From %s to %s", t.From(), t.To())
+	}
+
+	return pdfInfo{
+		Title:    t.Dashboard.Title,
+		Author:   t.Dashboard.CreatedBy,
+		Subject:  subject,
+		Keywords: t.Dashboard.Tags,
+	}
+}
+
+// Section returns the template data as a section of a report whose HTML
+// elements are identified by id.
+func (t templateData) Section(id string) section {
+	return section{t, id}
+}
+
+// section is a dashboard in the body of a report.
+type section struct {
+	templateData
+
+	ID string
+}
+
+// combinedData is the data used in templates of a combined report. Title
+// and time range of combined report are in the embedded templateData.
+type combinedData struct {
+	templateData
+
+	Sections []section
+}
@@ -0,0 +1,289 @@
+package report
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Styles of workbook cells. They are indexes in cellXfs of styles part.
+const (
+	styleDefault = iota
+	styleDateTime
+	styleDate
+	styleBold
+)
+
+// Maximum length of sheet names allowed by Excel.
+const maxSheetNameLength = 31
+
+// Layouts of time values that are stored as dates in workbooks.
+var (
+	dateTimeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05.000", "2006-01-02 15:04:05", "2006-01-02T15:04:05"}
+	dateLayouts     = []string{"2006-01-02"}
+)
+
+// Origin of serial dates in spreadsheets.
+var excelEpoch = time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+
+const (
+	contentTypesXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`%s</Types>`
+
+	rootRelsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+
+	stylesXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<numFmts count="2"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm:ss"/><numFmt numFmtId="165" formatCode="yyyy-mm-dd"/></numFmts>` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="4">` +
+		`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+		`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+		`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+		`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+		`</cellXfs>` +
+		`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+		`</styleSheet>`
+)
+
+// workbook writes a XLSX workbook to the underlying writer sheet by sheet
+// without keeping the sheets in memory.
+type workbook struct {
+	zw     *zip.Writer
+	sheets []string
+}
+
+func newWorkbook(w io.Writer) *workbook {
+	return &workbook{zw: zip.NewWriter(w)}
+}
+
+// AddSheet writes a new sheet with the given name and rows. Cells of header
+// rows are written as bold text while the other cells are typed as numbers
+// or dates when their values can be parsed.
+func (wb *workbook) AddSheet(name string, rows [][]string, headerRows int) error {
+	name = wb.sheetName(name)
+	wb.sheets = append(wb.sheets, name)
+
+	f, err := wb.zw.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(wb.sheets)))
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	for i, row := range rows {
+		fmt.Fprintf(&b, `<row r="%d">`, i+1)
+
+		for j, value := range row {
+			writeCell(&b, cellRef(j, i), value, i < headerRows)
+		}
+
+		b.WriteString(`</row>`)
+
+		// Flush rows regularly to keep memory usage low for big tables
+		if b.Len() > 1<<16 {
+			if _, err := io.WriteString(f, b.String()); err != nil {
+				return err
+			}
+
+			b.Reset()
+		}
+	}
+
+	b.WriteString(`</sheetData></worksheet>`)
+
+	_, err = io.WriteString(f, b.String())
+
+	return err
+}
+
+// Close writes the remaining parts of the workbook. It does not close the
+// underlying writer.
+func (wb *workbook) Close() error {
+	var overrides, sheets, rels strings.Builder
+
+	for i, name := range wb.sheets {
+		fmt.Fprintf(&overrides, `<Override PartName="/xl/worksheets/sheet%d.xml" `+
+			`ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+		fmt.Fprintf(&sheets, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escapeXML(name), i+1, i+1)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" `+
+			`Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+
+	fmt.Fprintf(&rels, `<Relationship Id="rId%d" `+
+		`Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(wb.sheets)+1)
+
+	parts := []struct {
+		name, content string
+	}{
+		{"[Content_Types].xml", fmt.Sprintf(contentTypesXML, overrides.String())},
+		{"_rels/.rels", rootRelsXML},
+		{
+			"xl/workbook.xml",
+			`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+				`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+				`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+				`<sheets>` + sheets.String() + `</sheets></workbook>`,
+		},
+		{
+			"xl/_rels/workbook.xml.rels",
+			`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+				`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+				rels.String() + `</Relationships>`,
+		},
+		{"xl/styles.xml", stylesXML},
+	}
+
+	for _, part := range parts {
+		f, err := wb.zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return err
+		}
+	}
+
+	return wb.zw.Close()
+}
+
+// sheetName returns a valid and unique sheet name based on name.
+func (wb *workbook) sheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+
+		return r
+	}, name)
+
+	name = strings.Trim(strings.TrimSpace(name), "'")
+	if name == "" {
+		name = "Sheet"
+	}
+
+	name = truncate(name, maxSheetNameLength)
+
+	// Names are case insensitive in Excel
+	unique := name
+
+	for i := 2; wb.hasSheet(unique); i++ {
+		suffix := fmt.Sprintf(" (%d)", i)
+		unique = truncate(name, maxSheetNameLength-len(suffix)) + suffix
+	}
+
+	return unique
+}
+
+func (wb *workbook) hasSheet(name string) bool {
+	for _, sheet := range wb.sheets {
+		if strings.EqualFold(sheet, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// truncate truncates s to at most n runes.
+func truncate(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n])
+	}
+
+	return s
+}
+
+// writeCell writes a cell with a typed value.
+func writeCell(b *strings.Builder, ref, value string, header bool) {
+	if header {
+		fmt.Fprintf(b, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, styleBold, escapeXML(value))
+
+		return
+	}
+
+	if value == "" {
+		return
+	}
+
+	if v, err := strconv.ParseFloat(value, 64); err == nil && !math.IsInf(v, 0) && !math.IsNaN(v) {
+		fmt.Fprintf(b, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(v, 'g', -1, 64))
+
+		return
+	}
+
+	if serial, style, ok := parseDate(value); ok {
+		fmt.Fprintf(b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, strconv.FormatFloat(serial, 'f', -1, 64))
+
+		return
+	}
+
+	fmt.Fprintf(b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, escapeXML(value))
+}
+
+// parseDate returns the serial date of value and the style to display it, if
+// value is a date. Spreadsheets do not have time zones and hence, wall clock
+// time of value is used.
+func parseDate(value string) (float64, int, bool) {
+	for _, layouts := range []struct {
+		layouts []string
+		style   int
+	}{
+		{dateTimeLayouts, styleDateTime},
+		{dateLayouts, styleDate},
+	} {
+		for _, layout := range layouts.layouts {
+			t, err := time.Parse(layout, value)
+			if err != nil {
+				continue
+			}
+
+			wallClock := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+
+			return wallClock.Sub(excelEpoch).Hours() / 24, layouts.style, true
+		}
+	}
+
+	return 0, 0, false
+}
+
+// cellRef returns the reference, like B3, of the cell at zero based column
+// and row indexes.
+func cellRef(col, row int) string {
+	var name []byte
+
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = append([]byte{byte('A' + (col-1)%26)}, name...)
+	}
+
+	return string(name) + strconv.Itoa(row+1)
+}
+
+// escapeXML escapes s to be used in XML text and attributes.
+func escapeXML(s string) string {
+	var b strings.Builder
+
+	xml.EscapeText(&b, []byte(s)) //nolint:errcheck
+
+	return b.String()
+}
@@ -0,0 +1,177 @@
+package report
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// xlsxCell is a cell of a parsed worksheet.
+type xlsxCell struct {
+	Ref    string `xml:"r,attr"`
+	Type   string `xml:"t,attr"`
+	Style  string `xml:"s,attr"`
+	Value  string `xml:"v"`
+	String string `xml:"is>t"`
+}
+
+// readZIP returns the content of the files in ZIP archive.
+func readZIP(data []byte) (map[string][]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+
+		files[f.Name], err = io.ReadAll(rc)
+		rc.Close()
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// readSheet returns the cells of a worksheet by reference.
+func readSheet(data []byte) (map[string]xlsxCell, error) {
+	var sheet struct {
+		Rows []struct {
+			Cells []xlsxCell `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+
+	if err := xml.Unmarshal(data, &sheet); err != nil {
+		return nil, err
+	}
+
+	cells := make(map[string]xlsxCell)
+
+	for _, row := range sheet.Rows {
+		for _, c := range row.Cells {
+			cells[c.Ref] = c
+		}
+	}
+
+	return cells, nil
+}
+
+func TestWorkbook(t *testing.T) {
+	Convey("When computing cell references", t, func() {
+		So(cellRef(0, 0), ShouldEqual, "A1")
+		So(cellRef(25, 1), ShouldEqual, "Z2")
+		So(cellRef(26, 2), ShouldEqual, "AA3")
+		So(cellRef(701, 9), ShouldEqual, "ZZ10")
+		So(cellRef(702, 0), ShouldEqual, "AAA1")
+	})
+
+	Convey("When parsing dates", t, func() {
+		serial, style, ok := parseDate("2024-12-14 12:00:00")
+		So(ok, ShouldBeTrue)
+		So(style, ShouldEqual, styleDateTime)
+		So(serial, ShouldEqual, 45640.5)
+
+		serial, style, ok = parseDate("1900-03-01")
+		So(ok, ShouldBeTrue)
+		So(style, ShouldEqual, styleDate)
+		So(serial, ShouldEqual, 61)
+
+		_, _, ok = parseDate("yesterday")
+		So(ok, ShouldBeFalse)
+	})
+
+	Convey("When writing a XLSX report", t, func() {
+		rep := &Report{conf: &config.Config{
+			TimeFormat: time.UnixDate,
+			Location:   time.UTC,
+		}}
+
+		dashData := &dashboard.Data{
+			Title:     "Finance",
+			Variables: "var-region=eu",
+			TimeRange: dashboard.TimeRange{From: "1734194455000", To: "1734194465000"},
+			Panels: []dashboard.Panel{
+				{ID: "1", Title: "Revenue: Q4/2024", CSVData: [][]string{
+					{"Time", "Revenue", "Region"},
+					{"2024-12-14 12:00:00", "1234.5", "eu <west>"},
+					{"2024-12-15 12:00:00", "-7", ""},
+				}},
+				{ID: "2", Title: "No data"},
+				{ID: "3", Title: "revenue: q4/2024", CSVData: [][]string{{"Value"}, {"1e3"}}},
+				{ID: "4", CSVData: [][]string{{"Value"}, {"NaN"}}},
+			},
+		}
+
+		var buf bytes.Buffer
+		So(rep.writeXLSX(dashData, &buf), ShouldBeNil)
+
+		files, err := readZIP(buf.Bytes())
+		So(err, ShouldBeNil)
+
+		Convey("Workbook should have a sheet per panel with data after cover sheet", func() {
+			workbook := string(files["xl/workbook.xml"])
+			So(workbook, ShouldContainSubstring, `<sheet name="Report" sheetId="1" r:id="rId1"/>`)
+			So(workbook, ShouldContainSubstring, `<sheet name="Revenue_ Q4_2024" sheetId="2" r:id="rId2"/>`)
+			So(workbook, ShouldContainSubstring, `<sheet name="revenue_ q4_2024 (2)" sheetId="3" r:id="rId3"/>`)
+			So(workbook, ShouldContainSubstring, `<sheet name="Panel 4" sheetId="4" r:id="rId4"/>`)
+			So(workbook, ShouldNotContainSubstring, "No data")
+
+			So(files, ShouldContainKey, "[Content_Types].xml")
+			So(files, ShouldContainKey, "xl/styles.xml")
+			So(files, ShouldContainKey, "xl/worksheets/sheet4.xml")
+			So(string(files["xl/_rels/workbook.xml.rels"]), ShouldContainSubstring, `Id="rId5"`)
+		})
+
+		Convey("Cover sheet should describe the dashboard", func() {
+			cells, err := readSheet(files["xl/worksheets/sheet1.xml"])
+			So(err, ShouldBeNil)
+			So(cells["B1"].String, ShouldEqual, "Finance")
+			So(cells["A2"].String, ShouldEqual, "From")
+			So(cells["B2"].String, ShouldContainSubstring, "2024")
+			So(cells["B4"].String, ShouldEqual, "var-region=eu")
+		})
+
+		Convey("Panel sheets should have typed cells", func() {
+			cells, err := readSheet(files["xl/worksheets/sheet2.xml"])
+			So(err, ShouldBeNil)
+
+			So(cells["B1"].String, ShouldEqual, "Revenue")
+			So(cells["B1"].Style, ShouldEqual, "3")
+
+			So(cells["A2"].Type, ShouldBeEmpty)
+			So(cells["A2"].Value, ShouldEqual, "45640.5")
+			So(cells["A2"].Style, ShouldEqual, "1")
+
+			So(cells["B2"].Type, ShouldBeEmpty)
+			So(cells["B2"].Value, ShouldEqual, "1234.5")
+			So(cells["B3"].Value, ShouldEqual, "-7")
+
+			So(cells["C2"].Type, ShouldEqual, "inlineStr")
+			So(cells["C2"].String, ShouldEqual, "eu <west>")
+			So(cells, ShouldNotContainKey, "C3")
+
+			cells, err = readSheet(files["xl/worksheets/sheet3.xml"])
+			So(err, ShouldBeNil)
+			So(cells["A2"].Value, ShouldEqual, "1000")
+
+			cells, err = readSheet(files["xl/worksheets/sheet4.xml"])
+			So(err, ShouldBeNil)
+			So(cells["A2"].Type, ShouldEqual, "inlineStr")
+		})
+	})
+}
@@ -0,0 +1,158 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/helpers"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/worker"
+)
+
+// Name of the cover sheet of XLSX reports.
+const coverSheetName = "Report"
+
+// prepareXLSX collects tabular data of dashboard panels and returns a function
+// that writes it into a XLSX workbook.
+func (r *Report) prepareXLSX(ctx context.Context) (*dashboard.Data, renderFunc, error) {
+	dashboardData, err := r.dashboard.GetData(ctx)
+	if err != nil {
+		return nil, nil, &StageError{Stage: StageDiscovery, Err: fmt.Errorf("failed to get dashboard data: %w", err)}
+	}
+
+	if err := r.populateData(ctx, dashboardData); err != nil {
+		return nil, nil, fmt.Errorf("failed to populate panels: %w", err)
+	}
+
+	r.data = dashboardData
+
+	return dashboardData, func(w io.Writer) error {
+		if err := r.writeXLSX(dashboardData, w); err != nil {
+			return fmt.Errorf("failed to write XLSX: %w", err)
+		}
+
+		return nil
+	}, nil
+}
+
+// dataPanels returns the indexes of panels whose data is exported. Panels
+// with data included in PDF reports are used if set. Otherwise, the panels
+// included in PDF reports are used.
+func (r *Report) dataPanels(panels []dashboard.Panel) []int {
+	if len(r.conf.IncludePanelDataIDs) > 0 {
+		return selectPanels(panels, r.conf.IncludePanelDataIDs, nil, false)
+	}
+
+	return selectPanels(panels, r.conf.IncludePanelIDs, r.conf.ExcludePanelIDs, true)
+}
+
+// populateData populates the panels with tabular data. Panels that already
+// have data are not fetched again. When panels are not explicitly selected,
+// panels without data, like text panels, are skipped.
+func (r *Report) populateData(ctx context.Context, dashboardData *dashboard.Data) error {
+	defer helpers.TimeTrack(time.Now(), "panel data generation", r.logger)
+
+	explicit := len(r.conf.IncludePanelDataIDs) > 0
+	tablePanels := slices.DeleteFunc(r.dataPanels(dashboardData.Panels), func(idx int) bool {
+		return dashboardData.Panels[idx].CSVData != nil
+	})
+
+	failures := make([]*PanelError, len(dashboardData.Panels))
+
+	// Track the progress of panels population
+	var done atomic.Int64
+
+	// Admit all panels of report at once so that a busy server rejects the
+	// report before any panel is fetched
+	ctx, release, err := r.pools.Reserve(ctx, map[string]int{worker.Browser: len(tablePanels)})
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	total := len(tablePanels)
+	r.reportProgress(0, total)
+
+	wg := sync.WaitGroup{}
+
+	for idx, panel := range dashboardData.Panels {
+		if !slices.Contains(tablePanels, idx) {
+			continue
+		}
+
+		wg.Add(1)
+
+		if err := r.pools[worker.Browser].Do(ctx, func() {
+			defer wg.Done()
+
+			panelData, err := r.dashboard.PanelCSV(ctx, panel)
+			if err != nil {
+				if explicit || ctx.Err() != nil {
+					failures[idx] = &PanelError{PanelID: panel.ID, Title: panel.Title, Stage: StagePanelCSV, Err: err}
+				} else {
+					r.logger.Warn("skipping panel without data", "panel_id", panel.ID, "err", err)
+				}
+			}
+
+			dashboardData.Panels[idx].CSVData = panelData
+
+			r.reportProgress(int(done.Add(1)), total)
+		}); err != nil {
+			// Request has been cancelled before panel could be queued
+			wg.Done()
+
+			failures[idx] = &PanelError{PanelID: panel.ID, Title: panel.Title, Stage: StagePanelCSV, Err: err}
+		}
+	}
+
+	wg.Wait()
+
+	failures = slices.DeleteFunc(failures, func(failure *PanelError) bool { return failure == nil })
+	if len(failures) > 0 {
+		return newPanelsError(failures)
+	}
+
+	return nil
+}
+
+// writeXLSX writes a workbook with a cover sheet followed by a sheet for
+// every panel with tabular data.
+func (r *Report) writeXLSX(dashboardData *dashboard.Data, w io.Writer) error {
+	data := r.newTemplateData(dashboardData)
+
+	wb := newWorkbook(w)
+
+	cover := [][]string{
+		{"Dashboard", data.Title()},
+		{"From", data.From()},
+		{"To", data.To()},
+		{"Variables", data.VariableValues()},
+		{"Generated", data.Date},
+	}
+
+	if err := wb.AddSheet(coverSheetName, cover, 0); err != nil {
+		return err
+	}
+
+	for _, panel := range dashboardData.Panels {
+		if len(panel.CSVData) == 0 {
+			continue
+		}
+
+		name := panel.Title
+		if name == "" {
+			name = "Panel " + panel.ID
+		}
+
+		if err := wb.AddSheet(name, panel.CSVData, 1); err != nil {
+			return err
+		}
+	}
+
+	return wb.Close()
+}
@@ -0,0 +1,206 @@
+package report
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+)
+
+// Names of the files in ZIP bundles that do not depend on panels.
+const (
+	manifestFileName = "manifest.json"
+	reportFileName   = "report.pdf"
+)
+
+// manifest describes the content of a ZIP bundle.
+type manifest struct {
+	UID       string              `json:"uid"`
+	Title     string              `json:"title"`
+	Generated string              `json:"generated"`
+	TimeRange manifestTimeRange   `json:"timeRange"`
+	Variables map[string][]string `json:"variables"`
+	Report    string              `json:"report"`
+	Panels    []manifestPanel     `json:"panels"`
+}
+
+// manifestTimeRange is the time range of a ZIP bundle as given in the request
+// and as absolute time.
+type manifestTimeRange struct {
+	From         string    `json:"from"`
+	To           string    `json:"to"`
+	FromAbsolute time.Time `json:"fromAbsolute"`
+	ToAbsolute   time.Time `json:"toAbsolute"`
+}
+
+// manifestPanel describes a panel of a ZIP bundle and its files.
+type manifestPanel struct {
+	ID      string            `json:"id"`
+	Title   string            `json:"title"`
+	Type    string            `json:"type"`
+	GridPos dashboard.GridPos `json:"gridPos"`
+	PNG     string            `json:"png,omitempty"`
+	CSV     string            `json:"csv,omitempty"`
+}
+
+// prepareZIP collects PNGs and tabular data of dashboard panels and returns
+// a function that writes them along with the PDF report and a manifest into
+// a ZIP bundle.
+func (r *Report) prepareZIP(ctx context.Context) (*dashboard.Data, renderFunc, error) {
+	dashboardData, err := r.collect(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Panels with data in PDF report are already populated by collect
+	if err := r.populateData(ctx, dashboardData); err != nil {
+		return nil, nil, fmt.Errorf("failed to populate panels: %w", err)
+	}
+
+	htmlReport, err := r.generatePDFHTMLFile(ctx, dashboardData)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate HTML file: %w", err)
+	}
+
+	return dashboardData, func(w io.Writer) error {
+		if err := r.writeZIP(ctx, dashboardData, htmlReport, w); err != nil {
+			return fmt.Errorf("failed to write ZIP: %w", err)
+		}
+
+		return nil
+	}, nil
+}
+
+// writeZIP writes the manifest, panel PNGs and CSVs and the PDF report into
+// a ZIP bundle.
+func (r *Report) writeZIP(ctx context.Context, dashboardData *dashboard.Data, htmlReport HTML, w io.Writer) error {
+	// PDF is rendered before anything is written so that a failure to render
+	// it does not leave a partial bundle in the response
+	var pdf bytes.Buffer
+	if err := r.renderPDF(ctx, dashboardData, htmlReport, &pdf); err != nil {
+		return fmt.Errorf("failed to render PDF: %w", err)
+	}
+
+	zw := zip.NewWriter(w)
+
+	m := r.newManifest(dashboardData)
+
+	f, err := zw.Create(manifestFileName)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(m); err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	for i, panel := range dashboardData.Panels {
+		if name := m.Panels[i].PNG; name != "" {
+			if err := writeZIPFile(zw, name, func(w io.Writer) error {
+				_, err := io.Copy(w, base64.NewDecoder(base64.StdEncoding, strings.NewReader(panel.EncodedImage.Image)))
+
+				return err
+			}); err != nil {
+				return fmt.Errorf("failed to write PNG of panel %s: %w", panel.ID, err)
+			}
+		}
+
+		if name := m.Panels[i].CSV; name != "" {
+			if err := writeZIPFile(zw, name, func(w io.Writer) error {
+				return csv.NewWriter(w).WriteAll(panel.CSVData)
+			}); err != nil {
+				return fmt.Errorf("failed to write CSV of panel %s: %w", panel.ID, err)
+			}
+		}
+	}
+
+	if err := writeZIPFile(zw, m.Report, func(w io.Writer) error {
+		_, err := pdf.WriteTo(w)
+
+		return err
+	}); err != nil {
+		return fmt.Errorf("failed to write PDF: %w", err)
+	}
+
+	return zw.Close()
+}
+
+// writeZIPFile creates a new file with the given name in the ZIP bundle and
+// writes its content using write.
+func writeZIPFile(zw *zip.Writer, name string, write func(w io.Writer) error) error {
+	f, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return write(f)
+}
+
+// newManifest returns the manifest of a ZIP bundle of the dashboard data.
+func (r *Report) newManifest(dashboardData *dashboard.Data) manifest {
+	m := manifest{
+		UID:       dashboardData.UID,
+		Title:     dashboardData.Title,
+		Generated: r.newTemplateData(dashboardData).Date,
+		TimeRange: manifestTimeRange{
+			From:         dashboardData.TimeRange.From,
+			To:           dashboardData.TimeRange.To,
+			FromAbsolute: dashboardData.TimeRange.FromTime().In(r.conf.Location),
+			ToAbsolute:   dashboardData.TimeRange.ToTime().In(r.conf.Location),
+		},
+		Variables: dashboardData.TemplateVariables,
+		Report:    reportFileName,
+		Panels:    make([]manifestPanel, len(dashboardData.Panels)),
+	}
+
+	for i, panel := range dashboardData.Panels {
+		m.Panels[i] = manifestPanel{
+			ID:      panel.ID,
+			Title:   panel.Title,
+			Type:    panel.Type,
+			GridPos: panel.GridPos,
+		}
+
+		name := panelFileName(panel.ID)
+
+		if panel.EncodedImage.Image != "" {
+			m.Panels[i].PNG = "panels/" + name + ".png"
+		}
+
+		if len(panel.CSVData) > 0 {
+			m.Panels[i].CSV = "data/" + name + ".csv"
+		}
+	}
+
+	return m
+}
+
+// panelFileName returns the name of files of the panel with given ID without
+// extension.
+func panelFileName(id string) string {
+	id = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, strings.TrimPrefix(id, "panel-"))
+
+	return "panel-" + id
+}
@@ -0,0 +1,130 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/chrome"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPanelFileName(t *testing.T) {
+	Convey("When making file names of panels", t, func() {
+		Convey("Numeric IDs should be prefixed", func() {
+			So(panelFileName("5"), ShouldEqual, "panel-5")
+		})
+
+		Convey("IDs with prefix should not be prefixed twice", func() {
+			So(panelFileName("panel-5"), ShouldEqual, "panel-5")
+		})
+
+		Convey("Characters not safe in file names should be replaced", func() {
+			So(panelFileName("panel-5-clone-0"), ShouldEqual, "panel-5-clone-0")
+			So(panelFileName("../5 a"), ShouldEqual, "panel-___5_a")
+		})
+	})
+}
+
+func TestManifest(t *testing.T) {
+	Convey("When making the manifest of a ZIP bundle", t, func() {
+		rep := New(
+			logger,
+			&config.Config{TimeFormat: time.RFC3339, Location: time.UTC},
+			nil, nil, nil, nil,
+		)
+
+		dashData := dashboard.Data{
+			UID:   "abc",
+			Title: "My dashboard",
+			Panels: []dashboard.Panel{
+				{
+					ID: "1", Type: "timeseries", Title: "CPU",
+					GridPos:      dashboard.GridPos{H: 8, W: 12, X: 0, Y: 0},
+					EncodedImage: dashboard.PanelImage{Image: "iVBORw0KGgo=", MimeType: "image/png"},
+					CSVData:      dashboard.CSVData{{"Time", "cpu"}, {"2024-12-14 16:40:55", "0.5"}},
+				},
+				{ID: "2", Type: "text", Title: "Notes", EncodedImage: dashboard.PanelImage{Image: "iVBORw0KGgo=", MimeType: "image/png"}},
+				{ID: "3", Type: "table", Title: "Excluded"},
+			},
+			TimeRange: dashboard.TimeRange{
+				From: "1734194455000",
+				To:   "1734194465000",
+			},
+			TemplateVariables: map[string][]string{"host": {"server1", "server2"}},
+		}
+
+		m := rep.newManifest(&dashData)
+
+		Convey("It should describe the dashboard", func() {
+			So(m.UID, ShouldEqual, "abc")
+			So(m.Title, ShouldEqual, "My dashboard")
+			So(m.Report, ShouldEqual, "report.pdf")
+			So(m.Variables, ShouldResemble, map[string][]string{"host": {"server1", "server2"}})
+			So(m.TimeRange.From, ShouldEqual, "1734194455000")
+			So(m.TimeRange.FromAbsolute.Equal(time.UnixMilli(1734194455000)), ShouldBeTrue)
+			So(m.TimeRange.ToAbsolute.Equal(time.UnixMilli(1734194465000)), ShouldBeTrue)
+		})
+
+		Convey("It should list files of every panel", func() {
+			So(m.Panels, ShouldHaveLength, 3)
+			So(m.Panels[0], ShouldResemble, manifestPanel{
+				ID: "1", Title: "CPU", Type: "timeseries",
+				GridPos: dashboard.GridPos{H: 8, W: 12, X: 0, Y: 0},
+				PNG:     "panels/panel-1.png",
+				CSV:     "data/panel-1.csv",
+			})
+			So(m.Panels[1].PNG, ShouldEqual, "panels/panel-2.png")
+			So(m.Panels[1].CSV, ShouldBeEmpty)
+			So(m.Panels[2].PNG, ShouldBeEmpty)
+			So(m.Panels[2].CSV, ShouldBeEmpty)
+		})
+
+		Convey("It should be encoded without missing files", func() {
+			b, err := json.Marshal(m.Panels[2])
+			So(err, ShouldBeNil)
+			So(string(b), ShouldNotContainSubstring, "png")
+			So(string(b), ShouldNotContainSubstring, "csv")
+		})
+	})
+}
+
+func TestWriteZIP(t *testing.T) {
+	Convey("When PDF of a ZIP bundle cannot be rendered", t, func() {
+		// Browser is unreachable so that PDF rendering fails
+		chromeInstance, err := chrome.NewRemoteBrowserInstance(t.Context(), logger, "ws://127.0.0.1:1")
+		So(err, ShouldBeNil)
+
+		defer chromeInstance.Close(logger)
+
+		rep := New(
+			logger,
+			&config.Config{TimeFormat: time.RFC3339, Location: time.UTC},
+			nil, chromeInstance, nil, nil,
+		)
+
+		dashData := dashboard.Data{
+			UID:   "abc",
+			Title: "My dashboard",
+			Panels: []dashboard.Panel{
+				{
+					ID: "1", Type: "timeseries", Title: "CPU",
+					EncodedImage: dashboard.PanelImage{Image: "iVBORw0KGgo=", MimeType: "image/png"},
+					CSVData:      dashboard.CSVData{{"Time", "cpu"}, {"2024-12-14 16:40:55", "0.5"}},
+				},
+			},
+			TimeRange: dashboard.NewTimeRange("", ""),
+		}
+
+		var buf bytes.Buffer
+		err = rep.writeZIP(t.Context(), &dashData, HTML{}, &buf)
+
+		Convey("It should fail without writing anything", func() {
+			So(err, ShouldNotBeNil)
+			So(buf.Len(), ShouldEqual, 0)
+		})
+	})
+}
@@ -3,17 +3,21 @@ package plugin
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
 	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/delivery"
 	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/helpers"
 	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/report"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/worker"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
 	"github.com/mahendrapaipuri/authlib/authz"
@@ -32,22 +36,7 @@ const (
 
 // convertPanelIDs returns panel IDs based on Grafana version.
 func (app *App) convertPanelIDs(ids []string) []string {
-	// For Grafana < 11.3.0, we can use the IDs as such
-	if helpers.SemverCompare(app.grafanaSemVer, "v11.3.0") == -1 {
-		return ids
-	}
-
-	panelIDs := make([]string, len(ids))
-
-	for i, id := range ids {
-		if !strings.HasPrefix(id, "panel") {
-			panelIDs[i] = "panel-" + id
-		} else {
-			panelIDs[i] = id
-		}
-	}
-
-	return panelIDs
+	return helpers.PanelIDs(app.grafanaSemVer, ids)
 }
 
 // filterTemplateVariables filters query parameters to only include template variables.
@@ -66,6 +55,16 @@ func (app *App) filterTemplateVariables(queryParams url.Values) url.Values {
 		"includePanelID":     true,
 		"excludePanelID":     true,
 		"includePanelDataID": true,
+		"format":             true,
+		"collapsedRows":      true,
+		"rowPageBreak":       true,
+		"tableOfContents":    true,
+		"paperSize":          true,
+		"margins":            true,
+		"scale":              true,
+		"pageRanges":         true,
+		"tolerateFailures":   true,
+		"maxFailedPanels":    true,
 		"from":               true,
 		"to":                 true,
 		"width":              true,
@@ -89,33 +88,48 @@ func (app *App) filterTemplateVariables(queryParams url.Values) url.Values {
 	return filteredValues
 }
 
+// dashboardVariables returns the template variables and the time range of
+// query parameters that are set on dashboard models so that they reach
+// panels and the report.
+func (app *App) dashboardVariables(queryParams url.Values) url.Values {
+	values := app.filterTemplateVariables(queryParams)
+
+	for _, name := range []string{"from", "to"} {
+		if queryParams.Has(name) {
+			values.Set(name, queryParams.Get(name))
+		}
+	}
+
+	return values
+}
+
 // updateConfig updates the default config from query parameters.
-func (app *App) updateConfig(req *http.Request, conf *config.Config) {
-	if req.URL.Query().Has("theme") {
-		conf.Theme = req.URL.Query().Get("theme")
+func (app *App) updateConfig(values url.Values, conf *config.Config) {
+	if values.Has("theme") {
+		conf.Theme = values.Get("theme")
 	}
 
-	if req.URL.Query().Has("layout") {
-		conf.Layout = req.URL.Query().Get("layout")
+	if values.Has("layout") {
+		conf.Layout = values.Get("layout")
 	}
 
-	if req.URL.Query().Has("orientation") {
-		conf.Orientation = req.URL.Query().Get("orientation")
+	if values.Has("orientation") {
+		conf.Orientation = values.Get("orientation")
 	}
 
-	if req.URL.Query().Has("dashboardMode") {
-		conf.DashboardMode = req.URL.Query().Get("dashboardMode")
+	if values.Has("dashboardMode") {
+		conf.DashboardMode = values.Get("dashboardMode")
 	}
 
-	if req.URL.Query().Has("timeZone") {
-		conf.TimeZone = req.URL.Query().Get("timeZone")
+	if values.Has("timeZone") {
+		conf.TimeZone = values.Get("timeZone")
 	}
 
 	// Starting from Grafana v11.3.0, Grafana sets timezone query parameter.
 	// We should give priority to that over the plugin's config value.
 	// We will still support plugin's config parameter for backwards compatibility
-	if req.URL.Query().Has("timezone") {
-		timeZone := req.URL.Query().Get("timezone")
+	if values.Has("timezone") {
+		timeZone := values.Get("timezone")
 		if !slices.Contains([]string{"browser", "default"}, timeZone) {
 			if timeZone == "utc" {
 				timeZone = "Etc/UTC"
@@ -125,20 +139,72 @@ func (app *App) updateConfig(req *http.Request, conf *config.Config) {
 		}
 	}
 
-	if req.URL.Query().Has("timeFormat") {
-		conf.TimeFormat = req.URL.Query().Get("timeFormat")
+	if values.Has("timeFormat") {
+		conf.TimeFormat = values.Get("timeFormat")
+	}
+
+	if values.Has("includePanelID") {
+		conf.IncludePanelIDs = app.convertPanelIDs(values["includePanelID"])
+	}
+
+	if values.Has("excludePanelID") {
+		conf.ExcludePanelIDs = app.convertPanelIDs(values["excludePanelID"])
+	}
+
+	if values.Has("includePanelDataID") {
+		conf.IncludePanelDataIDs = app.convertPanelIDs(values["includePanelDataID"])
+	}
+
+	if values.Has("format") {
+		conf.Format = values.Get("format")
 	}
 
-	if req.URL.Query().Has("includePanelID") {
-		conf.IncludePanelIDs = app.convertPanelIDs(req.URL.Query()["includePanelID"])
+	if values.Has("collapsedRows") {
+		conf.CollapsedRows = values.Get("collapsedRows")
 	}
 
-	if req.URL.Query().Has("excludePanelID") {
-		conf.ExcludePanelIDs = app.convertPanelIDs(req.URL.Query()["excludePanelID"])
+	if values.Has("rowPageBreak") {
+		conf.RowPageBreak = values.Get("rowPageBreak") == "true"
 	}
 
-	if req.URL.Query().Has("includePanelDataID") {
-		conf.IncludePanelDataIDs = app.convertPanelIDs(req.URL.Query()["includePanelDataID"])
+	if values.Has("tableOfContents") {
+		conf.TableOfContents = values.Get("tableOfContents") == "true"
+	}
+
+	if values.Has("paperSize") {
+		conf.PaperSize = values.Get("paperSize")
+	}
+
+	if values.Has("margins") {
+		conf.Margins = values.Get("margins")
+	}
+
+	if values.Has("scale") {
+		// Invalid scales are rejected when validating config
+		scale, err := strconv.ParseFloat(values.Get("scale"), 64)
+		if err != nil {
+			scale = -1
+		}
+
+		conf.Scale = scale
+	}
+
+	if values.Has("pageRanges") {
+		conf.PageRanges = values.Get("pageRanges")
+	}
+
+	if values.Has("tolerateFailures") {
+		conf.TolerateFailures = values.Get("tolerateFailures") == "true"
+	}
+
+	if values.Has("maxFailedPanels") {
+		// Invalid fractions are rejected when validating config
+		fraction, err := strconv.ParseFloat(values.Get("maxFailedPanels"), 64)
+		if err != nil {
+			fraction = -1
+		}
+
+		conf.MaxFailedPanels = fraction
 	}
 }
 
@@ -180,69 +246,178 @@ func (app *App) grafanaAppURL(grafanaConfig *backend.GrafanaCfg) (string, error)
 	return strings.TrimSuffix(grafanaAppURL, "/"), nil
 }
 
-// dashboardModel fetches dashboard JSON model from Grafana API.
-func (app *App) dashboardModel(ctx context.Context, appURL, dashUID string, authHeader http.Header, values url.Values) (*dashboard.Model, error) {
-	dashURL := fmt.Sprintf("%s/api/dashboards/uid/%s", appURL, dashUID)
+// tokenAuthHeader returns the auth header that uses either user configured token
+// or service account token of the plugin.
+func (app *App) tokenAuthHeader(logger log.Logger, grafanaConfig *backend.GrafanaCfg, conf *config.Config) (http.Header, error) {
+	authHeader := http.Header{}
+
+	if conf.Token != "" {
+		logger.Debug("using user configured token")
+
+		authHeader.Add(backend.OAuthIdentityTokenHeaderName, "Bearer "+conf.Token)
+
+		return authHeader, nil
+	}
+
+	logger.Debug("using service account token")
 
-	// Create a new GET request
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dashURL, nil)
+	saToken, err := grafanaConfig.PluginAppClientSecret()
 	if err != nil {
-		return nil, fmt.Errorf("error creating request for %s: %w", dashURL, err)
+		return nil, err
 	}
 
-	// Forward auth headers
-	for name, values := range authHeader {
-		for _, value := range values {
-			req.Header.Add(name, value)
-		}
+	if saToken == "" {
+		return nil, errors.New("empty client secret")
 	}
 
-	// Make request
-	resp, err := app.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("error executing request for %s: %w", dashURL, err)
+	authHeader.Add(backend.OAuthIdentityTokenHeaderName, "Bearer "+saToken)
+
+	return authHeader, nil
+}
+
+// authHeader returns the auth header to make API requests to Grafana on behalf of
+// the user making the request.
+func (app *App) authHeader(logger log.Logger, req *http.Request, grafanaConfig *backend.GrafanaCfg, conf *config.Config) (http.Header, error) {
+	// This case is irrelevant starting from Grafana 10.4.4.
+	// This commit https://github.com/grafana/grafana/commit/56a4af87d706087ea42780a79f8043df1b5bc3ea
+	// made changes to not forward the cookies to app plugins.
+	// So we will not be able to use cookies to make requests to Grafana to fetch
+	// dashboards.
+	if req.Header.Get(backend.CookiesHeaderName) != "" {
+		logger.Debug("using user cookie")
+
+		authHeader := http.Header{}
+		authHeader.Add(backend.CookiesHeaderName, req.Header.Get(backend.CookiesHeaderName))
+
+		return authHeader, nil
+	}
+
+	return app.tokenAuthHeader(logger, grafanaConfig, conf)
+}
+
+// canViewDashboard returns true if the user making the request has permissions
+// to view the dashboard.
+func (app *App) canViewDashboard(logger log.Logger, req *http.Request, dashboardUID string, model *dashboard.Model) bool {
+	// If the required feature flags are not enabled, we cannot check permissions.
+	// In this case Grafana's API requests made with user's cookie will enforce
+	// the permissions.
+	if !app.featureTogglesEnabled(req.Context()) {
+		return true
+	}
+
+	// If dashboard is in a folder, check if user has permissions on either the dashboard
+	// or the folder.
+	resources := []authz.Resource{
+		{
+			Kind: "dashboards",
+			Attr: "uid",
+			ID:   dashboardUID,
+		},
+	}
+	if model.Meta.FolderUID != "" {
+		resources = append(resources, authz.Resource{
+			Kind: "folders",
+			Attr: "uid",
+			ID:   model.Meta.FolderUID,
+		})
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	// Check if user has access to the resource using authz client.
+	// Here we check if user has permissions to do an action "dashboards:read" on
+	// dashboards resource of a given dashboard UID
+	hasAccess, err := app.HasAccess(req, "dashboards:read", resources...)
 	if err != nil {
-		return nil, fmt.Errorf("error reading response body from %s: %w", dashURL, err)
+		logger.Error("failed to check permissions", "err", err)
+
+		return false
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(
-			"failed to fetch dashboard model: URL: %s. Status: %s, message: %s",
-			dashURL,
-			resp.Status,
-			string(body),
-		)
+	if !hasAccess {
+		logger.Error("user does not have necessary permissions to view dashboard")
+
+		return false
 	}
 
-	var model dashboard.Model
+	return true
+}
 
-	// Read data into dashboard.Model
-	err = json.Unmarshal(body, &model) //nolint:musttag
+// newDashboard returns a new dashboard of the model using the given config.
+func (app *App) newDashboard(logger log.Logger, conf *config.Config, grafanaAppURL string,
+	model *dashboard.Model, authHeader http.Header,
+) (*dashboard.Dashboard, error) {
+	return dashboard.New(
+		logger,
+		conf,
+		app.httpClient,
+		app.chromeInstance,
+		grafanaAppURL,
+		app.grafanaSemVer,
+		model,
+		authHeader,
+		app.panelCache,
+	)
+}
+
+// newReport returns a new report of the dashboard model using the given config.
+func (app *App) newReport(logger log.Logger, conf *config.Config, grafanaAppURL string,
+	model *dashboard.Model, authHeader http.Header,
+) (*report.Report, error) {
+	grafanaDashboard, err := app.newDashboard(logger, conf, grafanaAppURL, model, authHeader)
 	if err != nil {
-		return nil, fmt.Errorf("error reading response body into dashboard model: %w", err)
+		return nil, err
 	}
 
-	// Add template variables to model
-	model.Dashboard.Variables = values
+	logger.Info(fmt.Sprintf("generate report using %s chrome", app.chromeInstance.Name()))
 
-	return &model, nil
-}
+	// Make a new Report to put all PNGs into a HTML template and print it into a PDF
+	pdfReport := report.New(
+		logger,
+		conf,
+		app.httpClient,
+		app.chromeInstance,
+		app.workerPools,
+		grafanaDashboard,
+	)
 
-// handleReport handles creating a PDF report from a given dashboard UID
-// GET /api/plugins/mahendrapaipuri-dashboardreporter-app/resources/report.
-func (app *App) handleReport(w http.ResponseWriter, req *http.Request) {
-	if req.Method != http.MethodGet {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	// Archive all reports in object storage, if configured
+	if conf.S3Bucket != "" {
+		s3, err := delivery.NewS3(conf)
+		if err != nil {
+			return nil, err
+		}
 
-		return
+		pdfReport.AddSink(s3)
 	}
 
-	var err error
+	return pdfReport, nil
+}
 
+// dashboardModel fetches dashboard JSON model from Grafana API.
+func (app *App) dashboardModel(ctx context.Context, appURL, dashUID string, authHeader http.Header, values url.Values) (*dashboard.Model, error) {
+	return dashboard.FetchModel(ctx, app.httpClient, appURL, dashUID, authHeader, values)
+}
+
+// reportRequest is a report prepared from the query parameters of a request.
+type reportRequest struct {
+	report     *report.Report
+	conf       *config.Config
+	model      *dashboard.Model
+	authHeader http.Header
+	user       string
+	appURL     string
+	logger     log.Logger
+}
+
+// newReportRequest validates query parameters of req, checks that the user
+// has permissions on the dashboard and prepares a new report of it. On
+// failure, a JSON error response is written to w and false is returned.
+func (app *App) newReportRequest(w http.ResponseWriter, req *http.Request) (*reportRequest, bool) {
+	return app.newReportRequestFromQuery(w, req, req.URL.Query())
+}
+
+// newReportRequestFromQuery is like newReportRequest but reads report
+// parameters from query instead of the URL of req.
+func (app *App) newReportRequestFromQuery(w http.ResponseWriter, req *http.Request, query url.Values) (*reportRequest, bool) {
 	// Always start with an instance of current app's config
 	conf := app.conf
 
@@ -254,167 +429,132 @@ func (app *App) handleReport(w http.ResponseWriter, req *http.Request) {
 	currentUser := pluginConfig.User.Login
 
 	// Get Dashboard ID
-	dashboardUID := req.URL.Query().Get("dashUid")
+	dashboardUID := query.Get("dashUid")
 	if dashboardUID == "" {
 		ctxLogger.Debug("Query parameter dashUid not found")
-		http.Error(w, "missing dashUid query parameter", http.StatusBadRequest)
+		writeBadRequest(w, "missing dashUid query parameter")
 
-		return
+		return nil, false
+	}
+
+	if err := dashboard.NewTimeRange(query.Get("from"), query.Get("to")).Validate(); err != nil {
+		ctxLogger.Debug("invalid time range", "err", err)
+		writeBadRequest(w, err.Error())
+
+		return nil, false
 	}
 
 	// Add dash uid and user to logger
 	ctxLogger = ctxLogger.With("user", currentUser, "dash_uid", dashboardUID)
 
+	// fail writes the error response and notifies webhooks about the report
+	// that could not be generated. Model is nil when it has not been fetched
+	fail := func(err error, model *dashboard.Model) {
+		writeError(w, err)
+		app.webhooks.Notify(failedReportEvent(dashboardUID, model, err))
+	}
+
 	grafanaConfig := backend.GrafanaConfigFromContext(req.Context())
 
 	// Get Grafana App URL by looking both at passed config and user defined config
 	grafanaAppURL, err := app.grafanaAppURL(grafanaConfig)
 	if err != nil {
 		ctxLogger.Error("failed to get app URL", "err", err)
-		http.Error(w, "error generating report", http.StatusInternalServerError)
+		fail(err, nil)
 
-		return
+		return nil, false
 	}
 
 	// Update plugin's config from query params
-	app.updateConfig(req, &conf)
+	app.updateConfig(query, &conf)
 
 	// Validate new updated config
 	if err := conf.Validate(); err != nil {
 		ctxLogger.Debug("invalid config: "+conf.String(), "err", err)
-		http.Error(w, "invalid query parameters found", http.StatusBadRequest)
+		writeBadRequest(w, "invalid query parameters found: "+err.Error())
 
-		return
+		return nil, false
 	}
 
 	ctxLogger.Info("generate report using config: " + conf.String())
 
 	// authHeader is header name value pair that will be used in API requests
-	authHeader := http.Header{}
-
-	switch {
-	// This case is irrelevant starting from Grafana 10.4.4.
-	// This commit https://github.com/grafana/grafana/commit/56a4af87d706087ea42780a79f8043df1b5bc3ea
-	// made changes to not forward the cookies to app plugins.
-	// So we will not be able to use cookies to make requests to Grafana to fetch
-	// dashboards.
-	case req.Header.Get(backend.CookiesHeaderName) != "":
-		ctxLogger.Debug("using user cookie")
-
-		authHeader.Add(backend.CookiesHeaderName, req.Header.Get(backend.CookiesHeaderName))
-	case conf.Token != "":
-		ctxLogger.Debug("using user configured token")
-
-		authHeader.Add(backend.OAuthIdentityTokenHeaderName, "Bearer "+conf.Token)
-	default:
-		ctxLogger.Debug("using service account token")
-
-		saToken, err := grafanaConfig.PluginAppClientSecret()
-		if err != nil {
-			ctxLogger.Error("failed to get plugin app client secret", "err", err)
-			http.Error(w, "error generating report", http.StatusInternalServerError)
-
-			return
-		}
-
-		if saToken == "" {
-			ctxLogger.Error("failed to get plugin app client secret", "err", "empty client secret")
-			http.Error(w, "error generating report", http.StatusInternalServerError)
-
-			return
-		}
+	authHeader, err := app.authHeader(ctxLogger, req, grafanaConfig, &conf)
+	if err != nil {
+		ctxLogger.Error("failed to get plugin app client secret", "err", err)
+		fail(err, nil)
 
-		authHeader.Add(backend.OAuthIdentityTokenHeaderName, "Bearer "+saToken)
+		return nil, false
 	}
 
-	// Get dashboard JSON model from API
-	// Filter query parameters to only include template variables
-	templateVariables := app.filterTemplateVariables(req.URL.Query())
-
-	model, err := app.dashboardModel(req.Context(), grafanaAppURL, dashboardUID, authHeader, templateVariables)
+	// Get dashboard JSON model from API with the template variables and
+	// time range of query
+	model, err := app.dashboardModel(req.Context(), grafanaAppURL, dashboardUID, authHeader, app.dashboardVariables(query))
 	if err != nil {
 		ctxLogger.Error("failed to get dashboard JSON model", "err", err)
-		http.Error(w, "error generating report", http.StatusInternalServerError)
+		fail(&report.StageError{Stage: report.StageModel, Err: err}, nil)
 
-		return
+		return nil, false
 	}
 
-	// If dashboard is in a folder, check if user has permissions on either the dashboard
-	// or the folder.
-	resources := []authz.Resource{
-		{
-			Kind: "dashboards",
-			Attr: "uid",
-			ID:   dashboardUID,
-		},
+	if !app.canViewDashboard(ctxLogger, req, dashboardUID, model) {
+		fail(&report.StageError{Stage: report.StagePermission, Err: dashboard.ErrPermissionDenied}, model)
+
+		return nil, false
 	}
-	if model.Meta.FolderUID != "" {
-		resources = append(resources, authz.Resource{
-			Kind: "folders",
-			Attr: "uid",
-			ID:   model.Meta.FolderUID,
-		})
+
+	pdfReport, err := app.newReport(ctxLogger, &conf, grafanaAppURL, model, authHeader)
+	if err != nil {
+		ctxLogger.Error("failed to create a new dashboard", "err", err)
+		fail(err, model)
+
+		return nil, false
 	}
 
-	// If the required feature flags are enabled, check if user has access to the resource
-	// using authz client.
-	// Here we check if user has permissions to do an action "dashboards:read" on
-	// dashboards resource of a given dashboard UID
-	if app.featureTogglesEnabled(req.Context()) {
-		if hasAccess, err := app.HasAccess(
-			req, "dashboards:read",
-			resources...,
-		); err != nil || !hasAccess {
-			if err != nil {
-				ctxLogger.Error("failed to check permissions", "err", err)
-			} else {
-				ctxLogger.Error("user does not have necessary permissions to view dashboard")
-			}
+	return &reportRequest{
+		report:     pdfReport,
+		conf:       &conf,
+		model:      model,
+		authHeader: authHeader,
+		user:       currentUser,
+		appURL:     grafanaAppURL,
+		logger:     ctxLogger,
+	}, true
+}
 
-			http.Error(w, "permission denied", http.StatusForbidden)
+// handleReport handles creating a PDF report from a given dashboard UID
+// GET /api/plugins/mahendrapaipuri-dashboardreporter-app/resources/report.
+func (app *App) handleReport(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Code: codeMethodNotAllowed, Message: "method not allowed"})
 
-			return
-		}
+		return
 	}
 
-	grafanaDashboard, err := dashboard.New(
-		ctxLogger,
-		&conf,
-		app.httpClient,
-		app.chromeInstance,
-		grafanaAppURL,
-		app.grafanaSemVer,
-		model,
-		authHeader,
-	)
-	if err != nil {
-		ctxLogger.Error("failed to create a new dashboard", "err", err)
-		http.Error(w, "error generating report", http.StatusInternalServerError)
-
+	reportReq, ok := app.newReportRequest(w, req)
+	if !ok {
 		return
 	}
 
-	ctxLogger.Info(fmt.Sprintf("generate report using %s chrome", app.chromeInstance.Name()))
+	start := time.Now()
 
-	// Make app new Report to put all PNGs into app HTML template and print it into app PDF
-	pdfReport := report.New(
-		ctxLogger,
-		&conf,
-		app.httpClient,
-		app.chromeInstance,
-		app.workerPools,
-		grafanaDashboard,
-	)
+	// Generate report. Tasks of the report share the worker pools fairly
+	// with the reports of other users
+	ctx := worker.WithUser(req.Context(), reportReq.user, worker.Interactive)
+	err := reportReq.report.Generate(ctx, w)
 
-	// Generate report
-	if err = pdfReport.Generate(req.Context(), w); err != nil {
-		ctxLogger.Error("error generating report", "err", err)
-		http.Error(w, "error generating report", http.StatusInternalServerError)
+	event := reportEvent(reportReq.model, reportReq.report, start, err)
+	event.DownloadURL = resourceURL(reportReq.appURL, "report?"+req.URL.RawQuery)
+	app.webhooks.Notify(event)
+
+	if err != nil {
+		reportReq.logger.Error("error generating report", "err", err)
+		writeError(w, err)
 
 		return
 	}
 
-	ctxLogger.Info("report generated")
+	reportReq.logger.Info("report generated")
 }
 
 // handleHealth is an example HTTP GET resource that returns an OK response.
@@ -430,8 +570,30 @@ func (app *App) handleHealth(w http.ResponseWriter, _ *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// writeJSON writes v as JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.DefaultLogger.Error("failed to encode JSON response", "err", err)
+	}
+}
+
 // registerRoutes takes a *http.ServeMux and registers some HTTP handlers.
 func (app *App) registerRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/report", app.handleReport)
+	mux.HandleFunc("POST /report/combined", app.handleCombinedReport)
+	mux.HandleFunc("GET /panel", app.handlePanel)
 	mux.HandleFunc("/healthz", app.handleHealth)
+	mux.HandleFunc("GET /schedules", app.handleListSchedules)
+	mux.HandleFunc("POST /schedules", app.handleCreateSchedule)
+	mux.HandleFunc("GET /schedules/{id}", app.handleGetSchedule)
+	mux.HandleFunc("PUT /schedules/{id}", app.handleUpdateSchedule)
+	mux.HandleFunc("DELETE /schedules/{id}", app.handleDeleteSchedule)
+	mux.HandleFunc("GET /schedules/{id}/report", app.handleScheduleReport)
+	mux.HandleFunc("POST /jobs", app.handleCreateJob)
+	mux.HandleFunc("GET /jobs/{id}", app.handleGetJob)
+	mux.HandleFunc("DELETE /jobs/{id}", app.handleCancelJob)
+	mux.HandleFunc("GET /jobs/{id}/result", app.handleJobResult)
 }
@@ -0,0 +1,232 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed standard 5 field cron expression
+// (minute, hour, day of month, month and day of week).
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+
+	// When both day of month and day of week are restricted, a day matches
+	// if any of them matches which is the behaviour of Vixie cron.
+	domStar, dowStar bool
+}
+
+// bounds of each cron field.
+type bounds struct {
+	min, max uint
+	names    map[string]uint
+}
+
+var (
+	minutes = bounds{0, 59, nil}
+	hours   = bounds{0, 23, nil}
+	doms    = bounds{1, 31, nil}
+	months  = bounds{1, 12, map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// Both 0 and 7 are Sunday.
+	dows = bounds{0, 7, map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// Predefined cron expressions.
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Do not search for next activation time beyond this horizon. Expressions
+// like "0 0 30 2 *" never match.
+const searchHorizon = 5 * 365 * 24 * time.Hour
+
+// ParseCron parses a cron expression.
+func ParseCron(expr string) (*Cron, error) {
+	expr = strings.TrimSpace(expr)
+	if e, ok := macros[strings.ToLower(expr)]; ok {
+		expr = e
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields, found %d", expr, len(fields))
+	}
+
+	var (
+		c   Cron
+		err error
+	)
+
+	if c.minute, err = parseField(fields[0], minutes); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+
+	if c.hour, err = parseField(fields[1], hours); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+
+	if c.dom, err = parseField(fields[2], doms); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+
+	if c.month, err = parseField(fields[3], months); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+
+	if c.dow, err = parseField(fields[4], dows); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+
+	// Fold 7 into 0 for Sunday
+	if c.dow&(1<<7) > 0 {
+		c.dow |= 1
+	}
+
+	c.domStar = strings.HasPrefix(fields[2], "*")
+	c.dowStar = strings.HasPrefix(fields[4], "*")
+
+	return &c, nil
+}
+
+// parseField parses a comma separated list of ranges of a cron field into a bit set.
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+
+	for part := range strings.SplitSeq(field, ",") {
+		var (
+			start, end uint
+			err        error
+		)
+
+		rangePart := part
+		step := uint(1)
+
+		// Check for step
+		if i := strings.Index(part, "/"); i >= 0 {
+			rangePart = part[:i]
+
+			s, err := strconv.ParseUint(part[i+1:], 10, 0)
+			if err != nil || s == 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+
+			step = uint(s)
+		}
+
+		switch {
+		case rangePart == "*":
+			start, end = b.min, b.max
+		case strings.Contains(rangePart, "-"):
+			lohi := strings.SplitN(rangePart, "-", 2)
+
+			if start, err = parseValue(lohi[0], b); err != nil {
+				return 0, err
+			}
+
+			if end, err = parseValue(lohi[1], b); err != nil {
+				return 0, err
+			}
+		default:
+			if start, err = parseValue(rangePart, b); err != nil {
+				return 0, err
+			}
+
+			// A single value with a step, like 5/15, means from value to max
+			end = start
+			if step > 1 {
+				end = b.max
+			}
+		}
+
+		if start > end {
+			return 0, fmt.Errorf("invalid range %q", part)
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << v
+		}
+	}
+
+	return bits, nil
+}
+
+// parseValue parses a single value of a cron field which can be either a number or a name.
+func parseValue(s string, b bounds) (uint, error) {
+	if v, ok := b.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.ParseUint(s, 10, 0)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+
+	if uint(v) < b.min || uint(v) > b.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", v, b.min, b.max)
+	}
+
+	return uint(v), nil
+}
+
+// Next returns the first activation time strictly after t. The location of t is
+// used to evaluate the expression. A zero time is returned when no activation
+// time is found.
+func (c *Cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	horizon := t.Add(searchHorizon)
+
+	for t.Before(horizon) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+
+			continue
+		}
+
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+
+			continue
+		}
+
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+
+			continue
+		}
+
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+// dayMatches returns true if day of month and day of week of t match the expression.
+func (c *Cron) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) > 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) > 0
+
+	if c.domStar || c.dowStar {
+		return domMatch && dowMatch
+	}
+
+	return domMatch || dowMatch
+}
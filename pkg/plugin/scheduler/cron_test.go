@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCron(t *testing.T) {
+	Convey("When parsing invalid cron expressions", t, func() {
+		for _, expr := range []string{
+			"", "* * * *", "* * * * * *", "60 * * * *", "* 24 * * *", "* * 0 * *",
+			"* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "foo * * * *",
+		} {
+			_, err := ParseCron(expr)
+
+			Convey("Expression should fail: "+expr, func() {
+				So(err, ShouldNotBeNil)
+			})
+		}
+	})
+
+	Convey("When computing next activation times", t, func() {
+		// Wednesday
+		base := time.Date(2024, time.December, 11, 10, 30, 0, 0, time.UTC)
+
+		cases := map[string]struct {
+			Expr string
+			Next time.Time
+		}{
+			"every_minute": {
+				"* * * * *",
+				time.Date(2024, time.December, 11, 10, 31, 0, 0, time.UTC),
+			},
+			"hourly_macro": {
+				"@hourly",
+				time.Date(2024, time.December, 11, 11, 0, 0, 0, time.UTC),
+			},
+			"daily_at_8": {
+				"0 8 * * *",
+				time.Date(2024, time.December, 12, 8, 0, 0, 0, time.UTC),
+			},
+			"step": {
+				"*/20 * * * *",
+				time.Date(2024, time.December, 11, 10, 40, 0, 0, time.UTC),
+			},
+			"weekday_names": {
+				"0 9 * * mon-fri",
+				time.Date(2024, time.December, 12, 9, 0, 0, 0, time.UTC),
+			},
+			"sunday_as_7": {
+				"0 9 * * 7",
+				time.Date(2024, time.December, 15, 9, 0, 0, 0, time.UTC),
+			},
+			"monthly": {
+				"0 0 1 * *",
+				time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+			},
+			"month_names": {
+				"0 0 1 feb,mar *",
+				time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC),
+			},
+			"dom_or_dow": {
+				"0 0 20 * fri",
+				time.Date(2024, time.December, 13, 0, 0, 0, 0, time.UTC),
+			},
+			"leap_day": {
+				"0 0 29 2 *",
+				time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC),
+			},
+			"never": {
+				"0 0 30 2 *",
+				time.Time{},
+			},
+		}
+
+		for name, c := range cases {
+			cron, err := ParseCron(c.Expr)
+
+			Convey("Next activation should be correct: "+name, func() {
+				So(err, ShouldBeNil)
+				So(cron.Next(base), ShouldEqual, c.Next)
+			})
+		}
+	})
+
+	Convey("When computing next activation time in a time zone", t, func() {
+		loc, err := time.LoadLocation("Europe/Paris")
+		So(err, ShouldBeNil)
+
+		schedule := Schedule{Cron: "0 8 * * *", TimeZone: "Europe/Paris"}
+
+		next := schedule.Next(time.Date(2024, time.December, 11, 10, 30, 0, 0, time.UTC))
+
+		Convey("Next activation should be in the schedule's time zone", func() {
+			So(next.Equal(time.Date(2024, time.December, 12, 8, 0, 0, 0, loc)), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,104 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+// Interval at which schedules are checked for due runs.
+var tickInterval = 15 * time.Second
+
+// RunFunc generates the report of the given schedule.
+type RunFunc func(ctx context.Context, schedule Schedule) error
+
+// Scheduler runs the schedules of an org when they are due.
+type Scheduler struct {
+	logger log.Logger
+	store  *Store
+	orgID  int64
+	run    RunFunc
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New returns a new scheduler for the schedules of orgID in store.
+func New(logger log.Logger, store *Store, orgID int64, run RunFunc) *Scheduler {
+	return &Scheduler{
+		logger: logger.With("subsystem", "scheduler"),
+		store:  store,
+		orgID:  orgID,
+		run:    run,
+	}
+}
+
+// Start starts the scheduler loop in the background.
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(tickInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				s.tick(ctx, now)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the scheduler and waits for the runs in progress to finish.
+func (s *Scheduler) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+
+	s.wg.Wait()
+}
+
+// tick starts the runs of all schedules that are due at now.
+func (s *Scheduler) tick(ctx context.Context, now time.Time) {
+	for _, schedule := range s.store.List() {
+		if schedule.OrgID != s.orgID || schedule.Paused || schedule.NextRun.IsZero() || schedule.NextRun.After(now) {
+			continue
+		}
+
+		// When the plugin was not running for a while, only the latest
+		// missed activation will be run.
+		schedule, ok := s.store.claim(schedule.ID, schedule.NextRun, now)
+		if !ok {
+			continue
+		}
+
+		s.wg.Add(1)
+
+		go func() {
+			defer s.wg.Done()
+
+			logger := s.logger.With("schedule_id", schedule.ID, "dash_uid", schedule.DashboardUID)
+			logger.Info("running scheduled report", "due", schedule.LastRun)
+
+			err := s.run(ctx, schedule)
+			if err != nil {
+				logger.Error("scheduled report failed", "err", err)
+			} else {
+				logger.Info("scheduled report generated", "next_run", schedule.NextRun)
+			}
+
+			if err := s.store.finish(schedule.ID, err); err != nil {
+				logger.Error("failed to save result of scheduled report", "err", err)
+			}
+		}()
+	}
+}
@@ -0,0 +1,131 @@
+package scheduler
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStore(t *testing.T) {
+	Convey("When managing schedules in a store", t, func() {
+		path := filepath.Join(t.TempDir(), "schedules.json")
+
+		store, err := OpenStore(path)
+		So(err, ShouldBeNil)
+
+		schedule, err := store.Create(Schedule{OrgID: 1, DashboardUID: "dash", Cron: "@daily"})
+		So(err, ShouldBeNil)
+
+		Convey("Created schedule should have an ID and next run", func() {
+			So(schedule.ID, ShouldNotBeEmpty)
+			So(schedule.NextRun.IsZero(), ShouldBeFalse)
+		})
+
+		Convey("Schedules should be persisted", func() {
+			// Forget about the store to force loading from file
+			storesMu.Lock()
+			delete(stores, path)
+			storesMu.Unlock()
+
+			reopened, err := OpenStore(path)
+			So(err, ShouldBeNil)
+
+			got, err := reopened.Get(schedule.ID)
+			So(err, ShouldBeNil)
+			So(got.DashboardUID, ShouldEqual, "dash")
+		})
+
+		Convey("Update should keep store managed fields", func() {
+			updated, err := store.Update(Schedule{ID: schedule.ID, OrgID: 2, DashboardUID: "other", Cron: "@hourly"})
+			So(err, ShouldBeNil)
+			So(updated.OrgID, ShouldEqual, 1)
+			So(updated.DashboardUID, ShouldEqual, "other")
+			So(updated.CreatedAt, ShouldEqual, schedule.CreatedAt)
+		})
+
+		Convey("Deleted schedules should not be found", func() {
+			So(store.Delete(schedule.ID), ShouldBeNil)
+
+			_, err := store.Get(schedule.ID)
+			So(err, ShouldEqual, ErrNotFound)
+			So(store.Delete(schedule.ID), ShouldEqual, ErrNotFound)
+		})
+
+		Convey("Changes should not be applied when they cannot be persisted", func() {
+			// Parent of schedules file is a regular file
+			store.path = filepath.Join(path, "schedules.json")
+
+			_, err := store.Create(Schedule{OrgID: 1, DashboardUID: "new", Cron: "@daily"})
+			So(err, ShouldNotBeNil)
+			So(store.List(), ShouldHaveLength, 1)
+
+			_, err = store.Update(Schedule{ID: schedule.ID, DashboardUID: "other", Cron: "@hourly"})
+			So(err, ShouldNotBeNil)
+
+			got, err := store.Get(schedule.ID)
+			So(err, ShouldBeNil)
+			So(got.DashboardUID, ShouldEqual, "dash")
+
+			So(store.Delete(schedule.ID), ShouldNotBeNil)
+			So(store.List(), ShouldHaveLength, 1)
+		})
+
+		Convey("An activation should be claimed only once", func() {
+			_, ok := store.claim(schedule.ID, schedule.NextRun, schedule.NextRun)
+			So(ok, ShouldBeTrue)
+
+			_, ok = store.claim(schedule.ID, schedule.NextRun, schedule.NextRun)
+			So(ok, ShouldBeFalse)
+		})
+	})
+}
+
+func TestScheduler(t *testing.T) {
+	Convey("When scheduler ticks", t, func() {
+		store, err := OpenStore(filepath.Join(t.TempDir(), "schedules.json"))
+		So(err, ShouldBeNil)
+
+		due, err := store.Create(Schedule{OrgID: 1, DashboardUID: "due", Cron: "* * * * *"})
+		So(err, ShouldBeNil)
+
+		_, err = store.Create(Schedule{OrgID: 1, DashboardUID: "paused", Cron: "* * * * *", Paused: true})
+		So(err, ShouldBeNil)
+
+		_, err = store.Create(Schedule{OrgID: 2, DashboardUID: "other-org", Cron: "* * * * *"})
+		So(err, ShouldBeNil)
+
+		var (
+			runs   atomic.Int32
+			runUID atomic.Value
+		)
+
+		s := New(log.NewNullLogger(), store, 1, func(_ context.Context, schedule Schedule) error {
+			runUID.Store(schedule.DashboardUID)
+			runs.Add(1)
+
+			return nil
+		})
+
+		// Tick twice at the same instant after the schedule is due
+		now := due.NextRun.Add(time.Second)
+		s.tick(t.Context(), now)
+		s.tick(t.Context(), now)
+		s.Stop()
+
+		Convey("Only the due schedule of the org should run once", func() {
+			So(runs.Load(), ShouldEqual, 1)
+			So(runUID.Load(), ShouldEqual, "due")
+
+			got, err := store.Get(due.ID)
+			So(err, ShouldBeNil)
+			So(got.LastRun, ShouldEqual, due.NextRun)
+			So(got.NextRun.After(now), ShouldBeTrue)
+			So(got.LastError, ShouldBeEmpty)
+		})
+	})
+}
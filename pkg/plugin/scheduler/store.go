@@ -0,0 +1,247 @@
+package scheduler
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when schedule does not exist in store.
+var ErrNotFound = errors.New("schedule not found")
+
+// Stores are shared between app instances of the same process. When plugin
+// settings are updated, a new app instance is created while the old one
+// is still alive for a while and both must see the same schedules.
+var (
+	storesMu sync.Mutex
+	stores   = map[string]*Store{}
+)
+
+// Store is a file backed store of schedules.
+type Store struct {
+	mu        sync.RWMutex
+	path      string
+	schedules map[string]Schedule
+}
+
+// OpenStore returns the store persisted at path. Schedules are loaded from
+// the file if it exists.
+func OpenStore(path string) (*Store, error) {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+
+	if s, ok := stores[path]; ok {
+		return s, nil
+	}
+
+	s := &Store{
+		path:      path,
+		schedules: make(map[string]Schedule),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read schedules file: %w", err)
+	}
+
+	if len(data) > 0 {
+		var schedules []Schedule
+		if err := json.Unmarshal(data, &schedules); err != nil {
+			return nil, fmt.Errorf("failed to decode schedules file: %w", err)
+		}
+
+		for _, schedule := range schedules {
+			s.schedules[schedule.ID] = schedule
+		}
+	}
+
+	stores[path] = s
+
+	return s, nil
+}
+
+// List returns all schedules sorted by their creation time.
+func (s *Store) List() []Schedule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	schedules := make([]Schedule, 0, len(s.schedules))
+	for _, schedule := range s.schedules {
+		schedules = append(schedules, schedule)
+	}
+
+	slices.SortFunc(schedules, func(a, b Schedule) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	return schedules
+}
+
+// Get returns schedule with given ID.
+func (s *Store) Get(id string) (Schedule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	schedule, ok := s.schedules[id]
+	if !ok {
+		return Schedule{}, ErrNotFound
+	}
+
+	return schedule, nil
+}
+
+// Create adds a new schedule to store and returns it.
+func (s *Store) Create(schedule Schedule) (Schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	schedule.ID = strings.ToLower(rand.Text())
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+	schedule.LastRun = time.Time{}
+	schedule.LastError = ""
+	schedule.NextRun = schedule.Next(now)
+
+	s.schedules[schedule.ID] = schedule
+
+	// Schedule that is not persisted must not run
+	if err := s.save(); err != nil {
+		delete(s.schedules, schedule.ID)
+
+		return Schedule{}, err
+	}
+
+	return schedule, nil
+}
+
+// Update replaces the user defined fields of an existing schedule.
+func (s *Store) Update(schedule Schedule) (Schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.schedules[schedule.ID]
+	if !ok {
+		return Schedule{}, ErrNotFound
+	}
+
+	// Keep the fields that are managed by store
+	schedule.OrgID = current.OrgID
+	schedule.CreatedBy = current.CreatedBy
+	schedule.CreatedAt = current.CreatedAt
+	schedule.LastRun = current.LastRun
+	schedule.LastError = current.LastError
+	schedule.UpdatedAt = time.Now()
+	schedule.NextRun = schedule.Next(schedule.UpdatedAt)
+
+	s.schedules[schedule.ID] = schedule
+
+	// Changes that are not persisted must not be applied
+	if err := s.save(); err != nil {
+		s.schedules[schedule.ID] = current
+
+		return Schedule{}, err
+	}
+
+	return schedule, nil
+}
+
+// Delete removes a schedule from store.
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.schedules[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	delete(s.schedules, id)
+
+	// Schedule that is still persisted must keep running
+	if err := s.save(); err != nil {
+		s.schedules[id] = current
+
+		return err
+	}
+
+	return nil
+}
+
+// claim marks the schedule as running for activation at due time. It returns
+// false if the activation has already been claimed.
+func (s *Store) claim(id string, due, now time.Time) (Schedule, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule, ok := s.schedules[id]
+	if !ok || schedule.Paused || !schedule.LastRun.Before(due) {
+		return Schedule{}, false
+	}
+
+	schedule.LastRun = due
+	schedule.NextRun = schedule.Next(now)
+	s.schedules[id] = schedule
+
+	return schedule, true
+}
+
+// finish records the result of the schedule run.
+func (s *Store) finish(id string, runErr error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule, ok := s.schedules[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	schedule.LastError = ""
+	if runErr != nil {
+		schedule.LastError = runErr.Error()
+	}
+
+	s.schedules[id] = schedule
+
+	return s.save()
+}
+
+// save writes schedules to the file atomically.
+func (s *Store) save() error {
+	schedules := make([]Schedule, 0, len(s.schedules))
+	for _, schedule := range s.schedules {
+		schedules = append(schedules, schedule)
+	}
+
+	data, err := json.MarshalIndent(schedules, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode schedules: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o750); err != nil {
+		return fmt.Errorf("failed to create schedules directory: %w", err)
+	}
+
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write schedules file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("failed to write schedules file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+)
+
+// Schedule represents a report that is generated periodically.
+type Schedule struct {
+	ID           string              `json:"id"`
+	OrgID        int64               `json:"orgId"`
+	Name         string              `json:"name"`
+	DashboardUID string              `json:"dashUid"`
+	Cron         string              `json:"cron"`
+	TimeZone     string              `json:"timeZone,omitempty"`
+	Paused       bool                `json:"paused"`
+	From         string              `json:"from,omitempty"`
+	To           string              `json:"to,omitempty"`
+	Variables    map[string][]string `json:"variables,omitempty"`
+	// Overrides of plugin's report settings. They take the same values
+	// as the query parameters of report API.
+	Options map[string][]string `json:"options,omitempty"`
+	// Email addresses to which the report is sent
+	Recipients []string  `json:"recipients,omitempty"`
+	CreatedBy  string    `json:"createdBy"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+	LastRun    time.Time `json:"lastRun,omitzero"`
+	LastError  string    `json:"lastError,omitempty"`
+	NextRun    time.Time `json:"nextRun,omitzero"`
+}
+
+// Validate checks if the schedule is valid.
+func (s *Schedule) Validate() error {
+	if s.DashboardUID == "" {
+		return errors.New("dashboard UID is missing")
+	}
+
+	if _, err := ParseCron(s.Cron); err != nil {
+		return fmt.Errorf("invalid cron expression: %w", err)
+	}
+
+	if _, err := time.LoadLocation(s.TimeZone); err != nil {
+		return fmt.Errorf("invalid time zone: %w", err)
+	}
+
+	if err := dashboard.NewTimeRange(s.From, s.To).Validate(); err != nil {
+		return err
+	}
+
+	for _, recipient := range s.Recipients {
+		if _, err := mail.ParseAddress(recipient); err != nil {
+			return fmt.Errorf("invalid recipient %q: %w", recipient, err)
+		}
+	}
+
+	return nil
+}
+
+// Next returns the next activation time of schedule after t.
+func (s *Schedule) Next(t time.Time) time.Time {
+	c, err := ParseCron(s.Cron)
+	if err != nil {
+		return time.Time{}
+	}
+
+	// Empty time zone will give us UTC. Use local time zone of server in that case
+	loc := time.Local
+
+	if s.TimeZone != "" {
+		if l, err := time.LoadLocation(s.TimeZone); err == nil {
+			loc = l
+		}
+	}
+
+	return c.Next(t.In(loc))
+}
+
+// Query returns the schedule as query parameters of report API.
+func (s *Schedule) Query() url.Values {
+	values := url.Values{}
+
+	for name, v := range s.Options {
+		values[name] = v
+	}
+
+	for name, v := range s.Variables {
+		if !strings.HasPrefix(name, "var-") {
+			name = "var-" + name
+		}
+
+		values[name] = v
+	}
+
+	if s.From != "" {
+		values.Set("from", s.From)
+	}
+
+	if s.To != "" {
+		values.Set("to", s.To)
+	}
+
+	values.Set("dashUid", s.DashboardUID)
+
+	return values
+}
@@ -0,0 +1,448 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/delivery"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/report"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/scheduler"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/worker"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+// Roles that are allowed to manage schedules.
+var scheduleManagerRoles = []string{"Admin", "Editor"}
+
+// startScheduler starts the scheduler of reports of the current org.
+func (app *App) startScheduler(ctx context.Context) error {
+	if app.conf.StoragePath == "" {
+		return errors.New("storage path is not configured")
+	}
+
+	store, err := scheduler.OpenStore(filepath.Join(app.conf.StoragePath, fmt.Sprintf("schedules-%d.json", app.orgID)))
+	if err != nil {
+		return err
+	}
+
+	app.scheduleStore = store
+	app.scheduler = scheduler.New(app.ctxLogger, store, app.orgID, app.runSchedule)
+	app.scheduler.Start(ctx)
+
+	return nil
+}
+
+// scheduleReportPath returns the path where the latest report of a schedule is saved.
+func (app *App) scheduleReportPath(id string) string {
+	return filepath.Join(app.conf.StoragePath, "reports", id+".pdf")
+}
+
+// runSchedule generates the report of a schedule and saves it in the storage path.
+func (app *App) runSchedule(ctx context.Context, schedule scheduler.Schedule) (err error) {
+	// Always start with an instance of current app's config
+	conf := app.conf
+
+	ctxLogger := app.ctxLogger.With("schedule_id", schedule.ID, "dash_uid", schedule.DashboardUID)
+
+	query := schedule.Query()
+
+	var (
+		grafanaAppURL string
+		model         *dashboard.Model
+		pdfReport     *report.Report
+	)
+
+	start := time.Now()
+
+	// Failures before the report could be generated are notified as well
+	defer func() {
+		var event delivery.Event
+		if pdfReport != nil {
+			event = reportEvent(model, pdfReport, start, err)
+			event.DownloadURL = resourceURL(grafanaAppURL, "schedules/"+schedule.ID+"/report")
+		} else {
+			event = failedReportEvent(schedule.DashboardUID, model, err)
+		}
+
+		event.ScheduleID = schedule.ID
+		app.webhooks.Notify(event)
+	}()
+
+	// Update plugin's config from schedule's options
+	app.updateConfig(query, &conf)
+
+	if err := conf.Validate(); err != nil {
+		return fmt.Errorf("invalid schedule options: %w", err)
+	}
+
+	// There is no user behind a scheduled report. So always use plugin's
+	// token and Grafana config that we got when creating the app instance
+	grafanaAppURL, err = app.grafanaAppURL(app.grafanaConfig)
+	if err != nil {
+		return fmt.Errorf("failed to get app URL: %w", err)
+	}
+
+	authHeader, err := app.tokenAuthHeader(ctxLogger, app.grafanaConfig, &conf)
+	if err != nil {
+		return fmt.Errorf("failed to get plugin app client secret: %w", err)
+	}
+
+	model, err = app.dashboardModel(ctx, grafanaAppURL, schedule.DashboardUID, authHeader, app.dashboardVariables(query))
+	if err != nil {
+		return fmt.Errorf("failed to get dashboard JSON model: %w", err)
+	}
+
+	pdfReport, err = app.newReport(ctxLogger, &conf, grafanaAppURL, model, authHeader)
+	if err != nil {
+		return fmt.Errorf("failed to create a new dashboard: %w", err)
+	}
+
+	reportPath := app.scheduleReportPath(schedule.ID)
+	if err := os.MkdirAll(filepath.Dir(reportPath), 0o750); err != nil {
+		return fmt.Errorf("failed to create reports directory: %w", err)
+	}
+
+	// Write to a temporary file so that the previous report stays available
+	// until the new one is complete
+	f, err := os.CreateTemp(filepath.Dir(reportPath), schedule.ID+"-*.pdf")
+	if err != nil {
+		return fmt.Errorf("failed to create report file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	// Reports of users waiting for them are served before scheduled ones,
+	// which still get a share of workers
+	if err := pdfReport.Generate(worker.WithUser(ctx, schedule.CreatedBy, worker.Scheduled), f); err != nil {
+		f.Close()
+
+		return fmt.Errorf("error generating report: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write report file: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), reportPath); err != nil {
+		return fmt.Errorf("failed to save report file: %w", err)
+	}
+
+	if len(schedule.Recipients) == 0 {
+		return nil
+	}
+
+	pdf, err := os.ReadFile(reportPath)
+	if err != nil {
+		return fmt.Errorf("failed to read report file: %w", err)
+	}
+
+	if err := app.emailReport(ctx, &conf, pdfReport, model.Dashboard.Title+".pdf", pdf, schedule.Recipients); err != nil {
+		return fmt.Errorf("failed to email report: %w", err)
+	}
+
+	ctxLogger.Info("report emailed", "recipients", len(schedule.Recipients))
+
+	return nil
+}
+
+// scheduleFromRequest decodes and validates the schedule in the request body.
+func (app *App) scheduleFromRequest(req *http.Request) (scheduler.Schedule, error) {
+	var schedule scheduler.Schedule
+
+	if err := json.NewDecoder(req.Body).Decode(&schedule); err != nil {
+		return scheduler.Schedule{}, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	if err := schedule.Validate(); err != nil {
+		return scheduler.Schedule{}, err
+	}
+
+	if len(schedule.Recipients) > 0 && app.conf.SMTPHost == "" {
+		return scheduler.Schedule{}, errors.New("email delivery is not configured")
+	}
+
+	// Ensure that report options of the schedule are valid
+	conf := app.conf
+	app.updateConfig(schedule.Query(), &conf)
+
+	if err := conf.Validate(); err != nil {
+		return scheduler.Schedule{}, fmt.Errorf("invalid report options: %w", err)
+	}
+
+	// Latest report of schedules is always saved and emailed as PDF
+	if conf.Format != report.FormatPDF {
+		return scheduler.Schedule{}, errors.New("scheduled reports only support pdf format")
+	}
+
+	return schedule, nil
+}
+
+// scheduleManager returns the logger of the current request when user is allowed
+// to manage schedules. If not, an error response is written and nil is returned.
+func (app *App) scheduleManager(w http.ResponseWriter, req *http.Request) log.Logger {
+	ctxLogger := log.DefaultLogger.FromContext(req.Context())
+
+	if app.scheduleStore == nil {
+		http.Error(w, "report scheduler is not available", http.StatusServiceUnavailable)
+
+		return nil
+	}
+
+	user := backend.PluginConfigFromContext(req.Context()).User
+	if user == nil || !slices.Contains(scheduleManagerRoles, user.Role) {
+		http.Error(w, "permission denied", http.StatusForbidden)
+
+		return nil
+	}
+
+	return ctxLogger.With("user", user.Login)
+}
+
+// checkScheduleDashboard verifies that the user can view the dashboard of the schedule.
+func (app *App) checkScheduleDashboard(logger log.Logger, req *http.Request, schedule scheduler.Schedule) (int, error) {
+	conf := app.conf
+
+	grafanaConfig := backend.GrafanaConfigFromContext(req.Context())
+
+	grafanaAppURL, err := app.grafanaAppURL(grafanaConfig)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to get app URL: %w", err)
+	}
+
+	authHeader, err := app.authHeader(logger, req, grafanaConfig, &conf)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to get plugin app client secret: %w", err)
+	}
+
+	model, err := app.dashboardModel(req.Context(), grafanaAppURL, schedule.DashboardUID, authHeader, url.Values{})
+	if errors.Is(err, dashboard.ErrPermissionDenied) {
+		return http.StatusForbidden, errors.New("permission denied")
+	} else if err != nil {
+		return http.StatusBadRequest, fmt.Errorf("failed to get dashboard JSON model: %w", err)
+	}
+
+	if !app.canViewDashboard(logger, req, schedule.DashboardUID, model) {
+		return http.StatusForbidden, errors.New("permission denied")
+	}
+
+	return http.StatusOK, nil
+}
+
+// getSchedule returns the schedule with the ID in request path. Reports of
+// schedules are generated with plugin's token, so the user must be able to view
+// the dashboard of the schedule as well. If not, an error response is written.
+func (app *App) getSchedule(w http.ResponseWriter, req *http.Request, logger log.Logger) (scheduler.Schedule, bool) {
+	schedule, err := app.scheduleStore.Get(req.PathValue("id"))
+	if err != nil || schedule.OrgID != app.orgID {
+		http.Error(w, "schedule not found", http.StatusNotFound)
+
+		return scheduler.Schedule{}, false
+	}
+
+	if status, err := app.checkScheduleDashboard(logger, req, schedule); err != nil {
+		logger.Error("failed to verify dashboard of schedule", "schedule_id", schedule.ID, "dash_uid", schedule.DashboardUID, "err", err)
+		http.Error(w, err.Error(), status)
+
+		return scheduler.Schedule{}, false
+	}
+
+	return schedule, true
+}
+
+// handleListSchedules returns the schedules of current org whose dashboards
+// can be viewed by the user
+// GET /api/plugins/mahendrapaipuri-dashboardreporter-app/resources/schedules.
+func (app *App) handleListSchedules(w http.ResponseWriter, req *http.Request) {
+	ctxLogger := app.scheduleManager(w, req)
+	if ctxLogger == nil {
+		return
+	}
+
+	schedules := []scheduler.Schedule{}
+
+	for _, schedule := range app.scheduleStore.List() {
+		if schedule.OrgID != app.orgID {
+			continue
+		}
+
+		if _, err := app.checkScheduleDashboard(ctxLogger, req, schedule); err != nil {
+			ctxLogger.Debug("skipping schedule", "schedule_id", schedule.ID, "dash_uid", schedule.DashboardUID, "err", err)
+
+			continue
+		}
+
+		schedules = append(schedules, schedule)
+	}
+
+	writeJSON(w, http.StatusOK, schedules)
+}
+
+// handleCreateSchedule creates a new schedule
+// POST /api/plugins/mahendrapaipuri-dashboardreporter-app/resources/schedules.
+func (app *App) handleCreateSchedule(w http.ResponseWriter, req *http.Request) {
+	ctxLogger := app.scheduleManager(w, req)
+	if ctxLogger == nil {
+		return
+	}
+
+	schedule, err := app.scheduleFromRequest(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	if status, err := app.checkScheduleDashboard(ctxLogger, req, schedule); err != nil {
+		ctxLogger.Error("failed to verify dashboard of schedule", "dash_uid", schedule.DashboardUID, "err", err)
+		http.Error(w, err.Error(), status)
+
+		return
+	}
+
+	user := backend.PluginConfigFromContext(req.Context()).User
+
+	schedule.OrgID = app.orgID
+	schedule.CreatedBy = user.Login
+
+	if schedule, err = app.scheduleStore.Create(schedule); err != nil {
+		ctxLogger.Error("failed to create schedule", "err", err)
+		http.Error(w, "failed to create schedule", http.StatusInternalServerError)
+
+		return
+	}
+
+	ctxLogger.Info("schedule created", "schedule_id", schedule.ID, "dash_uid", schedule.DashboardUID)
+
+	writeJSON(w, http.StatusCreated, schedule)
+}
+
+// handleGetSchedule returns a schedule
+// GET /api/plugins/mahendrapaipuri-dashboardreporter-app/resources/schedules/{id}.
+func (app *App) handleGetSchedule(w http.ResponseWriter, req *http.Request) {
+	ctxLogger := app.scheduleManager(w, req)
+	if ctxLogger == nil {
+		return
+	}
+
+	if schedule, ok := app.getSchedule(w, req, ctxLogger); ok {
+		writeJSON(w, http.StatusOK, schedule)
+	}
+}
+
+// handleUpdateSchedule updates a schedule
+// PUT /api/plugins/mahendrapaipuri-dashboardreporter-app/resources/schedules/{id}.
+func (app *App) handleUpdateSchedule(w http.ResponseWriter, req *http.Request) {
+	ctxLogger := app.scheduleManager(w, req)
+	if ctxLogger == nil {
+		return
+	}
+
+	current, ok := app.getSchedule(w, req, ctxLogger)
+	if !ok {
+		return
+	}
+
+	schedule, err := app.scheduleFromRequest(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	if status, err := app.checkScheduleDashboard(ctxLogger, req, schedule); err != nil {
+		ctxLogger.Error("failed to verify dashboard of schedule", "dash_uid", schedule.DashboardUID, "err", err)
+		http.Error(w, err.Error(), status)
+
+		return
+	}
+
+	schedule.ID = current.ID
+
+	if schedule, err = app.scheduleStore.Update(schedule); err != nil {
+		ctxLogger.Error("failed to update schedule", "schedule_id", current.ID, "err", err)
+		http.Error(w, "failed to update schedule", http.StatusInternalServerError)
+
+		return
+	}
+
+	ctxLogger.Info("schedule updated", "schedule_id", schedule.ID)
+
+	writeJSON(w, http.StatusOK, schedule)
+}
+
+// handleDeleteSchedule deletes a schedule
+// DELETE /api/plugins/mahendrapaipuri-dashboardreporter-app/resources/schedules/{id}.
+func (app *App) handleDeleteSchedule(w http.ResponseWriter, req *http.Request) {
+	ctxLogger := app.scheduleManager(w, req)
+	if ctxLogger == nil {
+		return
+	}
+
+	schedule, ok := app.getSchedule(w, req, ctxLogger)
+	if !ok {
+		return
+	}
+
+	if err := app.scheduleStore.Delete(schedule.ID); err != nil {
+		ctxLogger.Error("failed to delete schedule", "schedule_id", schedule.ID, "err", err)
+		http.Error(w, "failed to delete schedule", http.StatusInternalServerError)
+
+		return
+	}
+
+	// Remove the latest report of the schedule as well
+	if err := os.Remove(app.scheduleReportPath(schedule.ID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		ctxLogger.Error("failed to remove report of schedule", "schedule_id", schedule.ID, "err", err)
+	}
+
+	ctxLogger.Info("schedule deleted", "schedule_id", schedule.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleScheduleReport returns the latest report generated by a schedule
+// GET /api/plugins/mahendrapaipuri-dashboardreporter-app/resources/schedules/{id}/report.
+func (app *App) handleScheduleReport(w http.ResponseWriter, req *http.Request) {
+	ctxLogger := app.scheduleManager(w, req)
+	if ctxLogger == nil {
+		return
+	}
+
+	schedule, ok := app.getSchedule(w, req, ctxLogger)
+	if !ok {
+		return
+	}
+
+	f, err := os.Open(app.scheduleReportPath(schedule.ID))
+	if err != nil {
+		http.Error(w, "report has not been generated yet", http.StatusNotFound)
+
+		return
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		http.Error(w, "failed to read report", http.StatusInternalServerError)
+
+		return
+	}
+
+	name := schedule.Name
+	if name == "" {
+		name = schedule.DashboardUID
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename*=UTF-8''%s.pdf`, url.PathEscape(name)))
+	http.ServeContent(w, req, "", stat.ModTime(), f)
+}
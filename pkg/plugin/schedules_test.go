@@ -0,0 +1,167 @@
+package plugin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/chrome"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/scheduler"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/worker"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestScheduleAccess(t *testing.T) {
+	Convey("When a schedule manager cannot view the dashboard of a schedule", t, func() {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/dashboards/uid/private":
+				http.Error(w, `{"message": "access denied"}`, http.StatusForbidden)
+			default:
+				w.Write([]byte(`{"dashboard": {"uid": "public", "title": "Public"}}`))
+			}
+		}))
+		defer ts.Close()
+
+		dir := t.TempDir()
+
+		store, err := scheduler.OpenStore(filepath.Join(dir, "schedules.json"))
+		So(err, ShouldBeNil)
+
+		private, err := store.Create(scheduler.Schedule{OrgID: 1, DashboardUID: "private", Cron: "0 8 * * *"})
+		So(err, ShouldBeNil)
+
+		public, err := store.Create(scheduler.Schedule{OrgID: 1, DashboardUID: "public", Cron: "0 8 * * *"})
+		So(err, ShouldBeNil)
+
+		app := &App{
+			conf:          config.Config{StoragePath: dir},
+			httpClient:    ts.Client(),
+			scheduleStore: store,
+			orgID:         1,
+			grafanaSemVer: "v11.4.0",
+		}
+
+		// Report of schedule has been generated with plugin's token
+		So(os.MkdirAll(filepath.Join(dir, "reports"), 0o750), ShouldBeNil)
+		So(os.WriteFile(app.scheduleReportPath(private.ID), []byte("%PDF"), 0o600), ShouldBeNil)
+
+		ctx := backend.WithGrafanaConfig(t.Context(), backend.NewGrafanaCfg(map[string]string{
+			backend.AppURL: ts.URL,
+		}))
+		ctx = backend.WithPluginContext(ctx, backend.PluginContext{User: &backend.User{Login: "foo", Role: "Editor"}})
+
+		newRequest := func(path, id string) *http.Request {
+			req := httptest.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+			req.Header.Set(backend.CookiesHeaderName, "grafana_session=foo")
+			req.SetPathValue("id", id)
+
+			return req
+		}
+
+		Convey("The report of schedule should not be served", func() {
+			rec := httptest.NewRecorder()
+			app.handleScheduleReport(rec, newRequest("/schedules/"+private.ID+"/report", private.ID))
+
+			So(rec.Code, ShouldEqual, http.StatusForbidden)
+			So(rec.Body.String(), ShouldNotContainSubstring, "%PDF")
+		})
+
+		Convey("The schedule should not be returned", func() {
+			rec := httptest.NewRecorder()
+			app.handleGetSchedule(rec, newRequest("/schedules/"+private.ID, private.ID))
+
+			So(rec.Code, ShouldEqual, http.StatusForbidden)
+
+			rec = httptest.NewRecorder()
+			app.handleGetSchedule(rec, newRequest("/schedules/"+public.ID, public.ID))
+
+			So(rec.Code, ShouldEqual, http.StatusOK)
+		})
+
+		Convey("The schedule should not be listed", func() {
+			rec := httptest.NewRecorder()
+			app.handleListSchedules(rec, newRequest("/schedules", ""))
+
+			var schedules []scheduler.Schedule
+			So(json.Unmarshal(rec.Body.Bytes(), &schedules), ShouldBeNil)
+			So(schedules, ShouldHaveLength, 1)
+			So(schedules[0].ID, ShouldEqual, public.ID)
+		})
+	})
+}
+
+func TestRunScheduleTimeRange(t *testing.T) {
+	Convey("When the report of a schedule is generated", t, func() {
+		var renderQuery url.Values
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/dashboards/uid/abc":
+				w.Write([]byte(`{"dashboard": {"uid": "abc", "title": "Ops", "panels": [
+					{"id": 2, "type": "timeseries", "title": "CPU", "gridPos": {"h": 8, "w": 12, "x": 0, "y": 0}}
+				]}}`))
+			case "/render/d-solo/abc/_":
+				renderQuery = r.URL.Query()
+
+				w.Header().Set("Content-Type", "image/png")
+				w.Write([]byte("\x89PNG\r\n\x1a\nfake"))
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		defer ts.Close()
+
+		conf := config.Config{
+			AppURL:         ts.URL,
+			Token:          "token",
+			StoragePath:    t.TempDir(),
+			Theme:          "light",
+			Orientation:    "portrait",
+			Layout:         "simple",
+			DashboardMode:  "default",
+			PanelDiscovery: "model",
+		}
+		So(conf.Validate(), ShouldBeNil)
+
+		// Browser is unreachable so that report fails once panels are fetched
+		chromeInstance, err := chrome.NewRemoteBrowserInstance(t.Context(), log.NewNullLogger(), "ws://127.0.0.1:1")
+		So(err, ShouldBeNil)
+
+		defer chromeInstance.Close(log.NewNullLogger())
+
+		app := &App{
+			conf:           conf,
+			httpClient:     ts.Client(),
+			chromeInstance: chromeInstance,
+			ctxLogger:      log.NewNullLogger(),
+			grafanaSemVer:  "v11.4.0",
+			workerPools: worker.Pools{
+				worker.Browser:  worker.New(t.Context(), worker.Browser, 1, 0),
+				worker.Renderer: worker.New(t.Context(), worker.Renderer, 1, 0),
+			},
+		}
+
+		schedule := scheduler.Schedule{
+			ID:           "daily",
+			DashboardUID: "abc",
+			From:         "2024-01-01T00:00:00.000Z",
+			To:           "2024-01-02T00:00:00.000Z",
+		}
+
+		// Report fails as browser is unreachable
+		So(app.runSchedule(t.Context(), schedule), ShouldNotBeNil)
+
+		Convey("Panels should be rendered with the time range of the schedule", func() {
+			So(renderQuery.Get("from"), ShouldEqual, "2024-01-01T00:00:00.000Z")
+			So(renderQuery.Get("to"), ShouldEqual, "2024-01-02T00:00:00.000Z")
+		})
+	})
+}
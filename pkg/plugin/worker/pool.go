@@ -1,14 +1,161 @@
 package worker
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"runtime"
+	"slices"
+	"sync"
 
-	"golang.org/x/net/context"
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/metrics"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
+var (
+	// ErrQueueFull is returned when a task is submitted to a pool whose
+	// queue is full.
+	ErrQueueFull = errors.New("server busy: too many queued tasks")
+
+	// ErrClosed is returned when a task is submitted to a pool that is done.
+	ErrClosed = errors.New("worker pool is closed")
+)
+
+// QueueFullError is the error of tasks rejected by a pool whose queue is
+// full. It matches ErrQueueFull.
+type QueueFullError struct {
+	// Position in queue the first task would have had
+	Position int
+}
+
+func (e *QueueFullError) Error() string {
+	return fmt.Sprintf("%s: %d tasks ahead", ErrQueueFull, e.Position-1)
+}
+
+func (e *QueueFullError) Is(target error) bool {
+	return target == ErrQueueFull
+}
+
+// Priority is the priority class of tasks. Queued tasks of a higher class are
+// run before the ones of a lower class, except that a lower class takes a turn
+// after priorityShare tasks of higher classes have run while it waited.
+type Priority int
+
+const (
+	// Interactive tasks are run for users waiting for their reports.
+	Interactive Priority = iota
+	// Scheduled tasks are run for reports of schedules.
+	Scheduled
+
+	numPriorities = int(Scheduled) + 1
+)
+
+// priorityShare is the number of tasks of higher classes that are run while a
+// lower class has queued tasks before the lower class takes a turn, so that
+// scheduled reports still make progress when interactive ones keep workers
+// busy.
+const priorityShare = 4
+
+type ownerKey struct{}
+
+// owner is the user and priority class of tasks.
+type owner struct {
+	user     string
+	priority Priority
+}
+
+// WithUser returns a copy of ctx in which tasks submitted to pools are run on
+// behalf of user with given priority. Tasks submitted without user share the
+// queue of an anonymous user in Interactive class.
+func WithUser(ctx context.Context, user string, priority Priority) context.Context {
+	return context.WithValue(ctx, ownerKey{}, owner{user, priority})
+}
+
+// ownerFromContext returns the owner of tasks submitted with ctx.
+func ownerFromContext(ctx context.Context) owner {
+	o, _ := ctx.Value(ownerKey{}).(owner)
+	if o.priority < 0 || int(o.priority) >= numPriorities {
+		o.priority = Interactive
+	}
+
+	return o
+}
+
+// queue is the queue of a priority class. Users with queued tasks take turns
+// so that a user with many tasks cannot starve the others.
+type queue struct {
+	// Users with queued tasks in the order of their turns
+	users []string
+	tasks map[string][]func()
+	size  int
+}
+
+// push queues f of user.
+func (q *queue) push(user string, f func()) {
+	if q.tasks == nil {
+		q.tasks = make(map[string][]func())
+	}
+
+	if _, ok := q.tasks[user]; !ok {
+		q.users = append(q.users, user)
+	}
+
+	q.tasks[user] = append(q.tasks[user], f)
+	q.size++
+}
+
+// pop returns the next task of the user whose turn it is. The user goes back
+// at the end of turns when it has more queued tasks.
+func (q *queue) pop() (func(), bool) {
+	if len(q.users) == 0 {
+		return nil, false
+	}
+
+	user := q.users[0]
+	tasks := q.tasks[user]
+	f := tasks[0]
+	tasks[0] = nil
+
+	q.users = q.users[1:]
+	q.size--
+
+	if len(tasks) > 1 {
+		q.tasks[user] = tasks[1:]
+		q.users = append(q.users, user)
+	} else {
+		delete(q.tasks, user)
+	}
+
+	return f, true
+}
+
+// reservationKey is the context key of the reservation of a pool.
+type reservationKey struct {
+	pool *Pool
+}
+
+// reservation is the number of slots reserved in queue of a pool that have
+// not been used yet.
+type reservation struct {
+	slots int
+}
+
 type Pool struct {
 	ctxCancelFunc context.CancelFunc
-	queue         chan func()
+	maxQueued     int
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	closed   bool
+	classes  [numPriorities]queue
+	reserved int
+
+	// Tasks of higher classes run while the class had queued tasks
+	skipped [numPriorities]int
+
+	// Gauges of queued tasks and busy workers
+	queued prometheus.Gauge
+	busy   prometheus.Gauge
 }
 
 type Pools map[string]*Pool
@@ -18,34 +165,284 @@ const (
 	Renderer = "renderer"
 )
 
-func New(ctx context.Context, maxWorker int) *Pool {
+// New returns a new pool with the given name and number of workers. At most
+// maxQueued tasks can wait for a worker, zero meaning no limit. Name is used
+// to label the metrics of the pool.
+func New(ctx context.Context, name string, maxWorker, maxQueued int) *Pool {
 	if maxWorker <= 0 {
 		maxWorker = runtime.NumCPU()
 	}
 
-	queue := make(chan func(), maxWorker)
 	ctx, cancel := context.WithCancel(ctx)
 
+	pool := &Pool{
+		ctxCancelFunc: cancel,
+		maxQueued:     maxQueued,
+		queued:        metrics.WorkerQueued.WithLabelValues(name),
+		busy:          metrics.WorkerBusy.WithLabelValues(name),
+	}
+	pool.cond = sync.NewCond(&pool.mu)
+
 	for range maxWorker {
 		go func() {
 			for {
-				select {
-				case f := <-queue:
-					f()
-				case <-ctx.Done():
+				f, ok := pool.next()
+				if !ok {
 					return
 				}
+
+				pool.busy.Inc()
+				f()
+				pool.busy.Dec()
 			}
 		}()
 	}
 
-	return &Pool{cancel, queue}
+	// Wake up idle workers so that they return once queued tasks have run
+	context.AfterFunc(ctx, func() {
+		pool.mu.Lock()
+		pool.closed = true
+		pool.mu.Unlock()
+
+		pool.cond.Broadcast()
+	})
+
+	return pool
+}
+
+// next waits for the next task to run. It returns false when pool is done
+// and no tasks are queued anymore.
+func (w *Pool) next() (func(), bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for {
+		sizes := w.sizes()
+		if i := pick(&sizes, &w.skipped); i >= 0 {
+			f, _ := w.classes[i].pop()
+			w.queued.Dec()
+
+			return f, true
+		}
+
+		if w.closed {
+			return nil, false
+		}
+
+		w.cond.Wait()
+	}
+}
+
+// pick returns the class whose task runs next given the number of queued
+// tasks per class in sizes, or -1 when no tasks are queued. It takes the task
+// from sizes and updates the number of tasks skipped by waiting classes.
+func pick(sizes, skipped *[numPriorities]int) int {
+	next := -1
+
+	for i, size := range sizes {
+		if size == 0 {
+			continue
+		}
+
+		if next < 0 {
+			next = i
+		} else if skipped[i] >= priorityShare {
+			next = i
+
+			break
+		}
+	}
+
+	if next < 0 {
+		return -1
+	}
+
+	sizes[next]--
+	skipped[next] = 0
+
+	for i := next + 1; i < numPriorities; i++ {
+		if sizes[i] > 0 {
+			skipped[i]++
+		} else {
+			skipped[i] = 0
+		}
+	}
+
+	return next
+}
+
+// sizes returns the number of queued tasks per class.
+func (w *Pool) sizes() [numPriorities]int {
+	var sizes [numPriorities]int
+	for i, q := range w.classes {
+		sizes[i] = q.size
+	}
+
+	return sizes
+}
+
+// size returns the number of queued tasks.
+func (w *Pool) size() int {
+	var size int
+	for _, q := range w.classes {
+		size += q.size
+	}
+
+	return size
+}
+
+// Reserve reserves n slots in queue for the tasks of a batch so that either
+// all of them or none are admitted. A batch larger than the queue is only
+// admitted when nothing else is queued. It returns a copy of ctx with which
+// the tasks are submitted by Do and a function releasing the slots that have
+// not been used. It returns a QueueFullError when the slots cannot be
+// reserved.
+func (w *Pool) Reserve(ctx context.Context, n int) (context.Context, func(), error) {
+	if err := ctx.Err(); err != nil {
+		return ctx, func() {}, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return ctx, func() {}, ErrClosed
+	}
+
+	if pending := w.size() + w.reserved; w.maxQueued > 0 && pending > 0 && pending+n > w.maxQueued {
+		return ctx, func() {}, &QueueFullError{Position: pending + 1}
+	}
+
+	r := &reservation{slots: n}
+	w.reserved += n
+
+	release := func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		w.reserved -= r.slots
+		r.slots = 0
+	}
+
+	return context.WithValue(ctx, reservationKey{w}, r), release, nil
+}
+
+// Do queues f to be run by a worker of pool on behalf of the user of ctx set
+// by WithUser. Slots reserved in ctx by Reserve are used first. Otherwise it
+// returns a QueueFullError when too many tasks are queued. It returns the
+// error of ctx when it is done. Once queued, f is always run, even when pool
+// is done.
+func (w *Pool) Do(ctx context.Context, f func()) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	o := ownerFromContext(ctx)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return ErrClosed
+	}
+
+	if r, ok := ctx.Value(reservationKey{w}).(*reservation); ok && r.slots > 0 {
+		r.slots--
+		w.reserved--
+	} else if pending := w.size() + w.reserved; w.maxQueued > 0 && pending >= w.maxQueued {
+		return &QueueFullError{Position: pending + 1}
+	}
+
+	w.classes[o.priority].push(o.user, f)
+	w.queued.Inc()
+	w.cond.Signal()
+
+	return nil
 }
 
-func (w *Pool) Do(f func()) {
-	w.queue <- f
+// Position returns the position in queue of the next task of user, one
+// being the next task to run. It returns zero when user has no queued tasks.
+// Position can change as tasks of higher priority or of other users are
+// queued.
+func (w *Pool) Position(user string) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for i, q := range w.classes {
+		idx := slices.Index(q.users, user)
+		if idx < 0 {
+			continue
+		}
+
+		// Users of class take turns, so the task is the (idx+1)th one of
+		// class to run. Follow the turns of classes until then
+		sizes, skipped := w.sizes(), w.skipped
+
+		var position int
+
+		for turns := idx + 1; turns > 0; {
+			position++
+
+			if pick(&sizes, &skipped) == i {
+				turns--
+			}
+		}
+
+		return position
+	}
+
+	return 0
 }
 
 func (w *Pool) Done() {
 	w.ctxCancelFunc()
 }
+
+// Reserve reserves slots in the queues of pools for the number of tasks of
+// a batch per pool name, so that either all of them or none are admitted.
+// See Pool.Reserve.
+func (p Pools) Reserve(ctx context.Context, tasks map[string]int) (context.Context, func(), error) {
+	var releases []func()
+
+	release := func() {
+		for _, r := range releases {
+			r()
+		}
+	}
+
+	for name, n := range tasks {
+		pool, ok := p[name]
+		if !ok || n == 0 {
+			continue
+		}
+
+		var (
+			r   func()
+			err error
+		)
+
+		if ctx, r, err = pool.Reserve(ctx, n); err != nil {
+			release()
+
+			return ctx, func() {}, err
+		}
+
+		releases = append(releases, r)
+	}
+
+	return ctx, release, nil
+}
+
+// Position returns the smallest position in queues of pools of the tasks
+// of user or zero when user has no queued tasks.
+func (p Pools) Position(user string) int {
+	var position int
+
+	for _, pool := range p {
+		if pos := pool.Position(user); pos > 0 && (position == 0 || pos < position) {
+			position = pos
+		}
+	}
+
+	return position
+}
@@ -1,9 +1,15 @@
 package worker_test
 
 import (
+	"context"
+	"errors"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/metrics"
 	"github.com/asanluis/grafana-dashboard-reporter-app/pkg/plugin/worker"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -12,17 +18,250 @@ func TestPool(t *testing.T) {
 
 	ctx := t.Context()
 
-	pool := worker.New(ctx, 1)
+	pool := worker.New(ctx, "test", 1, 0)
 
 	resultCh := make(chan int, 10)
 
 	for i := range 10 {
-		pool.Do(func() {
+		assert.NoError(t, pool.Do(ctx, func() {
 			resultCh <- i
-		})
+		}))
 	}
 
 	for i := range 10 {
 		assert.Equal(t, i, <-resultCh)
 	}
 }
+
+func TestPoolMetrics(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+
+	pool := worker.New(ctx, "metrics", 1, 0)
+
+	startedCh := make(chan struct{})
+	releaseCh := make(chan struct{})
+	doneCh := make(chan struct{}, 2)
+
+	for range 2 {
+		assert.NoError(t, pool.Do(ctx, func() {
+			startedCh <- struct{}{}
+			<-releaseCh
+			doneCh <- struct{}{}
+		}))
+	}
+
+	// First task is running and second one is waiting for the worker
+	<-startedCh
+	assert.InDelta(t, 1, testutil.ToFloat64(metrics.WorkerBusy.WithLabelValues("metrics")), 0)
+	assert.InDelta(t, 1, testutil.ToFloat64(metrics.WorkerQueued.WithLabelValues("metrics")), 0)
+
+	releaseCh <- struct{}{}
+	<-startedCh
+	releaseCh <- struct{}{}
+
+	<-doneCh
+	<-doneCh
+
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(metrics.WorkerBusy.WithLabelValues("metrics")) == 0 &&
+			testutil.ToFloat64(metrics.WorkerQueued.WithLabelValues("metrics")) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestPoolCancel(t *testing.T) {
+	t.Parallel()
+
+	pool := worker.New(t.Context(), "cancel", 1, 0)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	assert.ErrorIs(t, pool.Do(ctx, func() {}), context.Canceled)
+}
+
+func TestPoolFairShare(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+
+	pool := worker.New(ctx, "fair", 1, 7)
+
+	startedCh := make(chan struct{})
+	releaseCh := make(chan struct{})
+	resultCh := make(chan string, 8)
+
+	// Keep the worker busy while tasks are queued
+	assert.NoError(t, pool.Do(ctx, func() {
+		close(startedCh)
+		<-releaseCh
+	}))
+	<-startedCh
+
+	for range 4 {
+		assert.NoError(t, pool.Do(worker.WithUser(ctx, "alice", worker.Interactive), func() { resultCh <- "alice" }))
+	}
+
+	for range 2 {
+		assert.NoError(t, pool.Do(worker.WithUser(ctx, "bob", worker.Interactive), func() { resultCh <- "bob" }))
+	}
+
+	assert.NoError(t, pool.Do(worker.WithUser(ctx, "carol", worker.Scheduled), func() { resultCh <- "carol" }))
+
+	assert.Equal(t, 1, pool.Position("alice"))
+	assert.Equal(t, 2, pool.Position("bob"))
+	assert.Equal(t, 5, pool.Position("carol"))
+	assert.Equal(t, 0, pool.Position("dave"))
+	assert.Equal(t, 1, worker.Pools{worker.Browser: pool}.Position("alice"))
+
+	// Queue is full
+	assert.ErrorIs(t, pool.Do(worker.WithUser(ctx, "dave", worker.Interactive), func() {}), worker.ErrQueueFull)
+
+	close(releaseCh)
+
+	var order []string
+	for range 7 {
+		order = append(order, <-resultCh)
+	}
+
+	// Users take turns and scheduled tasks take a turn after their share of
+	// interactive ones
+	assert.Equal(t, []string{"alice", "bob", "alice", "bob", "carol", "alice", "alice"}, order)
+}
+
+func TestPoolScheduledShare(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+
+	pool := worker.New(ctx, "share", 1, 0)
+
+	startedCh := make(chan struct{})
+	releaseCh := make(chan struct{})
+	resultCh := make(chan string, 64)
+
+	// Keep the worker busy while tasks are queued
+	assert.NoError(t, pool.Do(ctx, func() {
+		close(startedCh)
+		<-releaseCh
+	}))
+	<-startedCh
+
+	interactive := worker.WithUser(ctx, "alice", worker.Interactive)
+
+	// Every interactive task queues another one so that interactive work
+	// does not run out before the scheduled tasks have run
+	var queued atomic.Int32
+
+	var runInteractive func()
+	runInteractive = func() {
+		resultCh <- "alice"
+
+		if queued.Add(1) <= 16 {
+			assert.NoError(t, pool.Do(interactive, runInteractive))
+		}
+	}
+
+	for range 4 {
+		assert.NoError(t, pool.Do(interactive, runInteractive))
+	}
+
+	for range 3 {
+		assert.NoError(t, pool.Do(worker.WithUser(ctx, "bob", worker.Scheduled), func() { resultCh <- "bob" }))
+	}
+
+	assert.Equal(t, 5, pool.Position("bob"))
+
+	close(releaseCh)
+
+	var positions []int
+
+	for i := 1; i <= 23; i++ {
+		if <-resultCh == "bob" {
+			positions = append(positions, i)
+		}
+	}
+
+	// Scheduled tasks run after every four interactive ones
+	assert.Equal(t, []int{5, 10, 15}, positions)
+}
+
+func TestPoolReserve(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+
+	pool := worker.New(ctx, "reserve", 1, 3)
+
+	startedCh := make(chan struct{})
+	releaseCh := make(chan struct{})
+	resultCh := make(chan int, 5)
+
+	// A batch larger than the queue is admitted when nothing is queued
+	batchCtx, release, err := pool.Reserve(ctx, 5)
+	assert.NoError(t, err)
+
+	assert.NoError(t, pool.Do(batchCtx, func() {
+		close(startedCh)
+		<-releaseCh
+	}))
+	<-startedCh
+
+	// Other batches are rejected as a whole while slots are reserved
+	_, _, err = pool.Reserve(ctx, 1)
+
+	var queueErr *worker.QueueFullError
+	assert.ErrorAs(t, err, &queueErr)
+	assert.ErrorIs(t, err, worker.ErrQueueFull)
+	assert.Equal(t, 5, queueErr.Position)
+
+	for i := range 4 {
+		assert.NoError(t, pool.Do(batchCtx, func() { resultCh <- i }))
+	}
+
+	// Unused slots are released
+	release()
+	close(releaseCh)
+
+	for i := range 4 {
+		assert.Equal(t, i, <-resultCh)
+	}
+
+	_, release, err = pool.Reserve(ctx, 3)
+	assert.NoError(t, err)
+
+	release()
+}
+
+func TestPoolDoneRunsQueuedTasks(t *testing.T) {
+	t.Parallel()
+
+	pool := worker.New(t.Context(), "done", 1, 0)
+
+	startedCh := make(chan struct{})
+	releaseCh := make(chan struct{})
+	resultCh := make(chan int, 3)
+
+	assert.NoError(t, pool.Do(t.Context(), func() {
+		close(startedCh)
+		<-releaseCh
+	}))
+	<-startedCh
+
+	for i := range 3 {
+		assert.NoError(t, pool.Do(t.Context(), func() { resultCh <- i }))
+	}
+
+	pool.Done()
+	close(releaseCh)
+
+	// Tasks queued before pool is done are still run
+	for i := range 3 {
+		assert.Equal(t, i, <-resultCh)
+	}
+
+	assert.Eventually(t, func() bool {
+		return errors.Is(pool.Do(t.Context(), func() {}), worker.ErrClosed)
+	}, time.Second, 10*time.Millisecond)
+}